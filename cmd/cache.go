@@ -0,0 +1,139 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chef/chef-analyze/pkg/formatter"
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
+
+var (
+	cacheFlags struct {
+		olderThan string
+		maxSize   string
+	}
+	cacheCmd = &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the local cache of downloaded cookbooks",
+		Long: `Manage the local cache of downloaded cookbooks, cookbook versions are only
+downloaded again when their files change on the Chef Infra Server and files
+shared between versions are stored only once.
+`,
+	}
+	cacheListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the cookbook versions inside the cache",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			usage, err := newCookbookCache().List()
+			if err != nil {
+				return err
+			}
+
+			formattedSummary := formatter.CacheListSummary(usage)
+			fmt.Println(formattedSummary.Report)
+			if formattedSummary.Errors != "" {
+				fmt.Println(formattedSummary.Errors)
+			}
+			return nil
+		},
+	}
+	cachePruneCmd = &cobra.Command{
+		Use:   "prune",
+		Short: "Remove cookbook versions that have not been used recently",
+		Long: `Removes the cookbook versions that have not been used for longer than
+the --older-than duration and, when --max-size is provided, the least recently
+used cookbook versions until the cache fits in that size. Files that are no
+longer used by any cookbook version are removed as well.
+`,
+		Example: `  chef-analyze cache prune --older-than 2w
+  chef-analyze cache prune --older-than 0 --max-size 500MB`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			olderThan, err := reporting.ParseDuration(cacheFlags.olderThan)
+			if err != nil {
+				return err
+			}
+
+			var maxSize int64
+			if cacheFlags.maxSize != "" {
+				maxSize, err = reporting.ParseByteSize(cacheFlags.maxSize)
+				if err != nil {
+					return err
+				}
+			}
+
+			result, err := newCookbookCache().Prune(olderThan, maxSize)
+			if err != nil {
+				return err
+			}
+
+			for _, removed := range result.Removed {
+				fmt.Printf("Removed %s (%s)\n", removed.Name, removed.Version)
+			}
+			fmt.Printf("Pruned %d cookbook versions, %d files, %d cookstyle results and %d run journals\n",
+				len(result.Removed), result.NumFiles, result.NumResults, result.NumRuns)
+			return nil
+		},
+	}
+	cacheCleanCmd = &cobra.Command{
+		Use:   "clean",
+		Short: "Remove every cookbook version from the cache",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			err := newCookbookCache().Clean()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Cache cleaned (%s)\n", globalFlags.cacheDir)
+			return nil
+		},
+	}
+)
+
+func init() {
+	cachePruneCmd.PersistentFlags().StringVar(
+		&cacheFlags.olderThan,
+		"older-than", "30d",
+		"remove cookbook versions not used for this long, like '30d', '2w' or '12h' (0 to disable)",
+	)
+	cachePruneCmd.PersistentFlags().StringVar(
+		&cacheFlags.maxSize,
+		"max-size", "",
+		"remove the least recently used cookbook versions until the cache fits, like '500MB' or '2GB'",
+	)
+
+	// adds the list command as a sub-command of the cache command
+	// => chef-analyze cache list
+	cacheCmd.AddCommand(cacheListCmd)
+	// adds the prune command as a sub-command of the cache command
+	// => chef-analyze cache prune
+	cacheCmd.AddCommand(cachePruneCmd)
+	// adds the clean command as a sub-command of the cache command
+	// => chef-analyze cache clean
+	cacheCmd.AddCommand(cacheCleanCmd)
+}
+
+// the cache commands only manage local files, they don't need a Chef Infra Server
+func newCookbookCache() *reporting.CookbookCache {
+	return reporting.NewCookbookCache(globalFlags.cacheDir, nil)
+}
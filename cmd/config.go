@@ -17,35 +17,127 @@
 
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chef/go-libs/credentials"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/chef/chef-analyze/pkg/formatter"
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
 
 var (
+	configFlags struct {
+		force bool
+	}
 	configCmd = &cobra.Command{
-		Use:    "config",
-		Hidden: true, // this will avoid the command to be displayed in the help/usage message
-		Short:  "Manage your local Chef configuration (default: $HOME/.chef/credentials)",
+		Use:   "config",
+		Short: "Manage your local Chef configuration (default: $HOME/.chef/credentials)",
 	}
 	configVerifyCmd = &cobra.Command{
 		Use:   "verify",
 		Short: "Verify your Chef configuration",
-		Args:  cobra.NoArgs,
-		RunE: func(_ *cobra.Command, args []string) error {
-			// TODO @afiune verify the config
+		Long: `Verifies that the selected credentials profile can be used to generate
+reports by loading it, parsing the client key, sending a signed request to
+the Chef Infra Server and probing the permissions this tool needs, that is,
+searching nodes, listing cookbooks and downloading cookbooks.
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status := reporting.VerifyConfig(
+				func() (credentials.Credentials, error) {
+					return credentials.FromViper(globalFlags.profile, overrideCredentials())
+				},
+				globalFlags.noSSLverify,
+			)
+
+			fmt.Print(formatter.MakeConfigStatusTXT(status).Report)
+
+			if !status.Passed() {
+				// the checklist already explains what went wrong
+				cmd.SilenceUsage = true
+				return errors.New("configuration verification failed")
+			}
 			return nil
 		},
 	}
 	configInitCmd = &cobra.Command{
 		Use:   "init",
 		Short: "Initialize a local Chef configuration",
-		Args:  cobra.NoArgs,
+		Long: `Creates a new profile inside your Chef credentials file, or creates the file
+if it doesn't exist yet. Any setting that is not provided with a flag is
+prompted interactively.
+
+The client key must be a valid RSA private key and an existing profile is
+never overwritten unless the --force flag is provided.
+`,
+		Example: `  chef-analyze config init
+  chef-analyze config init --profile dev \
+    --chef_server_url https://chef.example.com/organizations/dev \
+    --client_name admin --client_key ~/.chef/admin.pem`,
+		Args: cobra.NoArgs,
 		RunE: func(_ *cobra.Command, args []string) error {
-			// TODO @afiune help user initialize a local config file
+			credsFile := viper.ConfigFileUsed()
+			if credsFile == "" {
+				var err error
+				credsFile, err = reporting.DefaultCredentialsFile()
+				if err != nil {
+					return err
+				}
+			}
+
+			profile := reporting.CredentialsProfile{
+				Name: globalFlags.profile,
+				CredsDetail: credentials.CredsDetail{
+					ChefServerUrl: globalFlags.chefServerURL,
+					ClientName:    globalFlags.clientName,
+					ClientKey:     globalFlags.clientKey,
+				},
+			}
+
+			err := promptMissingSettings(os.Stdin, &profile)
+			if err != nil {
+				return err
+			}
+
+			if profile.ClientKey != "" {
+				profile.ClientKey, err = expandPath(profile.ClientKey)
+				if err != nil {
+					return err
+				}
+			}
+
+			err = profile.Validate()
+			if err != nil {
+				return err
+			}
+
+			err = reporting.SaveCredentialsProfile(credsFile, profile, configFlags.force)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Profile '%s' saved to '%s'\n", profile.Name, credsFile)
 			return nil
 		},
 	}
 )
 
 func init() {
+	configInitCmd.PersistentFlags().BoolVarP(
+		&configFlags.force,
+		"force", "f", false,
+		"overwrite the profile if it already exists",
+	)
+
 	// adds the verify command as a sub-command of the config command
 	// => chef-analyze config verify
 	configCmd.AddCommand(configVerifyCmd)
@@ -53,3 +145,57 @@ func init() {
 	// => chef-analyze config init
 	configCmd.AddCommand(configInitCmd)
 }
+
+// asks the user for every setting of the profile that was not provided via flags
+func promptMissingSettings(in io.Reader, profile *reporting.CredentialsProfile) error {
+	var (
+		reader  = bufio.NewReader(in)
+		prompts = []struct {
+			question string
+			value    *string
+		}{
+			{"Chef Infra Server URL (https://chef.example.com/organizations/my-org)", &profile.ChefServerUrl},
+			{"Chef Infra Server API client name", &profile.ClientName},
+			{"Path to the API client key", &profile.ClientKey},
+		}
+	)
+
+	for _, p := range prompts {
+		if *p.value != "" {
+			continue
+		}
+
+		fmt.Printf("%s: ", p.question)
+		answer, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return errors.Wrap(err, "unable to read answer")
+		}
+		*p.value = strings.TrimSpace(answer)
+
+		if err == io.EOF {
+			// nothing else to read, let the validation report what is missing
+			fmt.Println()
+			break
+		}
+	}
+
+	return nil
+}
+
+// expands a leading '~' to the home directory and makes the path absolute,
+// profiles are used from any directory so relative paths are not an option
+func expandPath(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", errors.Wrap(err, "unable to detect home directory")
+		}
+		path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to expand path '%s'", path)
+	}
+	return abs, nil
+}
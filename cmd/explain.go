@@ -0,0 +1,78 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chef/chef-analyze/pkg/formatter"
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
+
+var (
+	explainCmd = &cobra.Command{
+		Use:   "explain",
+		Short: "Explain how artifacts of a Chef Infra Server are related",
+	}
+	explainNodeCmd = &cobra.Command{
+		Use:   "node NAME",
+		Short: "Explain why a node applies every one of its cookbooks",
+		Long: `Expands the run list of a node the way the Chef Infra Client does and prints,
+for every cookbook the node applied, the chain that pulled it in:
+
+  role -> nested role -> recipe -> cookbook dependencies
+
+Roles use the run list of the environment of the node when they have one, and
+dependencies are resolved from the metadata of the cookbook versions that the
+node applied. Missing roles and role cycles are reported as well.
+`,
+		Example: `  chef-analyze explain node web01`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			chefClient, err := newChefClientFromFlags()
+			if err != nil {
+				return err
+			}
+
+			expansion, err := reporting.ExpandNodeRunList(
+				chefClient.Nodes,
+				reporting.NewChefRoleRunLists(chefClient),
+				chefClient.Cookbooks,
+				args[0],
+			)
+			if err != nil {
+				return err
+			}
+
+			results := formatter.MakeExplainNodeTXT(expansion)
+			fmt.Print(results.Report)
+			if results.Errors != "" {
+				fmt.Printf("\nErrors:\n%s", results.Errors)
+			}
+
+			return nil
+		},
+	}
+)
+
+func init() {
+	// adds the node command as a sub-command of the explain command
+	// => chef-analyze explain node
+	explainCmd.AddCommand(explainNodeCmd)
+}
@@ -0,0 +1,143 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/chef/chef-analyze/pkg/formatter"
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
+
+const repNameFixes = "fixes"
+
+var (
+	fixCmd = &cobra.Command{
+		Use:   "fix COOKBOOK [CONSTRAINT...] [COOKBOOK [CONSTRAINT...]...]",
+		Short: "Auto-correct cookbook violations and generate patches",
+		Long: `Downloads the selected cookbook versions and runs the cookstyle auto-correct
+on a copy of them, the same ChefDeprecations and ChefCorrectness cops that the
+upgrade verification of the cookbooks report uses are corrected.
+
+A unified diff is saved for every cookbook version that was corrected, apply it
+inside the cookbook directory with 'patch -p1'. The report lists the violations
+that were corrected and the ones that remain and need manual work.
+
+Cookbooks are selected by name or glob pattern, each of them can be followed by
+one or more Chef-style version constraints (=, !=, >, <, >=, <=, ~>).
+`,
+		Example: `  chef-analyze fix apache2 '~> 5.0'
+  chef-analyze fix 'mysql*' nginx '>= 1.0' '< 2.0'`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			filters, err := reporting.ParseCookbookFilters(args)
+			if err != nil {
+				return err
+			}
+
+			chefClient, err := newChefClientFromFlags()
+			if err != nil {
+				return err
+			}
+
+			err = createOutputDirectories()
+			if err != nil {
+				return err
+			}
+
+			fixState, err := reporting.NewFixes(
+				chefClient.Cookbooks,
+				filters,
+				fixFlags.workers,
+				func(fs *reporting.FixStatus) {
+					fs.Cache = reporting.NewCookbookCache(globalFlags.cacheDir, chefClient.Cookbooks)
+				},
+			)
+			if err != nil {
+				return err
+			}
+
+			formattedSummary := formatter.FixesReportSummary(fixState)
+			fmt.Println(formattedSummary.Report)
+
+			for _, fix := range fixState.Fixes {
+				err = savePatch(fix)
+				if err != nil {
+					return err
+				}
+			}
+
+			results := formatter.MakeFixesReportTXT(fixState)
+			err = saveReport(repNameFixes, TxtExt, results.Report)
+			if err != nil {
+				return err
+			}
+			err = saveErrorReport(repNameFixes, results.Errors)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+	fixFlags struct {
+		workers int
+	}
+)
+
+func init() {
+	fixCmd.PersistentFlags().IntVarP(
+		&fixFlags.workers,
+		"workers", "w", 50,
+		"maximum number of parallel workers at once",
+	)
+}
+
+func patchesDir() string {
+	return filepath.Join(globalFlags.cacheDir, "patches")
+}
+
+// saves the patch of a corrected cookbook version, if any
+func savePatch(fix *reporting.CookbookFix) error {
+	if fix.Patch == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(patchesDir(), os.ModePerm); err != nil {
+		return errors.Wrap(err, "unable to create patches/ directory")
+	}
+
+	var (
+		patchName      = fmt.Sprintf("%s-%s-%s.patch", fix.Name, fix.Version, timestamp)
+		patchPath      = filepath.Join(patchesDir(), patchName)
+		patchFile, err = os.Create(patchPath)
+	)
+	if err != nil {
+		return errors.Wrapf(err, "unable to save patch for cookbook %s", fix.Name)
+	}
+
+	patchFile.WriteString(fix.Patch)
+	patchFile.Close()
+
+	fmt.Printf("Patch for %s (%s) saved to %s\n", fix.Name, fix.Version, patchPath)
+	return nil
+}
@@ -0,0 +1,139 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
+
+var (
+	generateCmd = &cobra.Command{
+		Use:   "generate",
+		Short: "Generate artifacts from a Chef Infra Server",
+	}
+	generateEffortlessCmd = &cobra.Command{
+		Use:   "effortless",
+		Short: "Generate an Effortless package from a node or a role",
+		Long: `Generates a ready-to-build Effortless Infra package from the expanded run list
+of a node or a role, it contains:
+
+  Policyfile.rb    the expanded run list with its cookbooks pinned to the versions in use
+  habitat/plan.sh  a Habitat plan that uses the Effortless Infra scaffolding
+  kitchen.yml      a Test Kitchen configuration to verify the policy
+
+The run list of a node is expanded using the environment run lists of its roles.
+The cookbooks of a node are pinned to the versions it applied in its last Chef
+Infra Client run, the cookbooks of a role are pinned to the latest versions
+available on the Chef Infra Server. Attributes are not migrated.
+`,
+		Example: `  chef-analyze generate effortless --node web01
+  chef-analyze generate effortless --role webserver --origin my_origin --output-dir webserver`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if (effortlessFlags.node == "") == (effortlessFlags.role == "") {
+				return errors.New("either --node or --role is required")
+			}
+
+			chefClient, err := newChefClientFromFlags()
+			if err != nil {
+				return err
+			}
+
+			var pkg *reporting.EffortlessPackage
+			if effortlessFlags.node != "" {
+				pkg, err = reporting.NewEffortlessFromNode(
+					reporting.NewChefSearch(chefClient),
+					reporting.NewChefRoleRunLists(chefClient),
+					chefClient.Cookbooks,
+					effortlessFlags.node,
+				)
+			} else {
+				pkg, err = reporting.NewEffortlessFromRole(
+					reporting.NewChefRoleRunLists(chefClient),
+					chefClient.Cookbooks,
+					effortlessFlags.role,
+				)
+			}
+			if err != nil {
+				return err
+			}
+			pkg.Origin = effortlessFlags.origin
+			pkg.ChefServerURL = chefClient.BaseURL.String()
+
+			outputDir := effortlessFlags.outputDir
+			if outputDir == "" {
+				outputDir = "effortless-" + pkg.Name
+			}
+
+			files, err := pkg.Write(outputDir, effortlessFlags.force)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Effortless package generated from the %s (%d recipes, %d cookbooks)\n",
+				pkg.Source, len(pkg.RunList), len(pkg.Cookbooks))
+			for _, file := range files {
+				fmt.Printf(" - %s\n", file)
+			}
+			fmt.Printf("Build it with 'hab pkg build %s'\n", outputDir)
+			return nil
+		},
+	}
+	effortlessFlags struct {
+		node      string
+		role      string
+		origin    string
+		outputDir string
+		force     bool
+	}
+)
+
+func init() {
+	generateEffortlessCmd.PersistentFlags().StringVar(
+		&effortlessFlags.node,
+		"node", "",
+		"generate the package from the run list of this node",
+	)
+	generateEffortlessCmd.PersistentFlags().StringVar(
+		&effortlessFlags.role,
+		"role", "",
+		"generate the package from the run list of this role",
+	)
+	generateEffortlessCmd.PersistentFlags().StringVar(
+		&effortlessFlags.origin,
+		"origin", "example",
+		"Habitat origin of the package",
+	)
+	generateEffortlessCmd.PersistentFlags().StringVar(
+		&effortlessFlags.outputDir,
+		"output-dir", "",
+		"directory to generate the package in (default \"effortless-<name>\")",
+	)
+	generateEffortlessCmd.PersistentFlags().BoolVarP(
+		&effortlessFlags.force,
+		"force", "f", false,
+		"overwrite the files of an existing package",
+	)
+	// adds the effortless command as a sub-command of the generate command
+	// => chef-analyze generate effortless
+	generateCmd.AddCommand(generateEffortlessCmd)
+}
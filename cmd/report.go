@@ -24,6 +24,7 @@ import (
 	"strings"
 	"time"
 
+	chef "github.com/chef/go-chef"
 	"github.com/chef/go-libs/credentials"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -32,87 +33,184 @@ import (
 	"github.com/chef/chef-analyze/pkg/reporting"
 )
 
-// TODO @afiune make this configurable
 const (
-	AnalyzeCacheDir  = ".analyze-cache"
-	repNameCookbooks = "cookbooks"
-	repNameNodes     = "nodes"
-	ErrExt           = "err"
-	TxtExt           = "txt"
-	CsvExt           = "csv"
+	repNameCookbooks    = "cookbooks"
+	repNameNodes        = "nodes"
+	repNameRoles        = "roles"
+	repNameEnvs         = "environments"
+	repNameDataBags     = "data-bags"
+	repNamePolicies     = "policies"
+	repNameDependencies = "dependencies"
+	repNameClients      = "clients-versions"
+	repNameOSSupport    = "os-support"
+	ErrExt              = "err"
+	TxtExt              = "txt"
+	CsvExt              = "csv"
+	JsonExt             = "json"
+	DotExt              = "dot"
+	HtmlExt             = "html"
+	SarifExt            = "sarif"
 )
 
 var (
-	timestamp  = time.Now().Format("20060102150405")
-	reportsDir = filepath.Join(AnalyzeCacheDir, "reports")
-	errorsDir  = filepath.Join(AnalyzeCacheDir, "errors")
-	reportCmd  = &cobra.Command{
+	timestamp = time.Now().Format("20060102150405")
+	reportCmd = &cobra.Command{
 		Use:   "report",
 		Short: "Generate reports from a Chef Infra Server",
 	}
 	reportCookbooksCmd = &cobra.Command{
-		Use:   "cookbooks",
+		Use:   "cookbooks [COOKBOOK [CONSTRAINT...]...]",
 		Short: "Generates a cookbook oriented report",
-		Args:  cobra.NoArgs,
+		Args:  cobra.ArbitraryArgs,
 		Long: `Generates cookbook oriented reports containing details about the number of
 violations each cookbook has, which violations can be are auto-corrected and
-the number of nodes using each cookbook.
+the number of nodes using each cookbook. Cookbooks locked by active policy
+revisions are considered in use, even if no node has converged with them yet.
+
+By default, every cookbook version is analyzed, to analyze only a subset of
+cookbooks provide their names or glob patterns, each of them can be followed
+by one or more Chef-style version constraints (=, !=, >, <, >=, <=, ~>).
 
 These reports could take a long time to run depending on the number of cookbooks
 to analyze and therefore reports will be written to disk. The location will be
 provided when the report is generated.
+
+Every cookbook version analyzed is checkpointed to a run journal inside the
+cache directory, if a run is interrupted, resume it with --resume and the ID
+of the run, only the remaining cookbook versions will be analyzed. A run must
+be resumed with the same flags and cookbook filters it was started with.
+
+When --active-only is provided, nodes that haven't checked in within the period
+provided with --stale-after, or that never checked in, don't count as using a
+cookbook.
+
+Use --format sarif to export the cookstyle violations in the Static Analysis
+Results Interchange Format (SARIF) consumed by code scanning dashboards, it
+implies --verify-upgrade.
+
+When --estimate is provided, the violations of every cookbook version are
+converted into hours and displayed as a ranked backlog, rolled up by team and
+organization. The minutes per violation, per cop, the multipliers by number of
+nodes and the teams owning the cookbooks are defined in a JSON effort model
+provided with --effort-model:
+
+  {
+    "minutes_per_correctable": 5,
+    "minutes_per_manual": 30,
+    "minutes_per_cop": {"ChefDeprecations/ResourceUsesOnlyResourceName": 60},
+    "node_multipliers": [{"min_nodes": 10, "multiplier": 1.5}],
+    "teams": {"web": ["apache2", "nginx*"]}
+  }
 `,
-		RunE: func(_ *cobra.Command, _ []string) error {
-			creds, err := credentials.FromViper(
-				globalFlags.profile,
-				overrideCredentials(),
-			)
+		Example: `  chef-analyze report cookbooks
+  chef-analyze report cookbooks apache2 '~> 5.0'
+  chef-analyze report cookbooks 'mysql*' nginx '>= 1.0' '< 2.0'
+  chef-analyze report cookbooks --verify-upgrade --resume 20191216-103000-a1b2
+  chef-analyze report cookbooks --estimate --effort-model ./effort.json`,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if err := validateReportFormat("txt", "csv", "json", "html", "sarif"); err != nil {
+				return err
+			}
 
+			filters, err := reporting.ParseCookbookFilters(args)
 			if err != nil {
 				return err
 			}
 
-			err = createOutputDirectories()
+			staleAfter, err := reporting.ParseDuration(cookbooksFlags.staleAfter)
 			if err != nil {
 				return err
 			}
 
-			cfg := &reporting.Reporting{Credentials: creds}
-			if globalFlags.noSSLverify {
-				cfg.NoSSLVerify = true
+			effortModel := reporting.DefaultEffortModel
+			if cookbooksFlags.effortModel != "" {
+				effortModel, err = reporting.LoadEffortModel(cookbooksFlags.effortModel)
+				if err != nil {
+					return err
+				}
 			}
 
-			chefClient, err := reporting.NewChefClient(cfg)
+			chefClient, err := newChefClientFromFlags()
 			if err != nil {
 				return err
 			}
 
+			err = createOutputDirectories()
+			if err != nil {
+				return err
+			}
+
+			cache := reporting.NewCookbookCache(globalFlags.cacheDir, chefClient.Cookbooks)
+			journal, err := openRunJournal(cache.RunsDir())
+			if err != nil {
+				return err
+			}
+			// on failures the journal stays on disk to resume the run, once the
+			// report is saved it is closed and removed explicitly
+			journalClosed := false
+			defer func() {
+				if !journalClosed {
+					journal.Close()
+				}
+			}()
+
+			// estimating the effort and the SARIF format require the cookstyle violations
 			cookbooksState, err := reporting.NewCookbooks(
 				chefClient.Cookbooks,
-				chefClient.Search,
-				cookbooksFlags.runCookstyle,
+				reporting.NewChefSearch(chefClient),
+				cookbooksFlags.runCookstyle || cookbooksFlags.estimate || reportsFlags.format == "sarif",
 				cookbooksFlags.onlyUnused,
 				cookbooksFlags.workers,
+				func(cbs *reporting.CookbooksStatus) {
+					cbs.Filters = filters
+					cbs.Policies = reporting.NewChefPolicies(chefClient)
+					cbs.Cache = cache
+					cbs.Journal = journal
+					cbs.Cookstyle.CacheDir = cbs.Cache.CookstyleDir()
+					cbs.Cookstyle.Refresh = cookbooksFlags.noCache
+					if cookbooksFlags.activeOnly {
+						cbs.StaleAfter = staleAfter
+					}
+				},
 			)
 			if err != nil {
 				return err
 			}
 
 			var (
-				formattedSummary = formatter.CookbooksReportSummary(cookbooksState)
-				results          *formatter.FormattedResult
-				ext              string
+				formattedSummary  = formatter.CookbooksReportSummary(cookbooksState)
+				formattedEstimate formatter.FormattedResult
+				results           *formatter.FormattedResult
+				ext               string
 			)
 
 			fmt.Println(formattedSummary.Report)
+			if cookbooksFlags.estimate {
+				formattedEstimate = formatter.EffortEstimateSummary(
+					reporting.NewEffortEstimate(cookbooksState, effortModel),
+				)
+				fmt.Println(formattedEstimate.Report)
+			}
 
 			switch reportsFlags.format {
 			case "csv":
 				ext = CsvExt
 				results = formatter.MakeCookbooksReportCSV(cookbooksState)
+			case "json":
+				ext = JsonExt
+				results = formatter.MakeCookbooksReportJSON(cookbooksState)
+			case "html":
+				ext = HtmlExt
+				results = formatter.MakeCookbooksReportHTML(cookbooksState)
+			case "sarif":
+				ext = SarifExt
+				results = formatter.MakeCookbooksReportSARIF(cookbooksState)
 			default:
 				ext = TxtExt
 				results = formatter.MakeCookbooksReportTXT(cookbooksState)
+				if results.Report != "" {
+					results.Report += formattedEstimate.Report
+				}
 			}
 
 			err = saveReport(repNameCookbooks, ext, results.Report)
@@ -124,59 +222,101 @@ provided when the report is generated.
 				return err
 			}
 
-			return nil
+			// the report is saved, there is nothing left to resume
+			journalClosed = true
+			if err := journal.Close(); err != nil {
+				return err
+			}
+			return journal.Remove()
 		},
 	}
 	reportNodesCmd = &cobra.Command{
 		Use:   "nodes",
 		Short: "Generates a nodes oriented report",
-		Args:  cobra.NoArgs,
-		RunE: func(_ *cobra.Command, _ []string) error {
-			creds, err := credentials.FromViper(
-				globalFlags.profile,
-				overrideCredentials(),
-			)
+		Long: `Generates a nodes oriented report containing the Chef Infra Client version,
+the operating system and the cookbooks applied to each node.
 
-			if err != nil {
+By default, every node is analyzed, to analyze only a subset of nodes use the
+filter flags, all of them are combined into a single search query.
+
+The report ends with a hygiene section that lists the nodes that haven't
+checked in within the period provided with --stale-after, or that never checked
+in, and the nodes that share the same fqdn, hostname or IP address, usually
+leftovers of rebuilt hosts.
+`,
+		Example: `  chef-analyze report nodes --environment production
+  chef-analyze report nodes --role webserver --platform ubuntu
+  chef-analyze report nodes --query 'name:web*'
+  chef-analyze report nodes --stale-after 30d`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := validateReportFormat("txt", "csv", "json", "html"); err != nil {
 				return err
 			}
 
-			err = createOutputDirectories()
+			staleAfter, err := reporting.ParseDuration(nodesFlags.staleAfter)
 			if err != nil {
 				return err
 			}
 
-			cfg := &reporting.Reporting{Credentials: creds}
-			if globalFlags.noSSLverify {
-				cfg.NoSSLVerify = true
+			chefClient, err := newChefClientFromFlags()
+			if err != nil {
+				return err
 			}
 
-			chefClient, err := reporting.NewChefClient(cfg)
+			err = createOutputDirectories()
 			if err != nil {
 				return err
 			}
 
-			fmt.Println("Analyzing nodes...")
-			report, err := reporting.Nodes(chefClient.Search)
+			filter := reporting.NodesFilter{
+				Environment: nodesFlags.environment,
+				Role:        nodesFlags.role,
+				PolicyGroup: nodesFlags.policyGroup,
+				Platform:    nodesFlags.platform,
+				Query:       nodesFlags.query,
+			}
+			if filter.IsEmpty() {
+				fmt.Println("Analyzing nodes...")
+			} else {
+				fmt.Printf("Analyzing nodes... (search query: %s)\n", filter.SearchStatement())
+			}
+			nodes, err := reporting.Nodes(reporting.NewChefSearch(chefClient), filter)
 			if err != nil {
 				return err
 			}
+			warnHiddenNodes(nodes)
+			report := nodes.Records
 
 			var (
+				hygiene          = reporting.NewNodesHygiene(report, staleAfter, time.Now())
 				formattedSummary = formatter.NodesReportSummary(report)
+				formattedHygiene = formatter.NodesHygieneSummary(hygiene)
 				results          *formatter.FormattedResult
 				ext              string
 			)
 
 			fmt.Println(formattedSummary.Report)
+			if len(report) != 0 {
+				fmt.Println(formattedHygiene.Report)
+			}
 
 			switch reportsFlags.format {
 			case "csv":
 				ext = CsvExt
 				results = formatter.MakeNodesReportCSV(report)
+			case "json":
+				ext = JsonExt
+				results = formatter.MakeNodesReportJSON(report, filter)
+			case "html":
+				ext = HtmlExt
+				results = formatter.MakeNodesReportHTML(report, filter)
 			default:
 				ext = TxtExt
-				results = formatter.MakeNodesReportTXT(report)
+				results = formatter.MakeNodesReportTXT(report, filter)
+				if results.Report != "" {
+					results.Report += formattedHygiene.Report
+				}
 			}
 
 			err = saveReport(repNameNodes, ext, results.Report)
@@ -194,7 +334,21 @@ provided when the report is generated.
 	cookbooksFlags struct {
 		onlyUnused   bool
 		runCookstyle bool
+		noCache      bool
+		activeOnly   bool
+		staleAfter   string
+		resume       string
 		workers      int
+		estimate     bool
+		effortModel  string
+	}
+	nodesFlags struct {
+		environment string
+		role        string
+		policyGroup string
+		platform    string
+		query       string
+		staleAfter  string
 	}
 	reportsFlags struct {
 		format string
@@ -206,7 +360,8 @@ func init() {
 	reportCmd.PersistentFlags().StringVarP(
 		&reportsFlags.format,
 		"format", "f", "txt",
-		"output format: txt is human readable, csv is machine readable",
+		"output format: txt is human readable, csv and json are machine readable, html is a self-contained web page, "+
+			"not every report supports every format",
 	)
 
 	// cookbooks cmd flags
@@ -225,28 +380,134 @@ func init() {
 		"verify-upgrade", "v", false,
 		"verify the upgrade compatibility of every cookbook",
 	)
+	reportCookbooksCmd.PersistentFlags().BoolVar(
+		&cookbooksFlags.noCache,
+		"no-cache", false,
+		"ignore the cached cookstyle results and analyze every cookbook again",
+	)
+	reportCookbooksCmd.PersistentFlags().BoolVar(
+		&cookbooksFlags.activeOnly,
+		"active-only", false,
+		"only count the nodes that checked in within the stale period as using a cookbook",
+	)
+	reportCookbooksCmd.PersistentFlags().StringVar(
+		&cookbooksFlags.staleAfter,
+		"stale-after", "30d",
+		"period without a check-in after which a node is stale, nodes that never checked in are stale too (e.g. 30d, 2w, 12h)",
+	)
+	reportCookbooksCmd.PersistentFlags().StringVar(
+		&cookbooksFlags.resume,
+		"resume", "",
+		"resume an interrupted run by its ID, only the remaining cookbook versions are analyzed",
+	)
+	reportCookbooksCmd.PersistentFlags().BoolVar(
+		&cookbooksFlags.estimate,
+		"estimate", false,
+		"estimate the hours to upgrade every cookbook version, implies --verify-upgrade",
+	)
+	reportCookbooksCmd.PersistentFlags().StringVar(
+		&cookbooksFlags.effortModel,
+		"effort-model", "",
+		"JSON file with the effort model used by --estimate (default: 5 minutes per auto-correctable and 30 per manual violation)",
+	)
 	// adds the cookbooks command as a sub-command of the report command
 	// => chef-analyze report cookbooks
 	reportCmd.AddCommand(reportCookbooksCmd)
 
+	// nodes cmd flags
+	reportNodesCmd.PersistentFlags().StringVarP(
+		&nodesFlags.environment,
+		"environment", "e", "",
+		"only analyze nodes in the provided environment",
+	)
+	reportNodesCmd.PersistentFlags().StringVarP(
+		&nodesFlags.role,
+		"role", "r", "",
+		"only analyze nodes with the provided role in their expanded run list",
+	)
+	reportNodesCmd.PersistentFlags().StringVarP(
+		&nodesFlags.policyGroup,
+		"policy-group", "g", "",
+		"only analyze nodes in the provided policy group",
+	)
+	reportNodesCmd.PersistentFlags().StringVarP(
+		&nodesFlags.platform,
+		"platform", "P", "",
+		"only analyze nodes running the provided platform",
+	)
+	reportNodesCmd.PersistentFlags().StringVarP(
+		&nodesFlags.query,
+		"query", "q", "",
+		"only analyze nodes matching the provided search query (Solr syntax)",
+	)
+	reportNodesCmd.PersistentFlags().StringVar(
+		&nodesFlags.staleAfter,
+		"stale-after", "30d",
+		"period without a check-in after which a node is stale, nodes that never checked in are stale too (e.g. 30d, 2w, 12h)",
+	)
 	// adds the nodes command as a sub-command of the report command
 	// => chef-analyze report nodes
 	reportCmd.AddCommand(reportNodesCmd)
 
 }
 
-func createOutputDirectories() error {
-	err := os.MkdirAll(errorsDir, os.ModePerm)
+// creates a Chef Infra Server client from the credentials and global flags
+func newChefClientFromFlags() (*chef.Client, error) {
+	creds, err := credentials.FromViper(
+		globalFlags.profile,
+		overrideCredentials(),
+	)
 	if err != nil {
-		return errors.Wrap(err, "unable to create reports/ directory")
+		return nil, err
 	}
-	err = os.MkdirAll(reportsDir, os.ModePerm)
+
+	cfg := &reporting.Reporting{Credentials: creds}
+	if globalFlags.noSSLverify {
+		cfg.NoSSLVerify = true
+	}
+
+	return reporting.NewChefClient(cfg)
+}
+
+// reports and errors are saved inside the cache directory
+func reportsDir() string {
+	return filepath.Join(globalFlags.cacheDir, "reports")
+}
+
+func errorsDir() string {
+	return filepath.Join(globalFlags.cacheDir, "errors")
+}
+
+func createOutputDirectories() error {
+	err := os.MkdirAll(errorsDir(), os.ModePerm)
 	if err != nil {
 		return errors.Wrap(err, "unable to create errors/ directory")
 	}
+	err = os.MkdirAll(reportsDir(), os.ModePerm)
+	if err != nil {
+		return errors.Wrap(err, "unable to create reports/ directory")
+	}
 	return nil
 }
 
+// warns about the nodes that a search didn't return, the report doesn't include them
+func warnHiddenNodes(nodes *reporting.NodesResult) {
+	if nodes.HiddenNodes != 0 {
+		fmt.Printf("Warning: %s\n", reporting.HiddenNodesWarning(nodes.HiddenNodes))
+	}
+}
+
+// returns an error if the --format flag is not one of the formats supported by a report
+func validateReportFormat(supported ...string) error {
+	for _, format := range supported {
+		if reportsFlags.format == format {
+			return nil
+		}
+	}
+	return errors.Errorf("unsupported format '%s' for this report, supported formats: %s",
+		reportsFlags.format, strings.Join(supported, ", "))
+}
+
 func saveErrorReport(baseName string, content string) error {
 	if content == "" {
 		return nil
@@ -254,7 +515,7 @@ func saveErrorReport(baseName string, content string) error {
 
 	var (
 		reportName      = fmt.Sprintf("%s-%s.%s", baseName, timestamp, "err")
-		reportPath      = filepath.Join(errorsDir, reportName)
+		reportPath      = filepath.Join(errorsDir(), reportName)
 		reportFile, err = os.Create(reportPath)
 	)
 	if err != nil {
@@ -268,6 +529,25 @@ func saveErrorReport(baseName string, content string) error {
 	return nil
 }
 
+// opens the journal of the run to resume or creates a new one
+func openRunJournal(dir string) (*reporting.RunJournal, error) {
+	if cookbooksFlags.resume == "" {
+		journal, err := reporting.NewRunJournal(dir)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("Starting run %s, if interrupted, resume it with '--resume %s'\n", journal.ID, journal.ID)
+		return journal, nil
+	}
+
+	journal, err := reporting.OpenRunJournal(dir, cookbooksFlags.resume)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Resuming run %s (%d cookbook versions already analyzed)\n", journal.ID, journal.NumCompleted())
+	return journal, nil
+}
+
 func saveReport(baseName string, ext string, content string) error {
 	if len(content) == 0 {
 		return nil
@@ -275,7 +555,7 @@ func saveReport(baseName string, ext string, content string) error {
 
 	var (
 		reportName      = fmt.Sprintf("%s-%s.%s", baseName, timestamp, ext)
-		reportPath      = filepath.Join(reportsDir, reportName)
+		reportPath      = filepath.Join(reportsDir(), reportName)
 		reportFile, err = os.Create(reportPath) // create a new report file
 	)
 	if err != nil {
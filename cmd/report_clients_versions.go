@@ -0,0 +1,131 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chef/chef-analyze/pkg/formatter"
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
+
+var (
+	reportClientsVersionsCmd = &cobra.Command{
+		Use:     "clients-versions",
+		Aliases: []string{"client-versions"},
+		Short:   "Generates a Chef Infra Client versions report",
+		Long: `Generates a report of the Chef Infra Client versions running across the fleet,
+grouped by major.minor version, including the number and percentage of nodes
+running every version together with their Ohai and Ruby versions.
+
+Every version is flagged as supported or end-of-life (eol) according to the
+support lifecycle of the Chef Infra Client embedded in this tool, and the
+nodes running an unsupported release are listed at the end of the report.
+
+Use --eol-data to provide a JSON file that adds major releases or overrides
+the embedded end-of-life dates, releases without an "eol" are supported:
+
+  [
+    {"major": 18, "eol": "2025-11-30"},
+    {"major": 19}
+  ]
+`,
+		Example: `  chef-analyze report clients-versions
+  chef-analyze report clients-versions --eol-data ./client-eol.json --format csv`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := validateReportFormat("txt", "csv"); err != nil {
+				return err
+			}
+
+			var (
+				releases = reporting.ChefClientReleases
+				err      error
+			)
+			if clientsVersionsFlags.eolData != "" {
+				releases, err = reporting.LoadChefClientReleases(clientsVersionsFlags.eolData)
+				if err != nil {
+					return err
+				}
+			}
+
+			chefClient, err := newChefClientFromFlags()
+			if err != nil {
+				return err
+			}
+
+			err = createOutputDirectories()
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Analyzing nodes...")
+			nodes, err := reporting.Nodes(reporting.NewChefSearch(chefClient), reporting.NodesFilter{})
+			if err != nil {
+				return err
+			}
+			warnHiddenNodes(nodes)
+
+			var (
+				report           = reporting.NewClientVersions(nodes.Records, releases, time.Now())
+				formattedSummary = formatter.ClientVersionsReportSummary(report)
+				results          *formatter.FormattedResult
+				ext              string
+			)
+
+			fmt.Println(formattedSummary.Report)
+
+			switch reportsFlags.format {
+			case "csv":
+				ext = CsvExt
+				results = formatter.MakeClientVersionsReportCSV(report)
+			default:
+				ext = TxtExt
+				results = formatter.MakeClientVersionsReportTXT(report)
+			}
+
+			err = saveReport(repNameClients, ext, results.Report)
+			if err != nil {
+				return err
+			}
+			err = saveErrorReport(repNameClients, results.Errors)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+	clientsVersionsFlags struct {
+		eolData string
+	}
+)
+
+func init() {
+	// clients-versions cmd flags
+	reportClientsVersionsCmd.PersistentFlags().StringVar(
+		&clientsVersionsFlags.eolData,
+		"eol-data", "",
+		"JSON file with Chef Infra Client end-of-life dates that extend or override the embedded ones",
+	)
+	// adds the clients-versions command as a sub-command of the report command
+	// => chef-analyze report clients-versions
+	reportCmd.AddCommand(reportClientsVersionsCmd)
+}
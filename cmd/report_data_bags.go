@@ -0,0 +1,103 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chef/chef-analyze/pkg/formatter"
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
+
+var (
+	reportDataBagsCmd = &cobra.Command{
+		Use:   "data-bags",
+		Short: "Generates a data bags oriented report",
+		Long: `Generates a data bags oriented report containing the number of items of every
+data bag, which items are encrypted and their encryption format version.
+
+Encrypted items are detected without the secret, items encrypted with the
+legacy format (version 1) are highlighted.
+`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := validateReportFormat("txt", "csv"); err != nil {
+				return err
+			}
+
+			chefClient, err := newChefClientFromFlags()
+			if err != nil {
+				return err
+			}
+
+			err = createOutputDirectories()
+			if err != nil {
+				return err
+			}
+
+			dataBagsState, err := reporting.NewDataBags(chefClient.DataBags, dataBagsFlags.workers)
+			if err != nil {
+				return err
+			}
+
+			var (
+				formattedSummary = formatter.DataBagsReportSummary(dataBagsState)
+				results          *formatter.FormattedResult
+				ext              string
+			)
+
+			fmt.Println(formattedSummary.Report)
+
+			switch reportsFlags.format {
+			case "csv":
+				ext = CsvExt
+				results = formatter.MakeDataBagsReportCSV(dataBagsState)
+			default:
+				ext = TxtExt
+				results = formatter.MakeDataBagsReportTXT(dataBagsState)
+			}
+
+			err = saveReport(repNameDataBags, ext, results.Report)
+			if err != nil {
+				return err
+			}
+			err = saveErrorReport(repNameDataBags, results.Errors)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+	dataBagsFlags struct {
+		workers int
+	}
+)
+
+func init() {
+	// data-bags cmd flags
+	reportDataBagsCmd.PersistentFlags().IntVarP(
+		&dataBagsFlags.workers,
+		"workers", "w", 50,
+		"maximum number of parallel workers at once",
+	)
+	// adds the data-bags command as a sub-command of the report command
+	// => chef-analyze report data-bags
+	reportCmd.AddCommand(reportDataBagsCmd)
+}
@@ -0,0 +1,114 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chef/chef-analyze/pkg/formatter"
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
+
+var (
+	reportDependenciesCmd = &cobra.Command{
+		Use:   "dependencies",
+		Short: "Generates a cookbook dependencies report",
+		Long: `Generates a report of the dependency graph of every uploaded cookbook version,
+built from the dependencies declared in their metadata. Every dependency is
+resolved to the latest uploaded version that satisfies its constraint.
+
+The report flags the dependencies that no uploaded version satisfies, the
+cookbooks that depend on each other (circular dependencies) and the leaf
+dependencies, cookbooks that other cookbooks depend on and that have no
+dependencies of their own.
+
+Use --format dot to export the graph in the Graphviz DOT language or
+--format json to export it in a machine readable format.
+`,
+		Example: `  chef-analyze report dependencies
+  chef-analyze report dependencies --format dot`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := validateReportFormat("txt", "json", "dot"); err != nil {
+				return err
+			}
+
+			chefClient, err := newChefClientFromFlags()
+			if err != nil {
+				return err
+			}
+
+			err = createOutputDirectories()
+			if err != nil {
+				return err
+			}
+
+			graph, err := reporting.NewDependencyGraph(chefClient.Cookbooks, dependenciesFlags.workers)
+			if err != nil {
+				return err
+			}
+
+			var (
+				formattedSummary = formatter.DependenciesReportSummary(graph)
+				results          *formatter.FormattedResult
+				ext              string
+			)
+
+			fmt.Println(formattedSummary.Report)
+
+			switch reportsFlags.format {
+			case "dot":
+				ext = DotExt
+				results = formatter.MakeDependenciesReportDOT(graph)
+			case "json":
+				ext = JsonExt
+				results = formatter.MakeDependenciesReportJSON(graph)
+			default:
+				ext = TxtExt
+				results = formatter.MakeDependenciesReportTXT(graph)
+			}
+
+			err = saveReport(repNameDependencies, ext, results.Report)
+			if err != nil {
+				return err
+			}
+			err = saveErrorReport(repNameDependencies, results.Errors)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+	dependenciesFlags struct {
+		workers int
+	}
+)
+
+func init() {
+	// dependencies cmd flags
+	reportDependenciesCmd.PersistentFlags().IntVarP(
+		&dependenciesFlags.workers,
+		"workers", "w", 50,
+		"maximum number of parallel workers at once",
+	)
+	// adds the dependencies command as a sub-command of the report command
+	// => chef-analyze report dependencies
+	reportCmd.AddCommand(reportDependenciesCmd)
+}
@@ -0,0 +1,132 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chef/chef-analyze/pkg/formatter"
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
+
+var (
+	reportEnvironmentsCmd = &cobra.Command{
+		Use:   "environments",
+		Short: "Generates an environments oriented report",
+		Long: `Generates an environments oriented report containing the cookbook version
+constraints of every environment, whether any uploaded cookbook version
+satisfies them and the number of nodes in each environment.
+
+The constraints of every environment are solved together with the dependencies
+of the cookbooks they pin, the same way the Chef Infra Server does, the report
+shows the cookbook versions the server would pick or, when they can't be
+resolved together, the cookbook and the constraints that conflict.
+
+When --verify-upgrade is provided, the cookbook versions pinned by the
+constraints are verified for upgrade compatibility.
+`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := validateReportFormat("txt", "csv"); err != nil {
+				return err
+			}
+
+			chefClient, err := newChefClientFromFlags()
+			if err != nil {
+				return err
+			}
+
+			err = createOutputDirectories()
+			if err != nil {
+				return err
+			}
+
+			envsState, err := reporting.NewEnvironments(
+				chefClient.Environments,
+				chefClient.Cookbooks,
+				reporting.NewChefSearch(chefClient),
+				environmentsFlags.runCookstyle,
+				environmentsFlags.workers,
+				func(cbs *reporting.CookbooksStatus) {
+					cbs.Cache = reporting.NewCookbookCache(globalFlags.cacheDir, chefClient.Cookbooks)
+					cbs.Cookstyle.CacheDir = cbs.Cache.CookstyleDir()
+					cbs.Cookstyle.Refresh = environmentsFlags.noCache
+				},
+			)
+			if err != nil {
+				return err
+			}
+
+			var (
+				formattedSummary = formatter.EnvironmentsReportSummary(envsState)
+				results          *formatter.FormattedResult
+				ext              string
+			)
+
+			fmt.Println(formattedSummary.Report)
+
+			switch reportsFlags.format {
+			case "csv":
+				ext = CsvExt
+				results = formatter.MakeEnvironmentsReportCSV(envsState)
+			default:
+				ext = TxtExt
+				results = formatter.MakeEnvironmentsReportTXT(envsState)
+			}
+
+			err = saveReport(repNameEnvs, ext, results.Report)
+			if err != nil {
+				return err
+			}
+			err = saveErrorReport(repNameEnvs, results.Errors)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+	environmentsFlags struct {
+		runCookstyle bool
+		noCache      bool
+		workers      int
+	}
+)
+
+func init() {
+	// environments cmd flags
+	reportEnvironmentsCmd.PersistentFlags().IntVarP(
+		&environmentsFlags.workers,
+		"workers", "w", 50,
+		"maximum number of parallel workers at once",
+	)
+	reportEnvironmentsCmd.PersistentFlags().BoolVarP(
+		&environmentsFlags.runCookstyle,
+		"verify-upgrade", "v", false,
+		"verify the upgrade compatibility of the pinned cookbook versions",
+	)
+	reportEnvironmentsCmd.PersistentFlags().BoolVar(
+		&environmentsFlags.noCache,
+		"no-cache", false,
+		"ignore the cached cookstyle results and analyze every cookbook again",
+	)
+	// adds the environments command as a sub-command of the report command
+	// => chef-analyze report environments
+	reportCmd.AddCommand(reportEnvironmentsCmd)
+}
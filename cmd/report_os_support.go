@@ -0,0 +1,139 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chef/chef-analyze/pkg/formatter"
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
+
+var (
+	reportOSSupportCmd = &cobra.Command{
+		Use:     "os-support",
+		Aliases: []string{"os-eol"},
+		Short:   "Generates an operating system end-of-life report",
+		Long: `Generates a report of the operating system releases running across the fleet,
+the platform and platform version of every node are mapped to the end-of-life
+date of their release and the nodes are grouped into supported, near-eol
+(reaching their end-of-life within the period provided with --near-eol) and
+eol releases. Nodes whose platform has no releases in the table are mapped
+using their platform family, like rocky and almalinux nodes to the rhel releases.
+
+The end-of-life dates come from a table embedded in this tool, use --eol-data
+to provide a JSON file that adds releases or overrides the embedded dates:
+
+  [
+    {"platform": "ubuntu", "version": "16.04", "eol": "2026-04-30"},
+    {"platform": "rocky", "version": "8", "eol": "2029-05-31"}
+  ]
+`,
+		Example: `  chef-analyze report os-support
+  chef-analyze report os-support --near-eol 365d
+  chef-analyze report os-support --eol-data ./os-eol.json --format csv`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := validateReportFormat("txt", "csv"); err != nil {
+				return err
+			}
+
+			nearEOL, err := reporting.ParseDuration(osSupportFlags.nearEOL)
+			if err != nil {
+				return err
+			}
+
+			releases := reporting.OSReleases
+			if osSupportFlags.eolData != "" {
+				releases, err = reporting.LoadOSReleases(osSupportFlags.eolData)
+				if err != nil {
+					return err
+				}
+			}
+
+			chefClient, err := newChefClientFromFlags()
+			if err != nil {
+				return err
+			}
+
+			err = createOutputDirectories()
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Analyzing nodes...")
+			nodes, err := reporting.Nodes(reporting.NewChefSearch(chefClient), reporting.NodesFilter{})
+			if err != nil {
+				return err
+			}
+			warnHiddenNodes(nodes)
+
+			var (
+				report           = reporting.NewOSSupport(nodes.Records, releases, nearEOL, time.Now())
+				formattedSummary = formatter.OSSupportReportSummary(report)
+				results          *formatter.FormattedResult
+				ext              string
+			)
+
+			fmt.Println(formattedSummary.Report)
+
+			switch reportsFlags.format {
+			case "csv":
+				ext = CsvExt
+				results = formatter.MakeOSSupportReportCSV(report)
+			default:
+				ext = TxtExt
+				results = formatter.MakeOSSupportReportTXT(report)
+			}
+
+			err = saveReport(repNameOSSupport, ext, results.Report)
+			if err != nil {
+				return err
+			}
+			err = saveErrorReport(repNameOSSupport, results.Errors)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+	osSupportFlags struct {
+		nearEOL string
+		eolData string
+	}
+)
+
+func init() {
+	// os-support cmd flags
+	reportOSSupportCmd.PersistentFlags().StringVar(
+		&osSupportFlags.nearEOL,
+		"near-eol", "180d",
+		"period before the end-of-life date of a release in which its nodes are near-eol (e.g. 90d, 26w)",
+	)
+	reportOSSupportCmd.PersistentFlags().StringVar(
+		&osSupportFlags.eolData,
+		"eol-data", "",
+		"JSON file with operating system end-of-life dates that extend or override the embedded ones",
+	)
+	// adds the os-support command as a sub-command of the report command
+	// => chef-analyze report os-support
+	reportCmd.AddCommand(reportOSSupportCmd)
+}
@@ -0,0 +1,93 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chef/chef-analyze/pkg/formatter"
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
+
+var reportPoliciesCmd = &cobra.Command{
+	Use:   "policies",
+	Short: "Generates a Policyfiles oriented report",
+	Long: `Generates a Policyfiles oriented report containing the policy revisions that
+are active in every policy group, their locked cookbook versions and the number
+of nodes using them.
+`,
+	Args: cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		if err := validateReportFormat("txt", "csv"); err != nil {
+			return err
+		}
+
+		chefClient, err := newChefClientFromFlags()
+		if err != nil {
+			return err
+		}
+
+		err = createOutputDirectories()
+		if err != nil {
+			return err
+		}
+
+		policiesState, err := reporting.NewPolicies(
+			reporting.NewChefPolicies(chefClient),
+			reporting.NewChefSearch(chefClient),
+		)
+		if err != nil {
+			return err
+		}
+
+		var (
+			formattedSummary = formatter.PoliciesReportSummary(policiesState)
+			results          *formatter.FormattedResult
+			ext              string
+		)
+
+		fmt.Println(formattedSummary.Report)
+
+		switch reportsFlags.format {
+		case "csv":
+			ext = CsvExt
+			results = formatter.MakePoliciesReportCSV(policiesState)
+		default:
+			ext = TxtExt
+			results = formatter.MakePoliciesReportTXT(policiesState)
+		}
+
+		err = saveReport(repNamePolicies, ext, results.Report)
+		if err != nil {
+			return err
+		}
+		err = saveErrorReport(repNamePolicies, results.Errors)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	// adds the policies command as a sub-command of the report command
+	// => chef-analyze report policies
+	reportCmd.AddCommand(reportPoliciesCmd)
+}
@@ -0,0 +1,94 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chef/chef-analyze/pkg/formatter"
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
+
+var reportRolesCmd = &cobra.Command{
+	Use:   "roles",
+	Short: "Generates a roles oriented report",
+	Long: `Generates a roles oriented report containing the run list of every role, the
+nested roles it pulls in, the roles, cookbooks and recipes it references that
+don't exist on the Chef Infra Server and the number of nodes using it.
+`,
+	Args: cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		if err := validateReportFormat("txt", "csv"); err != nil {
+			return err
+		}
+
+		chefClient, err := newChefClientFromFlags()
+		if err != nil {
+			return err
+		}
+
+		err = createOutputDirectories()
+		if err != nil {
+			return err
+		}
+
+		rolesState, err := reporting.NewRoles(
+			chefClient.Roles,
+			chefClient.Cookbooks,
+			reporting.NewChefSearch(chefClient),
+		)
+		if err != nil {
+			return err
+		}
+
+		var (
+			formattedSummary = formatter.RolesReportSummary(rolesState)
+			results          *formatter.FormattedResult
+			ext              string
+		)
+
+		fmt.Println(formattedSummary.Report)
+
+		switch reportsFlags.format {
+		case "csv":
+			ext = CsvExt
+			results = formatter.MakeRolesReportCSV(rolesState)
+		default:
+			ext = TxtExt
+			results = formatter.MakeRolesReportTXT(rolesState)
+		}
+
+		err = saveReport(repNameRoles, ext, results.Report)
+		if err != nil {
+			return err
+		}
+		err = saveErrorReport(repNameRoles, results.Errors)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	// adds the roles command as a sub-command of the report command
+	// => chef-analyze report roles
+	reportCmd.AddCommand(reportRolesCmd)
+}
@@ -24,6 +24,8 @@ import (
 	"github.com/chef/go-libs/credentials"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+
+	"github.com/chef/chef-analyze/pkg/reporting"
 )
 
 var (
@@ -34,6 +36,7 @@ var (
 		chefServerURL string
 		profile       string
 		noSSLverify   bool
+		cacheDir      string
 	}
 	rootCmd = &cobra.Command{
 		Use:   "chef-analyze",
@@ -83,6 +86,11 @@ func init() {
 		"ssl-no-verify", "o", false,
 		"Disable SSL certificate verification",
 	)
+	rootCmd.PersistentFlags().StringVar(
+		&globalFlags.cacheDir,
+		"cache-dir", reporting.DefaultCacheDir,
+		"Directory to store downloaded cookbooks, reports and errors",
+	)
 	// @afiune we can't use viper to bind the flags since our config doesn't really match
 	// any valid toml structure. (that is, the .chef/credentials toml file)
 	//
@@ -96,6 +104,14 @@ func init() {
 	rootCmd.AddCommand(reportCmd)
 	// adds the config command from 'cmd/config.go'
 	rootCmd.AddCommand(configCmd)
+	// adds the cache command from 'cmd/cache.go'
+	rootCmd.AddCommand(cacheCmd)
+	// adds the fix command from 'cmd/fix.go'
+	rootCmd.AddCommand(fixCmd)
+	// adds the generate command from 'cmd/generate.go'
+	rootCmd.AddCommand(generateCmd)
+	// adds the explain command from 'cmd/explain.go'
+	rootCmd.AddCommand(explainCmd)
 }
 
 func initConfig() {
@@ -114,7 +130,7 @@ func initConfig() {
 			viper.SetConfigFile(credsFile)
 		} else {
 
-			if !hasMinimumParams() && !isHelpCommand() {
+			if !hasMinimumParams() && !isHelpCommand() && !isLocalCommand() {
 				fmt.Printf("Error: %s\n", MissingMinimumParametersErr)
 				rootCmd.Usage()
 				os.Exit(-1)
@@ -149,6 +165,19 @@ func isHelpCommand() bool {
 	return false
 }
 
+// the config commands help users create their credentials and the cache
+// commands only manage local files, therefore, they must work without them
+func isLocalCommand() bool {
+	if len(os.Args) <= 1 {
+		return false
+	}
+	switch os.Args[1] {
+	case "config", "cache":
+		return true
+	}
+	return false
+}
+
 // overrides the credentials from the viper bound flags
 func overrideCredentials() credentials.OverrideFunc {
 	return func(c *credentials.Credentials) {
@@ -19,3 +19,11 @@ do_before() {
 do_prepare() {
   export GOFLAGS="-mod=vendor"
 }
+
+do_install() {
+  do_default_install
+
+  # publish the JSON schemas of our reports alongside the binary
+  mkdir -p "${pkg_prefix}/share/schemas"
+  cp -r "${SRC_PATH}/schemas/." "${pkg_prefix}/share/schemas/"
+}
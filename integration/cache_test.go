@@ -0,0 +1,105 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package integration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheCommand_ListEmpty(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the cache commands don't need credentials
+	out, stderr, exitcode := ChefAnalyze("cache", "list", "--cache-dir", dir)
+	assert.Contains(t,
+		out.String(),
+		"The cache is empty.",
+		"STDOUT message doesn't match")
+	assert.Empty(t,
+		stderr.String(),
+		"STDERR should be empty")
+	assert.Equal(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
+
+func TestCacheCommand_PruneAndClean(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a cookbook downloaded before the cache had manifests
+	cookbookDir := filepath.Join(dir, "cookbooks", "foo-1.0.0")
+	if err := os.MkdirAll(cookbookDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(cookbookDir, "metadata.rb"), []byte("name 'foo'"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, exitcode := ChefAnalyze("cache", "list", "--cache-dir", dir)
+	assert.Contains(t,
+		out.String(),
+		"1 cookbook versions",
+		"STDOUT message doesn't match")
+	assert.Equal(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+
+	out, _, exitcode = ChefAnalyze("cache", "prune", "--cache-dir", dir, "--older-than", "30d")
+	assert.Contains(t,
+		out.String(),
+		"Removed foo (1.0.0)",
+		"STDOUT message doesn't match")
+	assert.Equal(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+
+	out, _, exitcode = ChefAnalyze("cache", "clean", "--cache-dir", dir)
+	assert.Contains(t,
+		out.String(),
+		"Cache cleaned",
+		"STDOUT message doesn't match")
+	assert.Equal(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
+
+func TestCacheCommand_PruneInvalidFlags(t *testing.T) {
+	_, stderr, exitcode := ChefAnalyze("cache", "prune", "--older-than", "a while")
+	assert.Contains(t,
+		stderr.String(),
+		"Error: invalid duration 'a while'",
+		"STDERR message doesn't match")
+	assert.NotEqual(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+
+	_, stderr, exitcode = ChefAnalyze("cache", "prune", "--max-size", "huge")
+	assert.Contains(t,
+		stderr.String(),
+		"Error: invalid size 'huge'",
+		"STDERR message doesn't match")
+	assert.NotEqual(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
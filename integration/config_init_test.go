@@ -0,0 +1,136 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package integration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigInitCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-init")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		credsFile = filepath.Join(dir, ".chef", "credentials")
+		keyFile   = filepath.Join(dir, "admin.pem")
+		args      = []string{"config", "init",
+			"--credentials", credsFile,
+			"--profile", "dev",
+			"--chef_server_url", "https://chef.example.com/organizations/dev",
+			"--client_name", "admin",
+			"--client_key", keyFile,
+		}
+	)
+	if err := ioutil.WriteFile(keyFile, key(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, stderr, exitcode := ChefAnalyze(args...)
+	assert.Contains(t,
+		out.String(),
+		"Profile 'dev' saved to '"+credsFile+"'",
+		"STDOUT message doesn't match")
+	assert.Empty(t,
+		stderr.String(),
+		"STDERR should be empty")
+	assert.Equal(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+
+	content, err := ioutil.ReadFile(credsFile)
+	if assert.Nil(t, err) {
+		assert.Contains(t, string(content), "[dev]\nclient_name = 'admin'\n")
+	}
+
+	// the profile already exists now
+	_, stderr, exitcode = ChefAnalyze(args...)
+	assert.Contains(t,
+		stderr.String(),
+		"Error: profile 'dev' already exists",
+		"STDERR message doesn't match")
+	assert.NotEqual(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+
+	_, stderr, exitcode = ChefAnalyze(append(args, "--force")...)
+	assert.Empty(t,
+		stderr.String(),
+		"STDERR should be empty")
+	assert.Equal(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
+
+func TestConfigInitCommand_InvalidKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-init")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		credsFile = filepath.Join(dir, "credentials")
+		keyFile   = filepath.Join(dir, "empty.pem")
+	)
+	if err := ioutil.WriteFile(keyFile, []byte(""), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, stderr, exitcode := ChefAnalyze("config", "init",
+		"--credentials", credsFile,
+		"--chef_server_url", "https://chef.example.com/organizations/dev",
+		"--client_name", "admin",
+		"--client_key", keyFile,
+	)
+	assert.Contains(t,
+		stderr.String(),
+		"is not a valid RSA private key",
+		"STDERR message doesn't match")
+	assert.NotEqual(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+
+	_, err = os.Stat(credsFile)
+	assert.True(t, os.IsNotExist(err), "credentials file should not be created")
+}
+
+func TestConfigInitCommand_MissingSettings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-init")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// without a terminal, the prompts have nothing to read
+	out, stderr, exitcode := ChefAnalyze("config", "init",
+		"--credentials", filepath.Join(dir, "credentials"),
+	)
+	assert.Contains(t,
+		out.String(),
+		"Chef Infra Server URL",
+		"STDOUT message doesn't match")
+	assert.Contains(t,
+		stderr.String(),
+		"Error: chef_server_url cannot be empty",
+		"STDERR message doesn't match")
+	assert.NotEqual(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
@@ -0,0 +1,76 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package integration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigVerifyCommand(t *testing.T) {
+	out, err, exitcode := ChefAnalyzeWithCredentials("config", "verify")
+	assert.Contains(t,
+		out.String(),
+		"[ OK ] Organization 'bar' exists",
+		"STDOUT message doesn't match")
+	assert.Contains(t,
+		out.String(),
+		"[SKIP] Permission to download cookbooks",
+		"STDOUT message doesn't match")
+	assert.Contains(t,
+		out.String(),
+		"Your configuration is ready to generate reports.",
+		"STDOUT message doesn't match")
+	assert.Empty(t,
+		err.String(),
+		"STDERR should be empty")
+	assert.Equal(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
+
+func TestConfigVerifyCommand_MissingOrganization(t *testing.T) {
+	out, err, exitcode := ChefAnalyzeWithCredentials("config", "verify", "--profile", "dev")
+	assert.Contains(t,
+		out.String(),
+		"[FAIL] Organization 'dev' exists",
+		"STDOUT message doesn't match")
+	assert.Contains(t,
+		out.String(),
+		"hint: verify the organization name at the end of the chef_server_url",
+		"STDOUT message doesn't match")
+	assert.Contains(t,
+		err.String(),
+		"Error: configuration verification failed",
+		"STDERR message doesn't match")
+	assert.NotContains(t,
+		out.String(),
+		"Usage:",
+		"STDOUT should not display the usage")
+	assert.NotEqual(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
+
+func TestConfigVerifyCommand_InvalidKey(t *testing.T) {
+	out, _, exitcode := ChefAnalyzeWithCredentials("config", "verify", "--profile", "empty")
+	assert.Contains(t,
+		out.String(),
+		"[FAIL] Client key is a valid RSA private key",
+		"STDOUT message doesn't match")
+	assert.NotEqual(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
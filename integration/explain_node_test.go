@@ -0,0 +1,43 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package integration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainNode_NotFound(t *testing.T) {
+	_, err, exitcode := ChefAnalyzeWithCredentials("explain", "node", "web01")
+	assert.Contains(t,
+		err.String(),
+		"Error: unable to get node web01",
+		"STDERR message doesn't match")
+	assert.NotEqual(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
+
+func TestExplainNode_RequiresName(t *testing.T) {
+	_, err, exitcode := ChefAnalyzeWithCredentials("explain", "node")
+	assert.Contains(t,
+		err.String(),
+		"Error: accepts 1 arg(s), received 0",
+		"STDERR message doesn't match")
+	assert.NotEqual(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
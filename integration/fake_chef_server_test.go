@@ -32,6 +32,30 @@ func cookbooksList(w http.ResponseWriter, req *http.Request) {
 	fmt.Fprintf(w, "{}\n")
 }
 
+func rolesList(w http.ResponseWriter, req *http.Request) {
+	fmt.Fprintf(w, "{}\n")
+}
+
+func environmentsList(w http.ResponseWriter, req *http.Request) {
+	fmt.Fprintf(w, "{}\n")
+}
+
+func defaultEnvironment(w http.ResponseWriter, req *http.Request) {
+	fmt.Fprintf(w, "{\"name\": \"_default\"}\n")
+}
+
+func dataBagsList(w http.ResponseWriter, req *http.Request) {
+	fmt.Fprintf(w, "{}\n")
+}
+
+func policiesList(w http.ResponseWriter, req *http.Request) {
+	fmt.Fprintf(w, "{}\n")
+}
+
+func policyGroupsList(w http.ResponseWriter, req *http.Request) {
+	fmt.Fprintf(w, "{}\n")
+}
+
 // start a HTTP server listening on localhost:80 to create a fake Chef Server
 func startFakeChefServer() {
 	// TODO @afiune I think we probably need to have a way to define the responses
@@ -45,6 +69,30 @@ func startFakeChefServer() {
 		fmt.Sprintf("/organizations/%s/cookbooks", DefaultChefServerOrganization),
 		cookbooksList,
 	)
+	http.HandleFunc(
+		fmt.Sprintf("/organizations/%s/roles", DefaultChefServerOrganization),
+		rolesList,
+	)
+	http.HandleFunc(
+		fmt.Sprintf("/organizations/%s/environments", DefaultChefServerOrganization),
+		environmentsList,
+	)
+	http.HandleFunc(
+		fmt.Sprintf("/organizations/%s/environments/_default", DefaultChefServerOrganization),
+		defaultEnvironment,
+	)
+	http.HandleFunc(
+		fmt.Sprintf("/organizations/%s/data", DefaultChefServerOrganization),
+		dataBagsList,
+	)
+	http.HandleFunc(
+		fmt.Sprintf("/organizations/%s/policies", DefaultChefServerOrganization),
+		policiesList,
+	)
+	http.HandleFunc(
+		fmt.Sprintf("/organizations/%s/policy_groups", DefaultChefServerOrganization),
+		policyGroupsList,
+	)
 	// @afiune we use port 80 to use "HTTP" instead of "HTTPS" to avoid signing requests
 	http.ListenAndServe(":80", nil)
 }
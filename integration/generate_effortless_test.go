@@ -0,0 +1,48 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package integration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateEffortless_NodeNotFound(t *testing.T) {
+	_, err, exitcode := ChefAnalyzeWithCredentials("generate", "effortless", "--node", "web01")
+	assert.Contains(t,
+		err.String(),
+		"Error: node 'web01' not found",
+		"STDERR message doesn't match")
+	assert.NotEqual(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
+
+func TestGenerateEffortless_RequiresNodeOrRole(t *testing.T) {
+	for _, args := range [][]string{
+		[]string{"generate", "effortless"},
+		[]string{"generate", "effortless", "--node", "web01", "--role", "base"},
+	} {
+		_, err, exitcode := ChefAnalyzeWithCredentials(args...)
+		assert.Contains(t,
+			err.String(),
+			"Error: either --node or --role is required",
+			"STDERR message doesn't match")
+		assert.NotEqual(t, 0, exitcode,
+			"EXITCODE is not the expected one")
+	}
+}
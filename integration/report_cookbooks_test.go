@@ -39,3 +39,87 @@ func TestReportCommand_Cookbooks(t *testing.T) {
 	assert.Equal(t, 0, exitcode,
 		"EXITCODE is not the expected one")
 }
+
+func TestReportCommand_CookbooksWithFilters(t *testing.T) {
+	out, err, exitcode := ChefAnalyzeWithCredentials("report", "cookbooks", "foo", "~> 1.0", "bar*")
+	assert.Contains(t,
+		out.String(),
+		"Finding available cookbooks... (0 found)",
+		"STDOUT message doesn't match")
+	assert.Empty(t,
+		err.String(),
+		"STDERR should be empty")
+	assert.Equal(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
+
+func TestReportCommand_CookbooksWithInvalidFilters(t *testing.T) {
+	_, err, exitcode := ChefAnalyzeWithCredentials("report", "cookbooks", "~> 1.0", "foo")
+	assert.Contains(t,
+		err.String(),
+		"Error: version constraint '~> 1.0' must be preceded by a cookbook name",
+		"STDERR message doesn't match")
+	assert.NotEqual(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
+
+func TestReportCommand_CookbooksStartsRun(t *testing.T) {
+	out, _, exitcode := ChefAnalyzeWithCredentials("report", "cookbooks")
+	assert.Contains(t,
+		out.String(),
+		"Starting run ",
+		"STDOUT message doesn't match")
+	assert.Equal(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
+
+func TestReportCommand_CookbooksResumeUnknownRun(t *testing.T) {
+	_, err, exitcode := ChefAnalyzeWithCredentials("report", "cookbooks", "--resume", "20191216-103000-a1b2")
+	assert.Contains(t,
+		err.String(),
+		"Error: run '20191216-103000-a1b2' not found",
+		"STDERR message doesn't match")
+	assert.NotEqual(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
+
+func TestReportCommand_CookbooksMissingEffortModel(t *testing.T) {
+	out, err, exitcode := ChefAnalyzeWithCredentials("report", "cookbooks", "--estimate", "--effort-model", "/does/not/exist.json")
+	assert.Contains(t,
+		err.String(),
+		"Error: unable to read effort model '/does/not/exist.json'",
+		"STDERR message doesn't match")
+	assert.NotContains(t,
+		out.String(),
+		"Finding available cookbooks...",
+		"STDOUT message doesn't match")
+	assert.NotEqual(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
+
+func TestReportCommand_CookbooksSARIF(t *testing.T) {
+	out, err, exitcode := ChefAnalyzeWithCredentials("report", "cookbooks", "--format", "sarif")
+	assert.Contains(t,
+		out.String(),
+		"Finding available cookbooks... (0 found)",
+		"STDOUT message doesn't match")
+	assert.Contains(t,
+		out.String(),
+		"No cookbooks available for analysis",
+		"STDOUT message doesn't match")
+	assert.Empty(t,
+		err.String(),
+		"STDERR should be empty")
+	assert.Equal(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
+
+func TestReportCommand_CookbooksUnsupportedFormat(t *testing.T) {
+	_, err, exitcode := ChefAnalyzeWithCredentials("report", "cookbooks", "--format", "dot")
+	assert.Contains(t,
+		err.String(),
+		"Error: unsupported format 'dot' for this report, supported formats: txt, csv, json, html, sarif",
+		"STDERR message doesn't match")
+	assert.NotEqual(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
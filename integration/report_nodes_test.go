@@ -35,3 +35,61 @@ func TestReportCommand_Nodes(t *testing.T) {
 	assert.Equal(t, 0, exitcode,
 		"EXITCODE is not the expected one")
 }
+
+func TestReportCommand_NodesWithFilters(t *testing.T) {
+	out, err, exitcode := ChefAnalyzeWithCredentials("report", "nodes", "--environment", "qa", "--role", "web")
+	assert.Contains(t,
+		out.String(),
+		"Analyzing nodes... (search query: chef_environment:qa AND role:web)",
+		"STDOUT message doesn't match")
+	assert.Contains(t,
+		out.String(),
+		"No nodes found to analyze.",
+		"STDOUT message doesn't match")
+	assert.Empty(t,
+		err.String(),
+		"STDERR should be empty")
+	assert.Equal(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
+
+func TestReportCommand_NodesInvalidStaleAfter(t *testing.T) {
+	out, err, exitcode := ChefAnalyzeWithCredentials("report", "nodes", "--stale-after", "a month")
+	assert.Contains(t,
+		err.String(),
+		"Error: invalid duration 'a month'",
+		"STDERR message doesn't match")
+	assert.NotContains(t,
+		out.String(),
+		"Analyzing nodes...",
+		"the report should not be generated")
+	assert.NotEqual(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
+
+func TestReportCommand_NodesHTML(t *testing.T) {
+	out, err, exitcode := ChefAnalyzeWithCredentials("report", "nodes", "--format", "html")
+	assert.Contains(t,
+		out.String(),
+		"No nodes found to analyze.",
+		"STDOUT message doesn't match")
+	assert.Empty(t,
+		err.String(),
+		"STDERR should be empty")
+	assert.Equal(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
+
+func TestReportCommand_NodesUnsupportedFormat(t *testing.T) {
+	out, err, exitcode := ChefAnalyzeWithCredentials("report", "nodes", "--format", "sarif")
+	assert.Contains(t,
+		err.String(),
+		"Error: unsupported format 'sarif' for this report, supported formats: txt, csv, json, html",
+		"STDERR message doesn't match")
+	assert.NotContains(t,
+		out.String(),
+		"Analyzing nodes...",
+		"the report should not be generated")
+	assert.NotEqual(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
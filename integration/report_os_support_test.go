@@ -0,0 +1,50 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package integration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportCommand_OSSupport(t *testing.T) {
+	out, err, exitcode := ChefAnalyzeWithCredentials("report", "os-support")
+	assert.Contains(t,
+		out.String(),
+		"No nodes found to analyze.",
+		"STDOUT message doesn't match")
+	assert.Empty(t,
+		err.String(),
+		"STDERR should be empty")
+	assert.Equal(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
+
+func TestReportCommand_OSSupportMissingEOLData(t *testing.T) {
+	out, err, exitcode := ChefAnalyzeWithCredentials("report", "os-support", "--eol-data", "/does/not/exist.json")
+	assert.Contains(t,
+		err.String(),
+		"Error: unable to read OS end-of-life data '/does/not/exist.json'",
+		"STDERR message doesn't match")
+	assert.NotContains(t,
+		out.String(),
+		"Analyzing nodes...",
+		"STDOUT message doesn't match")
+	assert.NotEqual(t, 0, exitcode,
+		"EXITCODE is not the expected one")
+}
@@ -20,6 +20,7 @@ package formatter
 import (
 	"encoding/csv"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/chef/chef-analyze/pkg/reporting"
@@ -45,7 +46,7 @@ func MakeCookbooksReportCSV(state *reporting.CookbooksStatus) *FormattedResult {
 			"Message",
 		)
 	}
-	tableHeaders = append(tableHeaders, "Nodes")
+	tableHeaders = append(tableHeaders, "Nodes", "Policies")
 	csvWriter.Write(tableHeaders)
 
 	for _, record := range state.Records {
@@ -53,6 +54,10 @@ func MakeCookbooksReportCSV(state *reporting.CookbooksStatus) *FormattedResult {
 		if record.NumNodesAffected() != 0 {
 			nodesString = strings.Join(record.Nodes, " ")
 		}
+		policiesString := "None"
+		if len(record.Policies) != 0 {
+			policiesString = strings.Join(record.Policies, " ")
+		}
 
 		if state.RunCookstyle {
 			for _, file := range record.Files {
@@ -69,6 +74,7 @@ func MakeCookbooksReportCSV(state *reporting.CookbooksStatus) *FormattedResult {
 						"N",
 						offense.Message,
 						nodesString,
+						policiesString,
 					}
 					if offense.Correctable {
 						row[4] = "Y"
@@ -77,7 +83,7 @@ func MakeCookbooksReportCSV(state *reporting.CookbooksStatus) *FormattedResult {
 				}
 			}
 		} else {
-			row := []string{record.Name, record.Version, nodesString}
+			row := []string{record.Name, record.Version, nodesString, policiesString}
 			csvWriter.Write(row)
 		}
 
@@ -85,11 +91,14 @@ func MakeCookbooksReportCSV(state *reporting.CookbooksStatus) *FormattedResult {
 			errBuilder.WriteString(fmt.Sprintf(" - %s (%s): %v\n", record.Name, record.Version, e))
 		}
 	}
+	writePolicyErrors(&errBuilder, state)
 
 	csvWriter.Flush()
 	return &FormattedResult{strBuilder.String(), errBuilder.String()}
 }
 
+// the search query of filtered nodes is not part of the CSV report, so that it
+// stays readable by any CSV reader, the txt, json and html reports include it
 func MakeNodesReportCSV(records []*reporting.NodeReportItem) *FormattedResult {
 	var (
 		strBuilder strings.Builder
@@ -101,7 +110,8 @@ func MakeNodesReportCSV(records []*reporting.NodeReportItem) *FormattedResult {
 		return &FormattedResult{"", ""}
 	}
 
-	tableHeaders := []string{"Node Name", "Chef Version", "Operating System", "Cookbooks"}
+	tableHeaders := []string{"Node Name", "Chef Version", "Operating System", "Policy Name", "Policy Group", "Cookbooks",
+		"FQDN", "Hostname", "IP Address", "Last Check-In", "Stale"}
 	csvWriter.Write(tableHeaders)
 
 	for _, record := range records {
@@ -112,15 +122,321 @@ func MakeNodesReportCSV(records []*reporting.NodeReportItem) *FormattedResult {
 		if len(cookbooksList) != 0 {
 			cookbooksString = strings.Join(cookbooksList, " ")
 		}
+		stale := "N"
+		if record.Stale {
+			stale = "Y"
+		}
 
 		csvWriter.Write([]string{
 			record.Name,
 			record.ChefVersion,
 			record.OSVersionPretty(),
+			record.PolicyName,
+			record.PolicyGroup,
 			cookbooksString,
+			record.FQDN,
+			record.Hostname,
+			record.IPAddress,
+			lastCheckIn(record.LastCheckIn()),
+			stale,
+		})
+	}
+
+	csvWriter.Flush()
+	return &FormattedResult{strBuilder.String(), errBuilder.String()}
+}
+
+func MakeRolesReportCSV(state *reporting.RolesStatus) *FormattedResult {
+	var (
+		strBuilder strings.Builder
+		errBuilder strings.Builder
+		csvWriter  = csv.NewWriter(&strBuilder)
+	)
+
+	if state == nil || len(state.Records) == 0 {
+		return &FormattedResult{"", ""}
+	}
+
+	tableHeaders := []string{
+		"Role Name",
+		"Run List",
+		"Nested Roles",
+		"Missing Roles",
+		"Missing Cookbooks",
+		"Missing Recipes",
+		"Default Attributes",
+		"Override Attributes",
+		"Number of Nodes",
+		"Unused",
+	}
+	csvWriter.Write(tableHeaders)
+
+	for _, record := range state.Records {
+		unused := "N"
+		if record.Unused() {
+			unused = "Y"
+		}
+
+		csvWriter.Write([]string{
+			record.Name,
+			strings.Join(record.RunList, " "),
+			strings.Join(record.NestedRoles, " "),
+			strings.Join(record.MissingRoles, " "),
+			strings.Join(record.MissingCookbooks, " "),
+			strings.Join(record.MissingRecipes, " "),
+			strconv.Itoa(record.NumDefaultAttributes),
+			strconv.Itoa(record.NumOverrideAttributes),
+			strconv.Itoa(record.NumNodes()),
+			unused,
 		})
+
+		for _, e := range record.Errors() {
+			errBuilder.WriteString(fmt.Sprintf(" - %s: %v\n", record.Name, e))
+		}
 	}
 
 	csvWriter.Flush()
 	return &FormattedResult{strBuilder.String(), errBuilder.String()}
 }
+
+func MakeEnvironmentsReportCSV(state *reporting.EnvironmentsStatus) *FormattedResult {
+	var (
+		strBuilder strings.Builder
+		errBuilder strings.Builder
+		csvWriter  = csv.NewWriter(&strBuilder)
+	)
+
+	if state == nil || len(state.Records) == 0 {
+		return &FormattedResult{"", ""}
+	}
+
+	tableHeaders := []string{
+		"Environment Name",
+		"Nodes",
+		"Cookbook Name",
+		"Constraint",
+		"Pinned Version",
+		"Satisfied",
+		"Solved Version",
+	}
+	if state.RunCookstyle {
+		tableHeaders = append(tableHeaders, "Violations", "Auto-correctable")
+	}
+	csvWriter.Write(tableHeaders)
+
+	for _, record := range state.Records {
+		numNodes := strconv.Itoa(record.NumNodes)
+
+		if len(record.Constraints) == 0 {
+			row := []string{record.Name, numNodes, "", "", "", "", ""}
+			if state.RunCookstyle {
+				row = append(row, "", "")
+			}
+			csvWriter.Write(row)
+		}
+
+		for _, c := range record.Constraints {
+			row := []string{record.Name, numNodes, c.Cookbook, c.Constraint, c.PinnedVersion, "N", ""}
+			if c.Satisfied() {
+				row[5] = "Y"
+			}
+			if record.Solvable() {
+				row[6] = record.Solution.VersionOf(c.Cookbook)
+			}
+
+			if state.RunCookstyle {
+				if c.Cookstyle != nil {
+					row = append(row,
+						strconv.Itoa(c.Cookstyle.NumOffenses()),
+						strconv.Itoa(c.Cookstyle.NumCorrectable()),
+					)
+				} else {
+					row = append(row, "", "")
+				}
+			}
+			csvWriter.Write(row)
+		}
+
+		for _, e := range record.Errors() {
+			errBuilder.WriteString(fmt.Sprintf(" - %s: %v\n", record.Name, e))
+		}
+	}
+
+	csvWriter.Flush()
+	return &FormattedResult{strBuilder.String(), errBuilder.String()}
+}
+
+func MakeDataBagsReportCSV(state *reporting.DataBagsStatus) *FormattedResult {
+	var (
+		strBuilder strings.Builder
+		errBuilder strings.Builder
+		csvWriter  = csv.NewWriter(&strBuilder)
+	)
+
+	if state == nil || len(state.Records) == 0 {
+		return &FormattedResult{"", ""}
+	}
+
+	tableHeaders := []string{
+		"Data Bag Name",
+		"Item Name",
+		"Encrypted",
+		"Encryption Version",
+		"Cipher",
+		"Legacy Encryption",
+	}
+	csvWriter.Write(tableHeaders)
+
+	for _, record := range state.Records {
+		if record.NumItems() == 0 {
+			csvWriter.Write([]string{record.Name, "", "", "", "", ""})
+		}
+
+		for _, item := range record.Items {
+			row := []string{record.Name, item.Name, "N", "", "", "N"}
+			if item.Encrypted {
+				row[2] = "Y"
+				row[3] = strconv.Itoa(item.EncryptionVersion)
+				row[4] = item.Cipher
+			}
+			if item.LegacyEncryption() {
+				row[5] = "Y"
+			}
+			csvWriter.Write(row)
+		}
+
+		for _, e := range record.Errors() {
+			errBuilder.WriteString(fmt.Sprintf(" - %s: %v\n", record.Name, e))
+		}
+	}
+
+	csvWriter.Flush()
+	return &FormattedResult{strBuilder.String(), errBuilder.String()}
+}
+
+func MakePoliciesReportCSV(state *reporting.PoliciesStatus) *FormattedResult {
+	var (
+		strBuilder strings.Builder
+		errBuilder strings.Builder
+		csvWriter  = csv.NewWriter(&strBuilder)
+	)
+
+	if state == nil || len(state.Records) == 0 {
+		return &FormattedResult{"", ""}
+	}
+
+	tableHeaders := []string{
+		"Policy Group",
+		"Policy Name",
+		"Revision ID",
+		"Available Revisions",
+		"Nodes",
+		"Cookbooks",
+	}
+	csvWriter.Write(tableHeaders)
+
+	for _, record := range state.Records {
+		if len(record.Policies) == 0 {
+			csvWriter.Write([]string{record.Name, "", "", "", "", ""})
+		}
+
+		for _, policy := range record.Policies {
+			cookbooksString := "None"
+			if len(policy.CookbookLocks) != 0 {
+				cookbooksString = strings.Join(policy.CookbooksList(), " ")
+			}
+
+			csvWriter.Write([]string{
+				record.Name,
+				policy.Name,
+				policy.RevisionID,
+				strconv.Itoa(policy.NumRevisions),
+				strconv.Itoa(policy.NumNodes),
+				cookbooksString,
+			})
+		}
+
+		for _, e := range record.Errors() {
+			errBuilder.WriteString(fmt.Sprintf(" - %s: %v\n", record.Name, e))
+		}
+	}
+
+	csvWriter.Flush()
+	return &FormattedResult{strBuilder.String(), errBuilder.String()}
+}
+
+func MakeClientVersionsReportCSV(state *reporting.ClientVersionsStatus) *FormattedResult {
+	var (
+		strBuilder strings.Builder
+		csvWriter  = csv.NewWriter(&strBuilder)
+	)
+
+	if state == nil || len(state.Records) == 0 {
+		return &FormattedResult{"", ""}
+	}
+
+	tableHeaders := []string{
+		"Chef Version",
+		"Support",
+		"EOL",
+		"Number of Nodes",
+		"Percentage",
+		"Ohai Versions",
+		"Ruby Versions",
+		"Nodes",
+	}
+	csvWriter.Write(tableHeaders)
+
+	for _, record := range state.Records {
+		csvWriter.Write([]string{
+			record.Version,
+			record.Status,
+			record.EOL,
+			strconv.Itoa(record.NumNodes()),
+			fmt.Sprintf("%.1f", state.Percentage(record)),
+			strings.Join(record.OhaiVersions, " "),
+			strings.Join(record.RubyVersions, " "),
+			strings.Join(record.Nodes, " "),
+		})
+	}
+
+	csvWriter.Flush()
+	return &FormattedResult{strBuilder.String(), ""}
+}
+
+func MakeOSSupportReportCSV(state *reporting.OSSupportStatus) *FormattedResult {
+	var (
+		strBuilder strings.Builder
+		csvWriter  = csv.NewWriter(&strBuilder)
+	)
+
+	if state == nil || len(state.Records) == 0 {
+		return &FormattedResult{"", ""}
+	}
+
+	tableHeaders := []string{
+		"Platform",
+		"Version",
+		"Name",
+		"Support",
+		"EOL",
+		"Number of Nodes",
+		"Nodes",
+	}
+	csvWriter.Write(tableHeaders)
+
+	for _, record := range state.Records {
+		csvWriter.Write([]string{
+			record.Platform,
+			record.Version,
+			record.Name,
+			record.Status,
+			record.EOL,
+			strconv.Itoa(record.NumNodes()),
+			strings.Join(record.Nodes, " "),
+		})
+	}
+
+	csvWriter.Flush()
+	return &FormattedResult{strBuilder.String(), ""}
+}
@@ -46,14 +46,16 @@ func TestMakeCookbooksReportCSV_WithUnverifiedRecords(t *testing.T) {
 		RunCookstyle: false,
 		Records: []*reporting.CookbookRecord{
 			&reporting.CookbookRecord{Name: "my-cookbook", Version: "1.0", Nodes: []string{"node-1", "node-2"}},
+			&reporting.CookbookRecord{Name: "policy-cookbook", Version: "2.0", Policies: []string{"dev/app", "prod/app"}},
 		},
 	}
 
 	lines := strings.Split(subject.MakeCookbooksReportCSV(&cbStatus).Report, "\n")
-	assert.Equal(t, 3, len(lines))
-	assert.Equal(t, "Cookbook Name,Version,Nodes", lines[0])
-	assert.Equal(t, "my-cookbook,1.0,node-1 node-2", lines[1])
-	assert.Equal(t, "", lines[2])
+	assert.Equal(t, 4, len(lines))
+	assert.Equal(t, "Cookbook Name,Version,Nodes,Policies", lines[0])
+	assert.Equal(t, "my-cookbook,1.0,node-1 node-2,None", lines[1])
+	assert.Equal(t, "policy-cookbook,2.0,None,dev/app prod/app", lines[2])
+	assert.Equal(t, "", lines[3])
 }
 
 func TestMakeCookbooksReportCSV_WithVerifiedRecords(t *testing.T) {
@@ -70,8 +72,8 @@ func TestMakeCookbooksReportCSV_WithVerifiedRecords(t *testing.T) {
 	actual := subject.MakeCookbooksReportCSV(&cbStatus)
 	lines := strings.Split(actual.Report, "\n")
 	assert.Equal(t, 3, len(lines))
-	assert.Equal(t, "Cookbook Name,Version,File,Offense,Automatically Correctable,Message,Nodes", lines[0])
-	assert.Equal(t, "my-cookbook,1.0,/path/to/file.rb,ChefDeprecations/Blah,Y,some description,node-1 node-2", lines[1])
+	assert.Equal(t, "Cookbook Name,Version,File,Offense,Automatically Correctable,Message,Nodes,Policies", lines[0])
+	assert.Equal(t, "my-cookbook,1.0,/path/to/file.rb,ChefDeprecations/Blah,Y,some description,node-1 node-2,None", lines[1])
 	assert.Equal(t, "", lines[2])
 }
 
@@ -119,15 +121,205 @@ func TestMakeNodesReportCSV_WithRecords(t *testing.T) {
 			},
 		},
 		&reporting.NodeReportItem{Name: "node3", ChefVersion: "15.00", OS: "ubuntu", OSVersion: "16.04",
-			CookbookVersions: nil},
+			PolicyName: "app", PolicyGroup: "prod", CookbookVersions: nil,
+			FQDN: "node3.example.com", Hostname: "node3", IPAddress: "10.0.0.3", OhaiTime: 1576492200, Stale: true},
 	}
 
 	lines := strings.Split(subject.MakeNodesReportCSV(nodesReport).Report, "\n")
 	if assert.Equal(t, 5, len(lines)) {
-		assert.Equal(t, "Node Name,Chef Version,Operating System,Cookbooks", lines[0])
-		assert.Equal(t, "node1,12.22,windows v10.1,mycookbook(1.0)", lines[1])
-		assert.Equal(t, "node2,13.11,,mycookbook(1.0) test(9.9)", lines[2])
-		assert.Equal(t, "node3,15.00,ubuntu v16.04,None", lines[3])
+		assert.Equal(t, "Node Name,Chef Version,Operating System,Policy Name,Policy Group,Cookbooks,"+
+			"FQDN,Hostname,IP Address,Last Check-In,Stale", lines[0])
+		assert.Equal(t, "node1,12.22,windows v10.1,,,mycookbook(1.0),,,,,N", lines[1])
+		assert.Equal(t, "node2,13.11,,,,mycookbook(1.0) test(9.9),,,,,N", lines[2])
+		assert.Equal(t, "node3,15.00,ubuntu v16.04,app,prod,None,"+
+			"node3.example.com,node3,10.0.0.3,2019-12-16 10:30 UTC,Y", lines[3])
+		assert.Equal(t, "", lines[4])
+	}
+}
+
+func TestMakeRolesReportCSV_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeRolesReportCSV(nil))
+}
+
+func TestMakeRolesReportCSV_WithRecords(t *testing.T) {
+	rs := &reporting.RolesStatus{
+		Records: []*reporting.RoleRecord{
+			&reporting.RoleRecord{
+				Name:                 "base",
+				RunList:              []string{"recipe[ntp]", "role[security]", "role[foo]"},
+				NestedRoles:          []string{"security"},
+				MissingRoles:         []string{"foo"},
+				MissingCookbooks:     []string{"ntp"},
+				NumDefaultAttributes: 2,
+				Nodes:                []string{"node1", "node2"},
+			},
+			&reporting.RoleRecord{Name: "orphan", GetError: errors.New("unable to get role orphan")},
+		},
+	}
+
+	actual := subject.MakeRolesReportCSV(rs)
+	lines := strings.Split(actual.Report, "\n")
+	if assert.Equal(t, 4, len(lines)) {
+		assert.Equal(t, "Role Name,Run List,Nested Roles,Missing Roles,Missing Cookbooks,Missing Recipes,"+
+			"Default Attributes,Override Attributes,Number of Nodes,Unused", lines[0])
+		assert.Equal(t, "base,recipe[ntp] role[security] role[foo],security,foo,ntp,,2,0,2,N", lines[1])
+		assert.Equal(t, "orphan,,,,,,0,0,0,Y", lines[2])
+		assert.Equal(t, "", lines[3])
+	}
+	assert.Equal(t, " - orphan: unable to get role orphan\n", actual.Errors)
+}
+
+func TestMakeEnvironmentsReportCSV_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeEnvironmentsReportCSV(nil))
+}
+
+func TestMakeEnvironmentsReportCSV_WithVerifiedRecords(t *testing.T) {
+	es := &reporting.EnvironmentsStatus{
+		RunCookstyle: true,
+		Records: []*reporting.EnvironmentRecord{
+			&reporting.EnvironmentRecord{Name: "_default", NumNodes: 1},
+			&reporting.EnvironmentRecord{Name: "production", NumNodes: 3,
+				Constraints: []*reporting.ConstraintRecord{
+					&reporting.ConstraintRecord{Cookbook: "bar", Constraint: "~> 2.0"},
+					&reporting.ConstraintRecord{Cookbook: "foo", Constraint: "= 1.0.0", PinnedVersion: "1.0.0",
+						Cookstyle: &reporting.CookbookRecord{Name: "foo", Version: "1.0.0",
+							Files: []reporting.CookbookFile{
+								reporting.CookbookFile{Offenses: []reporting.CookstyleOffense{
+									reporting.CookstyleOffense{Correctable: true},
+									reporting.CookstyleOffense{Correctable: false},
+								}},
+							},
+						},
+					},
+				},
+				Solution: &reporting.Solution{Conflict: &reporting.SolverConflict{Cookbook: "bar"}},
+			},
+			&reporting.EnvironmentRecord{Name: "staging", NumNodes: 2,
+				Constraints: []*reporting.ConstraintRecord{
+					&reporting.ConstraintRecord{Cookbook: "foo", Constraint: "~> 1.0", PinnedVersion: "1.2.0"},
+				},
+				Solution: &reporting.Solution{Cookbooks: []reporting.CookbookVersion{
+					{Name: "bar", Version: "2.0.0"}, {Name: "foo", Version: "1.1.0"},
+				}},
+			},
+		},
+	}
+
+	lines := strings.Split(subject.MakeEnvironmentsReportCSV(es).Report, "\n")
+	if assert.Equal(t, 6, len(lines)) {
+		assert.Equal(t, "Environment Name,Nodes,Cookbook Name,Constraint,Pinned Version,Satisfied,"+
+			"Solved Version,Violations,Auto-correctable", lines[0])
+		assert.Equal(t, "_default,1,,,,,,,", lines[1])
+		assert.Equal(t, "production,3,bar,~> 2.0,,N,,,", lines[2])
+		assert.Equal(t, "production,3,foo,= 1.0.0,1.0.0,Y,,2,1", lines[3])
+		assert.Equal(t, "staging,2,foo,~> 1.0,1.2.0,Y,1.1.0,,", lines[4])
+		assert.Equal(t, "", lines[5])
+	}
+}
+
+func TestMakeDataBagsReportCSV_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeDataBagsReportCSV(nil))
+}
+
+func TestMakeDataBagsReportCSV_WithRecords(t *testing.T) {
+	ds := &reporting.DataBagsStatus{
+		Records: []*reporting.DataBagRecord{
+			&reporting.DataBagRecord{Name: "empty"},
+			&reporting.DataBagRecord{Name: "secrets",
+				Items: []*reporting.DataBagItemRecord{
+					&reporting.DataBagItemRecord{Name: "api", Encrypted: true, EncryptionVersion: 3, Cipher: "aes-256-gcm"},
+					&reporting.DataBagItemRecord{Name: "aws", Encrypted: true, EncryptionVersion: 1, Cipher: "aes-256-cbc"},
+					&reporting.DataBagItemRecord{Name: "notes"},
+				},
+			},
+		},
+	}
+
+	lines := strings.Split(subject.MakeDataBagsReportCSV(ds).Report, "\n")
+	if assert.Equal(t, 6, len(lines)) {
+		assert.Equal(t, "Data Bag Name,Item Name,Encrypted,Encryption Version,Cipher,Legacy Encryption", lines[0])
+		assert.Equal(t, "empty,,,,,", lines[1])
+		assert.Equal(t, "secrets,api,Y,3,aes-256-gcm,N", lines[2])
+		assert.Equal(t, "secrets,aws,Y,1,aes-256-cbc,Y", lines[3])
+		assert.Equal(t, "secrets,notes,N,,,N", lines[4])
+		assert.Equal(t, "", lines[5])
+	}
+}
+
+func TestMakePoliciesReportCSV_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakePoliciesReportCSV(nil))
+}
+
+func TestMakePoliciesReportCSV_WithRecords(t *testing.T) {
+	ps := &reporting.PoliciesStatus{
+		Records: []*reporting.PolicyGroupRecord{
+			&reporting.PolicyGroupRecord{Name: "empty"},
+			&reporting.PolicyGroupRecord{Name: "prod",
+				Policies: []*reporting.PolicyRecord{
+					&reporting.PolicyRecord{Name: "app", RevisionID: "abc123", NumRevisions: 3, NumNodes: 2,
+						CookbookLocks: []reporting.CookbookVersion{
+							reporting.CookbookVersion{Name: "apache2", Version: "5.0.1"},
+							reporting.CookbookVersion{Name: "mysql", Version: "8.0.0"},
+						},
+					},
+					&reporting.PolicyRecord{Name: "db", RevisionID: "def456", NumRevisions: 1},
+				},
+			},
+		},
+	}
+
+	lines := strings.Split(subject.MakePoliciesReportCSV(ps).Report, "\n")
+	if assert.Equal(t, 5, len(lines)) {
+		assert.Equal(t, "Policy Group,Policy Name,Revision ID,Available Revisions,Nodes,Cookbooks", lines[0])
+		assert.Equal(t, "empty,,,,,", lines[1])
+		assert.Equal(t, "prod,app,abc123,3,2,apache2(5.0.1) mysql(8.0.0)", lines[2])
+		assert.Equal(t, "prod,db,def456,1,0,None", lines[3])
 		assert.Equal(t, "", lines[4])
 	}
 }
+
+func TestMakeClientVersionsReportCSV_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeClientVersionsReportCSV(nil))
+}
+
+func TestMakeClientVersionsReportCSV(t *testing.T) {
+	actual := subject.MakeClientVersionsReportCSV(clientVersionsFixture())
+	lines := strings.Split(actual.Report, "\n")
+	if assert.Equal(t, 4, len(lines)) {
+		assert.Equal(t, "Chef Version,Support,EOL,Number of Nodes,Percentage,Ohai Versions,Ruby Versions,Nodes", lines[0])
+		assert.Equal(t, "15.4,supported,2021-04-30,2,66.7,15.3.1,2.6.3 2.6.5,web01 web02", lines[1])
+		assert.Equal(t, "14.12,eol,2020-04-30,1,33.3,,,db01", lines[2])
+		assert.Equal(t, "", lines[3])
+	}
+	assert.Empty(t, actual.Errors)
+}
+
+func TestMakeOSSupportReportCSV_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeOSSupportReportCSV(nil))
+}
+
+func TestMakeOSSupportReportCSV(t *testing.T) {
+	actual := subject.MakeOSSupportReportCSV(osSupportFixture())
+	lines := strings.Split(actual.Report, "\n")
+	if assert.Equal(t, 6, len(lines)) {
+		assert.Equal(t, "Platform,Version,Name,Support,EOL,Number of Nodes,Nodes", lines[0])
+		assert.Equal(t, "centos,7,,eol,2020-04-30,1,db01", lines[1])
+		assert.Equal(t, "ubuntu,18.04,,near-eol,2020-10-31,1,web01", lines[2])
+		assert.Equal(t, "windows,10.0.14393,Windows Server 2016,supported,2027-01-12,1,win01", lines[3])
+		assert.Equal(t, ",,,unknown,,1,new", lines[4])
+		assert.Equal(t, "", lines[5])
+	}
+	assert.Empty(t, actual.Errors)
+}
@@ -0,0 +1,94 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package formatter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
+
+// MakeDependenciesReportDOT renders the dependency graph in the Graphviz DOT
+// language, every dependency points to the latest version that satisfies it,
+// unsatisfied dependencies point to a dashed node in red and the cookbooks
+// that are part of a circular dependency are highlighted in orange
+//
+//	dot -Tsvg dependencies-20191216103000.dot > dependencies.svg
+func MakeDependenciesReportDOT(graph *reporting.DependencyGraph) *FormattedResult {
+	var (
+		errorBuilder strings.Builder
+		strBuilder   strings.Builder
+		inCycle      = map[string]bool{}
+		missing      = map[string]bool{}
+	)
+
+	if graph == nil || len(graph.Cookbooks) == 0 {
+		// nothing to do
+		return &FormattedResult{"", ""}
+	}
+
+	for _, cycle := range graph.Cycles {
+		for _, name := range cycle {
+			inCycle[name] = true
+		}
+	}
+
+	strBuilder.WriteString("digraph dependencies {\n")
+	strBuilder.WriteString("  node [shape=box];\n")
+
+	for _, cd := range graph.Cookbooks {
+		node := dotNodeID(cd.Name, cd.Version)
+		if inCycle[cd.Name] {
+			strBuilder.WriteString(fmt.Sprintf("  %s [color=orange];\n", node))
+		} else {
+			strBuilder.WriteString(fmt.Sprintf("  %s;\n", node))
+		}
+
+		for _, d := range cd.Dependencies {
+			label := strconv.Quote(d.Constraint)
+			if d.Satisfied() {
+				strBuilder.WriteString(
+					fmt.Sprintf("  %s -> %s [label=%s];\n", node, dotNodeID(d.Cookbook, d.Resolved()), label),
+				)
+				continue
+			}
+
+			target := strconv.Quote(d.Cookbook + " (missing)")
+			if !missing[target] {
+				missing[target] = true
+				strBuilder.WriteString(fmt.Sprintf("  %s [color=red, style=dashed];\n", target))
+			}
+			strBuilder.WriteString(
+				fmt.Sprintf("  %s -> %s [label=%s, color=red, style=dashed];\n", node, target, label),
+			)
+		}
+
+		for _, e := range cd.Errors() {
+			errorBuilder.WriteString(fmt.Sprintf(" - %s (%s): %v\n", cd.Name, cd.Version, e))
+		}
+	}
+
+	strBuilder.WriteString("}\n")
+
+	return &FormattedResult{strBuilder.String(), errorBuilder.String()}
+}
+
+func dotNodeID(name, version string) string {
+	return strconv.Quote(fmt.Sprintf("%s (%s)", name, version))
+}
@@ -0,0 +1,83 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package formatter_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/formatter"
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
+
+// a graph where app depends on a satisfied and a missing cookbook, and
+// base and common depend on each other
+func dependencyGraphFixture() *reporting.DependencyGraph {
+	return &reporting.DependencyGraph{
+		TotalCookbooks: 4,
+		Cookbooks: []*reporting.CookbookDependencies{
+			&reporting.CookbookDependencies{Name: "app", Version: "1.2.3",
+				Dependencies: []*reporting.Dependency{
+					&reporting.Dependency{Cookbook: "base", Constraint: "~> 2.0", Versions: []string{"2.1.0"}},
+					&reporting.Dependency{Cookbook: "mysql", Constraint: ">= 8.0"},
+				},
+			},
+			&reporting.CookbookDependencies{Name: "base", Version: "2.1.0",
+				Dependencies: []*reporting.Dependency{
+					&reporting.Dependency{Cookbook: "common", Constraint: ">= 0.0.0", Versions: []string{"1.0.0"}},
+				},
+				Dependents: []reporting.CookbookVersion{{Name: "app", Version: "1.2.3"}, {Name: "common", Version: "1.0.0"}},
+			},
+			&reporting.CookbookDependencies{Name: "broken", Version: "0.1.0", GetError: errors.New("not found")},
+			&reporting.CookbookDependencies{Name: "common", Version: "1.0.0",
+				Dependencies: []*reporting.Dependency{
+					&reporting.Dependency{Cookbook: "base", Constraint: ">= 2.0", Versions: []string{"2.1.0"}},
+				},
+				Dependents: []reporting.CookbookVersion{{Name: "base", Version: "2.1.0"}},
+			},
+		},
+		Cycles:           [][]string{{"base", "common"}},
+		LeafDependencies: []string{},
+	}
+}
+
+func TestMakeDependenciesReportDOT_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeDependenciesReportDOT(nil))
+}
+
+func TestMakeDependenciesReportDOT(t *testing.T) {
+	actual := subject.MakeDependenciesReportDOT(dependencyGraphFixture())
+	assert.Equal(t, ` - broken (0.1.0): not found
+`, actual.Errors)
+	assert.Equal(t, `digraph dependencies {
+  node [shape=box];
+  "app (1.2.3)";
+  "app (1.2.3)" -> "base (2.1.0)" [label="~> 2.0"];
+  "mysql (missing)" [color=red, style=dashed];
+  "app (1.2.3)" -> "mysql (missing)" [label=">= 8.0", color=red, style=dashed];
+  "base (2.1.0)" [color=orange];
+  "base (2.1.0)" -> "common (1.0.0)" [label=">= 0.0.0"];
+  "broken (0.1.0)";
+  "common (1.0.0)" [color=orange];
+  "common (1.0.0)" -> "base (2.1.0)" [label=">= 2.0"];
+}
+`, actual.Report)
+}
@@ -17,6 +17,13 @@
 
 package formatter
 
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
 type FormattedResult struct {
 	Report string
 	Errors string
@@ -41,3 +48,49 @@ func stringOrPlaceholder(s, placeholder string) string {
 	}
 	return s
 }
+
+// returns a human readable size like '1.5 MB', units are powers of 1024
+func humanBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// returns the time of the last check-in of a node in UTC, or an empty string when unknown
+func lastCheckIn(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02 15:04 UTC")
+}
+
+// same as lastCheckIn() but stale nodes that never checked in show 'never'
+func staleCheckIn(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return lastCheckIn(t)
+}
+
+// returns a human readable duration like '30d' or '12h0m0s', durations are
+// displayed in days when they are a whole number of days
+func humanDuration(d time.Duration) string {
+	const day = 24 * time.Hour
+	if d >= day && d%day == 0 {
+		return fmt.Sprintf("%dd", d/day)
+	}
+	return d.String()
+}
+
+// returns a number of hours rounded to one decimal, like '1.5' or '12'
+func humanHours(hours float64) string {
+	return strconv.FormatFloat(math.Round(hours*10)/10, 'f', -1, 64)
+}
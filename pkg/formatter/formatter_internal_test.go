@@ -37,3 +37,18 @@ func TestStringOrPlaceholder(t *testing.T) {
 	assert.Equal(t, "placeholder", stringOrPlaceholder("", "placeholder"))
 	assert.Equal(t, "foo", stringOrPlaceholder("foo", "placeholder"))
 }
+
+func TestHumanBytes(t *testing.T) {
+	assert.Equal(t, "0 B", humanBytes(0))
+	assert.Equal(t, "1023 B", humanBytes(1023))
+	assert.Equal(t, "1.0 KB", humanBytes(1024))
+	assert.Equal(t, "1.5 MB", humanBytes(3<<19))
+	assert.Equal(t, "2.0 GB", humanBytes(2<<30))
+}
+
+func TestHumanHours(t *testing.T) {
+	assert.Equal(t, "0", humanHours(0))
+	assert.Equal(t, "12", humanHours(12))
+	assert.Equal(t, "1.5", humanHours(1.5))
+	assert.Equal(t, "0.2", humanHours(0.16666))
+}
@@ -0,0 +1,296 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strconv"
+
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
+
+// the HTML reports are a single self-contained file, styles and scripts are
+// inlined and no external assets are loaded, so they can be attached anywhere
+type htmlPage struct {
+	Title string
+	Cards []htmlCard
+}
+
+type htmlCard struct {
+	Label string
+	Value string
+	// highlights the card when its value requires attention
+	Alert bool
+}
+
+type htmlCookbooksPage struct {
+	htmlPage
+	RunCookstyle bool
+	Cookbooks    []*reporting.CookbookRecord
+	Errors       []string
+}
+
+type htmlNodesPage struct {
+	htmlPage
+	SearchQuery string
+	Nodes       []htmlNode
+}
+
+type htmlNode struct {
+	*reporting.NodeReportItem
+	CheckIn   string
+	Cookbooks []string
+}
+
+func MakeCookbooksReportHTML(state *reporting.CookbooksStatus) *FormattedResult {
+	if state == nil || len(state.Records) == 0 {
+		// nothing to do
+		return &FormattedResult{"", ""}
+	}
+
+	var (
+		page = htmlCookbooksPage{
+			htmlPage:     htmlPage{Title: "Cookbooks Report"},
+			RunCookstyle: state.RunCookstyle,
+			Cookbooks:    state.Records,
+			Errors:       make([]string, 0),
+		}
+		offenses, correctable, inUse int
+	)
+
+	for _, record := range state.Records {
+		offenses += record.NumOffenses()
+		correctable += record.NumCorrectable()
+		if record.InUse() {
+			inUse++
+		}
+		for _, e := range record.Errors() {
+			page.Errors = append(page.Errors, fmt.Sprintf("%s (%s): %v", record.Name, record.Version, e))
+		}
+	}
+
+	for _, e := range state.PolicyErrors {
+		page.Errors = append(page.Errors, fmt.Sprintf("policies: %v", e))
+	}
+
+	page.Cards = append(page.Cards,
+		htmlCard{Label: "Cookbook Versions", Value: strconv.Itoa(len(state.Records))},
+		htmlCard{Label: "In Use", Value: strconv.Itoa(inUse)},
+	)
+	if state.RunCookstyle {
+		page.Cards = append(page.Cards,
+			htmlCard{Label: "Violations", Value: strconv.Itoa(offenses), Alert: offenses != 0},
+			htmlCard{Label: "Auto-correctable", Value: strconv.Itoa(correctable)},
+		)
+	}
+	page.Cards = append(page.Cards,
+		htmlCard{Label: "Errors", Value: strconv.Itoa(len(page.Errors)), Alert: len(page.Errors) != 0},
+	)
+
+	return renderHTMLReport("cookbooks", page)
+}
+
+func MakeNodesReportHTML(records []*reporting.NodeReportItem, filter reporting.NodesFilter) *FormattedResult {
+	if len(records) == 0 {
+		// nothing to do
+		return &FormattedResult{"", ""}
+	}
+
+	var (
+		page = htmlNodesPage{
+			htmlPage:    htmlPage{Title: "Nodes Report"},
+			SearchQuery: filter.SearchStatement(),
+			Nodes:       make([]htmlNode, 0, len(records)),
+		}
+		stale        int
+		chefVersions = map[string]bool{}
+		platforms    = map[string]bool{}
+	)
+
+	for _, record := range records {
+		if record.Stale {
+			stale++
+		}
+		chefVersions[record.ChefVersion] = true
+		platforms[record.OSVersionPretty()] = true
+		page.Nodes = append(page.Nodes, htmlNode{
+			NodeReportItem: record,
+			CheckIn:        lastCheckIn(record.LastCheckIn()),
+			Cookbooks:      record.CookbooksList(),
+		})
+	}
+
+	page.Cards = []htmlCard{
+		{Label: "Nodes", Value: strconv.Itoa(len(records))},
+		{Label: "Stale Nodes", Value: strconv.Itoa(stale), Alert: stale != 0},
+		{Label: "Chef Versions", Value: strconv.Itoa(len(chefVersions))},
+		{Label: "Operating Systems", Value: strconv.Itoa(len(platforms))},
+	}
+
+	return renderHTMLReport("nodes", page)
+}
+
+// errors are part of the HTML report itself, therefore, the only error we
+// could return is when we are unable to render the report
+func renderHTMLReport(name string, page interface{}) *FormattedResult {
+	var buffer bytes.Buffer
+	if err := htmlTemplates.ExecuteTemplate(&buffer, name, page); err != nil {
+		return &FormattedResult{"", fmt.Sprintf(" - unable to generate HTML report: %v\n", err)}
+	}
+	return &FormattedResult{buffer.String(), ""}
+}
+
+var htmlTemplates = template.Must(template.New("html").Parse(`
+{{- define "header" -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>chef-analyze: {{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292e; }
+h1 { font-size: 1.6em; }
+.cards { display: flex; flex-wrap: wrap; gap: 1em; margin-bottom: 2em; }
+.card { border: 1px solid #d1d5da; border-radius: 6px; padding: 1em 1.5em; min-width: 10em; }
+.card .value { font-size: 2em; font-weight: bold; }
+.card.alert { border-color: #d73a49; }
+.card.alert .value { color: #d73a49; }
+input.filter { padding: .4em; width: 24em; margin-bottom: .5em; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border: 1px solid #e1e4e8; padding: .3em .6em; text-align: left; vertical-align: top; }
+table.sortable th { cursor: pointer; background: #f6f8fa; user-select: none; }
+table.sortable th[data-order="asc"]::after { content: " \25B2"; }
+table.sortable th[data-order="desc"]::after { content: " \25BC"; }
+td.number { text-align: right; }
+details summary { cursor: pointer; }
+.error { color: #d73a49; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="cards">
+{{- range .Cards}}
+<div class="card{{if .Alert}} alert{{end}}"><div class="value">{{.Value}}</div><div class="label">{{.Label}}</div></div>
+{{- end}}
+</div>
+{{- end}}
+
+{{- define "footer" -}}
+<script>
+(function () {
+  function sortValue(cell) {
+    var value = cell.getAttribute("data-sort");
+    return value === null ? cell.textContent.trim() : value;
+  }
+  Array.prototype.forEach.call(document.querySelectorAll("table.sortable"), function (table) {
+    var headers = table.tHead.rows[0].cells;
+    Array.prototype.forEach.call(headers, function (th, column) {
+      th.addEventListener("click", function () {
+        var asc = th.getAttribute("data-order") !== "asc",
+            tbody = table.tBodies[0],
+            rows = Array.prototype.slice.call(tbody.rows);
+        Array.prototype.forEach.call(headers, function (h) { h.removeAttribute("data-order"); });
+        th.setAttribute("data-order", asc ? "asc" : "desc");
+        rows.sort(function (a, b) {
+          var cmp = sortValue(a.cells[column]).localeCompare(sortValue(b.cells[column]), undefined, {numeric: true});
+          return asc ? cmp : -cmp;
+        });
+        rows.forEach(function (row) { tbody.appendChild(row); });
+      });
+    });
+  });
+  Array.prototype.forEach.call(document.querySelectorAll("input.filter"), function (input) {
+    var table = document.getElementById(input.getAttribute("data-table"));
+    input.addEventListener("input", function () {
+      var term = input.value.toLowerCase();
+      Array.prototype.forEach.call(table.tBodies[0].rows, function (row) {
+        row.style.display = row.textContent.toLowerCase().indexOf(term) === -1 ? "none" : "";
+      });
+    });
+  });
+})();
+</script>
+</body>
+</html>
+{{end}}
+
+{{- define "list" -}}
+{{if .}}<details><summary>{{len .}}</summary>{{range $i, $e := .}}{{if $i}}, {{end}}{{$e}}{{end}}</details>{{else}}0{{end}}
+{{- end}}
+
+{{- define "cookbooks" -}}
+{{template "header" .}}
+<h2>Cookbooks</h2>
+<input class="filter" type="search" placeholder="Filter cookbooks..." data-table="cookbooks">
+<table id="cookbooks" class="sortable">
+<thead><tr><th>Cookbook</th><th>Version</th>{{if .RunCookstyle}}<th>Violations</th><th>Auto-correctable</th>{{end}}<th>Nodes Affected</th><th>Policies</th></tr></thead>
+<tbody>
+{{- range .Cookbooks}}
+<tr><td>{{.Name}}</td><td>{{.Version}}</td>
+{{- if $.RunCookstyle}}<td class="number">{{.NumOffenses}}</td><td class="number">{{.NumCorrectable}}</td>{{end -}}
+<td class="number" data-sort="{{.NumNodesAffected}}">{{template "list" .Nodes}}</td><td class="number" data-sort="{{len .Policies}}">{{template "list" .Policies}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- if .RunCookstyle}}
+<h2>Violations</h2>
+{{- range .Cookbooks}}{{if .NumOffenses}}
+<details class="offenses"><summary>{{.Name}} ({{.Version}}): {{.NumOffenses}} violations, {{.NumCorrectable}} auto-correctable</summary>
+<table>
+<thead><tr><th>File</th><th>Line</th><th>Cop</th><th>Severity</th><th>Auto-correctable</th><th>Message</th></tr></thead>
+<tbody>
+{{- range .Files}}{{$path := .Path}}{{range .Offenses}}
+<tr><td>{{$path}}</td><td>{{.Location.StartLine}}:{{.Location.StartColumn}}</td><td>{{.CopName}}</td><td>{{.Severity}}</td><td>{{if .Correctable}}Y{{else}}N{{end}}</td><td>{{.Message}}</td></tr>
+{{- end}}{{end}}
+</tbody>
+</table>
+</details>
+{{- end}}{{end}}
+{{- end}}
+{{- if .Errors}}
+<h2>Errors</h2>
+<details class="error"><summary>{{len .Errors}} errors</summary>
+<ul>
+{{- range .Errors}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+</details>
+{{- end}}
+{{template "footer" .}}
+{{- end}}
+
+{{- define "nodes" -}}
+{{template "header" .}}
+{{- if .SearchQuery}}
+<p>Search query: <code>{{.SearchQuery}}</code></p>
+{{- end}}
+<h2>Nodes</h2>
+<input class="filter" type="search" placeholder="Filter nodes..." data-table="nodes">
+<table id="nodes" class="sortable">
+<thead><tr><th>Node Name</th><th>Chef Version</th><th>Operating System</th><th>Policy</th><th>Cookbooks</th><th>Last Check-In</th></tr></thead>
+<tbody>
+{{- range .Nodes}}
+<tr><td>{{.Name}}</td><td>{{.ChefVersion}}</td><td>{{.OSVersionPretty}}</td><td>{{if .PolicyName}}{{.PolicyGroup}}/{{.PolicyName}}{{end}}</td><td class="number" data-sort="{{len .Cookbooks}}">{{template "list" .Cookbooks}}</td><td>{{.CheckIn}}{{if .Stale}} <span class="error">(stale)</span>{{end}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{template "footer" .}}
+{{- end}}
+`))
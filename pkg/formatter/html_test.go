@@ -0,0 +1,129 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package formatter_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/formatter"
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func TestMakeCookbooksReportHTML_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeCookbooksReportHTML(nil))
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeCookbooksReportHTML(&reporting.CookbooksStatus{}))
+}
+
+func TestMakeCookbooksReportHTML_WithVerifiedRecords(t *testing.T) {
+	offense := reporting.CookstyleOffense{
+		CopName: "ChefDeprecations/Blah", Message: "don't use <node.set>", Severity: "warning", Correctable: true,
+	}
+	offense.Location.StartLine = 3
+	offense.Location.StartColumn = 5
+
+	cbStatus := reporting.CookbooksStatus{
+		RunCookstyle: true,
+		Records: []*reporting.CookbookRecord{
+			&reporting.CookbookRecord{Name: "my-cookbook", Version: "1.0", Nodes: []string{"node-1", "node-2"},
+				Files: []reporting.CookbookFile{
+					reporting.CookbookFile{Path: "recipes/default.rb", Offenses: []reporting.CookstyleOffense{offense}},
+				},
+			},
+			&reporting.CookbookRecord{Name: "their-cookbook", Version: "1.1",
+				DownloadError: errors.New("could not download"),
+			},
+		},
+	}
+
+	actual := subject.MakeCookbooksReportHTML(&cbStatus)
+	assert.Empty(t, actual.Errors, "errors should be embedded in the HTML report")
+
+	for _, s := range []string{
+		"<!DOCTYPE html>",
+		"<title>chef-analyze: Cookbooks Report</title>",
+		`<div class="card"><div class="value">2</div><div class="label">Cookbook Versions</div></div>`,
+		`<div class="card alert"><div class="value">1</div><div class="label">Violations</div></div>`,
+		`<input class="filter" type="search" placeholder="Filter cookbooks..." data-table="cookbooks">`,
+		`<table id="cookbooks" class="sortable">`,
+		`<th>Violations</th><th>Auto-correctable</th>`,
+		`<td class="number" data-sort="2"><details><summary>2</summary>node-1, node-2</details></td>`,
+		`<details class="offenses"><summary>my-cookbook (1.0): 1 violations, 1 auto-correctable</summary>`,
+		`<tr><td>recipes/default.rb</td><td>3:5</td><td>ChefDeprecations/Blah</td><td>warning</td><td>Y</td>` +
+			`<td>don&#39;t use &lt;node.set&gt;</td></tr>`,
+		`<details class="error"><summary>1 errors</summary>`,
+		"<li>their-cookbook (1.1): could not download</li>",
+		"<script>",
+	} {
+		assert.Containsf(t, actual.Report, s, "there is something missing in the report: '%s' is missing", s)
+	}
+
+	// the report must be self-contained
+	for _, s := range []string{"<link", "src=", "http://", "https://"} {
+		assert.NotContainsf(t, actual.Report, s, "the report should not load external assets: '%s' found", s)
+	}
+}
+
+func TestMakeCookbooksReportHTML_WithoutCookstyle(t *testing.T) {
+	actual := subject.MakeCookbooksReportHTML(&reporting.CookbooksStatus{
+		Records: []*reporting.CookbookRecord{&reporting.CookbookRecord{Name: "my-cookbook", Version: "1.0"}},
+	})
+	assert.Empty(t, actual.Errors)
+	assert.Contains(t, actual.Report, "<td>my-cookbook</td><td>1.0</td>")
+	assert.NotContains(t, actual.Report, "<h2>Violations</h2>")
+	assert.NotContains(t, actual.Report, "<h2>Errors</h2>")
+}
+
+func TestMakeNodesReportHTML_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeNodesReportHTML(nil, reporting.NodesFilter{}))
+}
+
+func TestMakeNodesReportHTML_WithRecords(t *testing.T) {
+	nodesReport := []*reporting.NodeReportItem{
+		&reporting.NodeReportItem{Name: "node1", ChefVersion: "12.22", OS: "windows", OSVersion: "10.1",
+			CookbookVersions: []reporting.CookbookVersion{
+				reporting.CookbookVersion{Name: "mycookbook", Version: "1.0"}},
+		},
+		&reporting.NodeReportItem{Name: "node2", ChefVersion: "15.00", PolicyName: "app", PolicyGroup: "prod",
+			OhaiTime: 1576492200, Stale: true},
+	}
+
+	actual := subject.MakeNodesReportHTML(nodesReport, reporting.NodesFilter{Environment: "qa"})
+	assert.Empty(t, actual.Errors)
+
+	for _, s := range []string{
+		"<title>chef-analyze: Nodes Report</title>",
+		`<div class="card alert"><div class="value">1</div><div class="label">Stale Nodes</div></div>`,
+		`<div class="card"><div class="value">2</div><div class="label">Chef Versions</div></div>`,
+		"<p>Search query: <code>chef_environment:qa</code></p>",
+		`<table id="nodes" class="sortable">`,
+		`<tr><td>node1</td><td>12.22</td><td>windows v10.1</td><td></td>` +
+			`<td class="number" data-sort="1"><details><summary>1</summary>mycookbook(1.0)</details></td><td></td></tr>`,
+		`<tr><td>node2</td><td>15.00</td><td></td><td>prod/app</td><td class="number" data-sort="0">0</td>` +
+			`<td>2019-12-16 10:30 UTC <span class="error">(stale)</span></td></tr>`,
+	} {
+		assert.Containsf(t, actual.Report, s, "there is something missing in the report: '%s' is missing", s)
+	}
+}
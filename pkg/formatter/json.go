@@ -0,0 +1,302 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
+
+// the version of the JSON reports schema, the schemas are published inside the
+// 'schemas/' directory of this repository and shipped alongside the binary
+//
+// NOTE: bump this version every time the structure of the JSON reports changes
+const JSONSchemaVersion = "1"
+
+// types of errors a cookbook record can have
+const (
+	jsonDownloadErrorType    = "download"
+	jsonUsageLookupErrorType = "usage_lookup"
+	jsonCookstyleErrorType   = "cookstyle"
+)
+
+type jsonCookbooksReport struct {
+	SchemaVersion  string               `json:"schema_version"`
+	Report         string               `json:"report"`
+	VerifyUpgrade  bool                 `json:"verify_upgrade"`
+	OnlyUnused     bool                 `json:"only_unused"`
+	TotalCookbooks int                  `json:"total_cookbooks"`
+	Cookbooks      []jsonCookbookRecord `json:"cookbooks"`
+	PolicyErrors   []string             `json:"policy_errors"`
+}
+
+type jsonCookbookRecord struct {
+	Name              string                       `json:"name"`
+	Version           string                       `json:"version"`
+	Nodes             []string                     `json:"nodes"`
+	Policies          []string                     `json:"policies"`
+	NumNodesAffected  int                          `json:"num_nodes_affected"`
+	NumOffenses       int                          `json:"num_offenses"`
+	NumCorrectable    int                          `json:"num_correctable"`
+	Files             []reporting.CookbookFile     `json:"files"`
+	CookstyleMetadata *reporting.CookstyleMetadata `json:"cookstyle_metadata,omitempty"`
+	Errors            []jsonRecordError            `json:"errors"`
+}
+
+type jsonRecordError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+type jsonNodesReport struct {
+	SchemaVersion string           `json:"schema_version"`
+	Report        string           `json:"report"`
+	SearchQuery   string           `json:"search_query"`
+	Nodes         []jsonNodeRecord `json:"nodes"`
+}
+
+type jsonNodeRecord struct {
+	Name        string                `json:"name"`
+	ChefVersion string                `json:"chef_version"`
+	OS          string                `json:"os"`
+	OSVersion   string                `json:"os_version"`
+	PolicyName  string                `json:"policy_name"`
+	PolicyGroup string                `json:"policy_group"`
+	FQDN        string                `json:"fqdn"`
+	Hostname    string                `json:"hostname"`
+	IPAddress   string                `json:"ipaddress"`
+	LastCheckIn string                `json:"last_check_in"`
+	Stale       bool                  `json:"stale"`
+	Cookbooks   []jsonCookbookVersion `json:"cookbooks"`
+}
+
+type jsonCookbookVersion struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type jsonDependenciesReport struct {
+	SchemaVersion    string                     `json:"schema_version"`
+	Report           string                     `json:"report"`
+	TotalCookbooks   int                        `json:"total_cookbooks"`
+	Cookbooks        []jsonCookbookDependencies `json:"cookbooks"`
+	Cycles           [][]string                 `json:"cycles"`
+	LeafDependencies []string                   `json:"leaf_dependencies"`
+}
+
+type jsonCookbookDependencies struct {
+	Name         string                `json:"name"`
+	Version      string                `json:"version"`
+	Dependencies []jsonDependency      `json:"dependencies"`
+	Dependents   []jsonCookbookVersion `json:"dependents"`
+	Errors       []string              `json:"errors"`
+}
+
+type jsonDependency struct {
+	Cookbook   string   `json:"cookbook"`
+	Constraint string   `json:"constraint"`
+	Satisfied  bool     `json:"satisfied"`
+	Resolved   string   `json:"resolved"`
+	Versions   []string `json:"versions"`
+}
+
+func MakeCookbooksReportJSON(state *reporting.CookbooksStatus) *FormattedResult {
+	if state == nil || len(state.Records) == 0 {
+		// nothing to do
+		return &FormattedResult{"", ""}
+	}
+
+	report := jsonCookbooksReport{
+		SchemaVersion:  JSONSchemaVersion,
+		Report:         "cookbooks",
+		VerifyUpgrade:  state.RunCookstyle,
+		OnlyUnused:     state.OnlyUnused,
+		TotalCookbooks: state.TotalCookbooks,
+		Cookbooks:      make([]jsonCookbookRecord, 0, len(state.Records)),
+		PolicyErrors:   make([]string, 0, len(state.PolicyErrors)),
+	}
+	for _, e := range state.PolicyErrors {
+		report.PolicyErrors = append(report.PolicyErrors, e.Error())
+	}
+
+	for _, record := range state.Records {
+		jsonRecord := jsonCookbookRecord{
+			Name:             record.Name,
+			Version:          record.Version,
+			Nodes:            record.Nodes,
+			Policies:         record.Policies,
+			NumNodesAffected: record.NumNodesAffected(),
+			NumOffenses:      record.NumOffenses(),
+			NumCorrectable:   record.NumCorrectable(),
+			Files:            record.Files,
+			Errors:           jsonCookbookRecordErrors(record),
+		}
+
+		// we only have metadata when cookstyle was run successfully
+		if state.RunCookstyle && record.CookstyleError == nil && record.DownloadError == nil {
+			metadata := record.CookstyleMetadata
+			jsonRecord.CookstyleMetadata = &metadata
+		}
+
+		// avoid 'null' values in our JSON report
+		if jsonRecord.Nodes == nil {
+			jsonRecord.Nodes = []string{}
+		}
+		if jsonRecord.Policies == nil {
+			jsonRecord.Policies = []string{}
+		}
+		if jsonRecord.Files == nil {
+			jsonRecord.Files = []reporting.CookbookFile{}
+		}
+
+		report.Cookbooks = append(report.Cookbooks, jsonRecord)
+	}
+
+	return marshalJSONReport(report)
+}
+
+func MakeNodesReportJSON(records []*reporting.NodeReportItem, filter reporting.NodesFilter) *FormattedResult {
+	if len(records) == 0 {
+		// nothing to do
+		return &FormattedResult{"", ""}
+	}
+
+	report := jsonNodesReport{
+		SchemaVersion: JSONSchemaVersion,
+		Report:        "nodes",
+		SearchQuery:   filter.SearchStatement(),
+		Nodes:         make([]jsonNodeRecord, 0, len(records)),
+	}
+
+	for _, record := range records {
+		jsonRecord := jsonNodeRecord{
+			Name:        record.Name,
+			ChefVersion: record.ChefVersion,
+			OS:          record.OS,
+			OSVersion:   record.OSVersion,
+			PolicyName:  record.PolicyName,
+			PolicyGroup: record.PolicyGroup,
+			FQDN:        record.FQDN,
+			Hostname:    record.Hostname,
+			IPAddress:   record.IPAddress,
+			Stale:       record.Stale,
+			Cookbooks:   make([]jsonCookbookVersion, 0, len(record.CookbookVersions)),
+		}
+		if checkIn := record.LastCheckIn(); !checkIn.IsZero() {
+			jsonRecord.LastCheckIn = checkIn.UTC().Format(time.RFC3339)
+		}
+
+		for _, cbv := range record.CookbookVersions {
+			jsonRecord.Cookbooks = append(jsonRecord.Cookbooks,
+				jsonCookbookVersion{Name: cbv.Name, Version: cbv.Version},
+			)
+		}
+
+		report.Nodes = append(report.Nodes, jsonRecord)
+	}
+
+	return marshalJSONReport(report)
+}
+
+func MakeDependenciesReportJSON(graph *reporting.DependencyGraph) *FormattedResult {
+	if graph == nil || len(graph.Cookbooks) == 0 {
+		// nothing to do
+		return &FormattedResult{"", ""}
+	}
+
+	report := jsonDependenciesReport{
+		SchemaVersion:    JSONSchemaVersion,
+		Report:           "dependencies",
+		TotalCookbooks:   graph.TotalCookbooks,
+		Cookbooks:        make([]jsonCookbookDependencies, 0, len(graph.Cookbooks)),
+		Cycles:           graph.Cycles,
+		LeafDependencies: graph.LeafDependencies,
+	}
+
+	for _, cd := range graph.Cookbooks {
+		jsonRecord := jsonCookbookDependencies{
+			Name:         cd.Name,
+			Version:      cd.Version,
+			Dependencies: make([]jsonDependency, 0, len(cd.Dependencies)),
+			Dependents:   make([]jsonCookbookVersion, 0, len(cd.Dependents)),
+			Errors:       make([]string, 0),
+		}
+
+		for _, d := range cd.Dependencies {
+			jsonDep := jsonDependency{
+				Cookbook:   d.Cookbook,
+				Constraint: d.Constraint,
+				Satisfied:  d.Satisfied(),
+				Resolved:   d.Resolved(),
+				Versions:   d.Versions,
+			}
+			// avoid 'null' values in our JSON report
+			if jsonDep.Versions == nil {
+				jsonDep.Versions = []string{}
+			}
+			jsonRecord.Dependencies = append(jsonRecord.Dependencies, jsonDep)
+		}
+		for _, dependent := range cd.Dependents {
+			jsonRecord.Dependents = append(jsonRecord.Dependents,
+				jsonCookbookVersion{Name: dependent.Name, Version: dependent.Version},
+			)
+		}
+		for _, e := range cd.Errors() {
+			jsonRecord.Errors = append(jsonRecord.Errors, e.Error())
+		}
+
+		report.Cookbooks = append(report.Cookbooks, jsonRecord)
+	}
+
+	// avoid 'null' values in our JSON report
+	if report.Cycles == nil {
+		report.Cycles = [][]string{}
+	}
+	if report.LeafDependencies == nil {
+		report.LeafDependencies = []string{}
+	}
+
+	return marshalJSONReport(report)
+}
+
+func jsonCookbookRecordErrors(record *reporting.CookbookRecord) []jsonRecordError {
+	errs := make([]jsonRecordError, 0)
+	if record.DownloadError != nil {
+		errs = append(errs, jsonRecordError{jsonDownloadErrorType, record.DownloadError.Error()})
+	}
+	if record.UsageLookupError != nil {
+		errs = append(errs, jsonRecordError{jsonUsageLookupErrorType, record.UsageLookupError.Error()})
+	}
+	if record.CookstyleError != nil {
+		errs = append(errs, jsonRecordError{jsonCookstyleErrorType, record.CookstyleError.Error()})
+	}
+	return errs
+}
+
+// errors are part of the JSON report itself, therefore, the only error we
+// could return is when we are unable to marshal the report
+func marshalJSONReport(report interface{}) *FormattedResult {
+	jsonBytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return &FormattedResult{"", fmt.Sprintf(" - unable to generate JSON report: %v\n", err)}
+	}
+
+	return &FormattedResult{string(jsonBytes) + "\n", ""}
+}
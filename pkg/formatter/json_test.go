@@ -0,0 +1,243 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package formatter_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/formatter"
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func TestMakeCookbooksReportJSON_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeCookbooksReportJSON(nil))
+}
+
+func TestMakeCookbooksReportJSON_Empty(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeCookbooksReportJSON(&reporting.CookbooksStatus{}))
+}
+
+func TestMakeCookbooksReportJSON_WithVerifiedRecords(t *testing.T) {
+	offense := reporting.CookstyleOffense{
+		CopName: "ChefDeprecations/Blah", Message: "some description", Severity: "warning", Correctable: true,
+	}
+	offense.Location.StartLine = 3
+	offense.Location.LastColumn = 12
+
+	cbStatus := reporting.CookbooksStatus{
+		RunCookstyle:   true,
+		TotalCookbooks: 2,
+		PolicyErrors:   []error{errors.New("unable to retrieve policy groups: 403 Forbidden")},
+		Records: []*reporting.CookbookRecord{
+			&reporting.CookbookRecord{Name: "my-cookbook", Version: "1.0", Nodes: []string{"node-1", "node-2"},
+				Policies:          []string{"prod/app"},
+				CookstyleMetadata: reporting.CookstyleMetadata{RubocopVersion: "0.75.1", RubyVersion: "2.6.5"},
+				Files: []reporting.CookbookFile{
+					reporting.CookbookFile{Path: "/path/to/file.rb", Offenses: []reporting.CookstyleOffense{offense}},
+				},
+			},
+			&reporting.CookbookRecord{Name: "their-cookbook", Version: "1.1",
+				DownloadError:    errors.New("could not download"),
+				UsageLookupError: errors.New("could not look up usage"),
+			},
+		},
+	}
+
+	actual := subject.MakeCookbooksReportJSON(&cbStatus)
+	assert.Empty(t, actual.Errors, "errors should be embedded in the JSON report")
+
+	var report map[string]interface{}
+	if assert.Nil(t, json.Unmarshal([]byte(actual.Report), &report)) {
+		assert.Equal(t, subject.JSONSchemaVersion, report["schema_version"])
+		assert.Equal(t, "cookbooks", report["report"])
+		assert.Equal(t, true, report["verify_upgrade"])
+		assert.Equal(t, false, report["only_unused"])
+		assert.Equal(t, float64(2), report["total_cookbooks"])
+		assert.Equal(t, []interface{}{"unable to retrieve policy groups: 403 Forbidden"}, report["policy_errors"])
+
+		cookbooks := report["cookbooks"].([]interface{})
+		if assert.Equal(t, 2, len(cookbooks)) {
+			cookbook := cookbooks[0].(map[string]interface{})
+			assert.Equal(t, "my-cookbook", cookbook["name"])
+			assert.Equal(t, "1.0", cookbook["version"])
+			assert.Equal(t, []interface{}{"node-1", "node-2"}, cookbook["nodes"])
+			assert.Equal(t, []interface{}{"prod/app"}, cookbook["policies"])
+			assert.Equal(t, float64(2), cookbook["num_nodes_affected"])
+			assert.Equal(t, float64(1), cookbook["num_offenses"])
+			assert.Equal(t, float64(1), cookbook["num_correctable"])
+			assert.Equal(t, []interface{}{}, cookbook["errors"])
+
+			metadata := cookbook["cookstyle_metadata"].(map[string]interface{})
+			assert.Equal(t, "0.75.1", metadata["rubocop_version"])
+			assert.Equal(t, "2.6.5", metadata["ruby_version"])
+
+			files := cookbook["files"].([]interface{})
+			if assert.Equal(t, 1, len(files)) {
+				file := files[0].(map[string]interface{})
+				assert.Equal(t, "/path/to/file.rb", file["path"])
+				jsonOffense := file["offenses"].([]interface{})[0].(map[string]interface{})
+				assert.Equal(t, "ChefDeprecations/Blah", jsonOffense["cop_name"])
+				assert.Equal(t, "warning", jsonOffense["severity"])
+				assert.Equal(t, true, jsonOffense["correctable"])
+				location := jsonOffense["location"].(map[string]interface{})
+				assert.Equal(t, float64(3), location["start_line"])
+				assert.Equal(t, float64(12), location["last_column"])
+			}
+
+			cookbook = cookbooks[1].(map[string]interface{})
+			assert.Equal(t, "their-cookbook", cookbook["name"])
+			assert.Equal(t, []interface{}{}, cookbook["nodes"])
+			assert.Equal(t, []interface{}{}, cookbook["policies"])
+			assert.Equal(t, []interface{}{}, cookbook["files"])
+			assert.Nil(t, cookbook["cookstyle_metadata"], "metadata should not be reported on errors")
+			assert.Equal(t,
+				[]interface{}{
+					map[string]interface{}{"type": "download", "message": "could not download"},
+					map[string]interface{}{"type": "usage_lookup", "message": "could not look up usage"},
+				},
+				cookbook["errors"],
+			)
+		}
+	}
+}
+
+func TestMakeNodesReportJSON_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeNodesReportJSON(nil, reporting.NodesFilter{}))
+}
+
+func TestMakeNodesReportJSON_WithRecords(t *testing.T) {
+	nodesReport := []*reporting.NodeReportItem{
+		&reporting.NodeReportItem{Name: "node1", ChefVersion: "12.22", OS: "windows", OSVersion: "10.1",
+			CookbookVersions: []reporting.CookbookVersion{
+				reporting.CookbookVersion{Name: "mycookbook", Version: "1.0"}},
+		},
+		&reporting.NodeReportItem{Name: "node2", ChefVersion: "15.00", PolicyName: "app", PolicyGroup: "prod",
+			FQDN: "node2.example.com", Hostname: "node2", IPAddress: "10.0.0.2", OhaiTime: 1576492200, Stale: true,
+			CookbookVersions: nil},
+	}
+
+	actual := subject.MakeNodesReportJSON(nodesReport, reporting.NodesFilter{Environment: "qa"})
+	assert.Empty(t, actual.Errors)
+	assert.JSONEq(t, `{
+  "schema_version": "1",
+  "report": "nodes",
+  "search_query": "chef_environment:qa",
+  "nodes": [
+    {
+      "name": "node1",
+      "chef_version": "12.22",
+      "os": "windows",
+      "os_version": "10.1",
+      "policy_name": "",
+      "policy_group": "",
+      "fqdn": "",
+      "hostname": "",
+      "ipaddress": "",
+      "last_check_in": "",
+      "stale": false,
+      "cookbooks": [{"name": "mycookbook", "version": "1.0"}]
+    },
+    {
+      "name": "node2",
+      "chef_version": "15.00",
+      "os": "",
+      "os_version": "",
+      "policy_name": "app",
+      "policy_group": "prod",
+      "fqdn": "node2.example.com",
+      "hostname": "node2",
+      "ipaddress": "10.0.0.2",
+      "last_check_in": "2019-12-16T10:30:00Z",
+      "stale": true,
+      "cookbooks": []
+    }
+  ]
+}`, actual.Report)
+}
+
+// every version of our JSON reports must have a published schema
+func TestJSONSchemasArePublished(t *testing.T) {
+	for _, report := range []string{"cookbooks", "nodes", "dependencies"} {
+		schemaPath := filepath.Join("..", "..", "schemas", "v"+subject.JSONSchemaVersion, report+"-report.schema.json")
+		schemaBytes, err := ioutil.ReadFile(schemaPath)
+		if assert.Nilf(t, err, "missing JSON schema for the %s report", report) {
+			var schema map[string]interface{}
+			assert.Nil(t, json.Unmarshal(schemaBytes, &schema), "malformed JSON schema")
+		}
+	}
+}
+
+func TestMakeDependenciesReportJSON_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeDependenciesReportJSON(nil))
+}
+
+func TestMakeDependenciesReportJSON(t *testing.T) {
+	graph := dependencyGraphFixture()
+	graph.Cookbooks = graph.Cookbooks[:3]
+
+	actual := subject.MakeDependenciesReportJSON(graph)
+	assert.Empty(t, actual.Errors, "errors should be embedded in the JSON report")
+	assert.JSONEq(t, `{
+  "schema_version": "1",
+  "report": "dependencies",
+  "total_cookbooks": 4,
+  "cookbooks": [
+    {
+      "name": "app",
+      "version": "1.2.3",
+      "dependencies": [
+        {"cookbook": "base", "constraint": "~> 2.0", "satisfied": true, "resolved": "2.1.0", "versions": ["2.1.0"]},
+        {"cookbook": "mysql", "constraint": ">= 8.0", "satisfied": false, "resolved": "", "versions": []}
+      ],
+      "dependents": [],
+      "errors": []
+    },
+    {
+      "name": "base",
+      "version": "2.1.0",
+      "dependencies": [
+        {"cookbook": "common", "constraint": ">= 0.0.0", "satisfied": true, "resolved": "1.0.0", "versions": ["1.0.0"]}
+      ],
+      "dependents": [{"name": "app", "version": "1.2.3"}, {"name": "common", "version": "1.0.0"}],
+      "errors": []
+    },
+    {
+      "name": "broken",
+      "version": "0.1.0",
+      "dependencies": [],
+      "dependents": [],
+      "errors": ["not found"]
+    }
+  ],
+  "cycles": [["base", "common"]],
+  "leaf_dependencies": []
+}`, actual.Report)
+}
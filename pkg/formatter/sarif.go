@@ -0,0 +1,283 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package formatter
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
+
+// the SARIF report follows the Static Analysis Results Interchange Format 2.1.0
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+const (
+	SARIFVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	// the base of the artifact locations, every cookbook version is analyzed
+	// inside its own directory named 'COOKBOOK/VERSION/'
+	sarifURIBaseID = "COOKBOOKS"
+)
+
+type sarifReport struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Artifacts   []sarifArtifact   `json:"artifacts"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string            `json:"name"`
+	InformationURI string            `json:"informationUri"`
+	Rules          []sarifRule       `json:"rules"`
+	Properties     map[string]string `json:"properties,omitempty"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications"`
+}
+
+type sarifNotification struct {
+	Level   string       `json:"level"`
+	Message sarifMessage `json:"message"`
+}
+
+type sarifArtifact struct {
+	Location sarifArtifactLocation `json:"location"`
+	// index of the artifact of the cookbook version the file belongs to
+	ParentIndex *int              `json:"parentIndex,omitempty"`
+	Properties  map[string]string `json:"properties,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+	Index     *int   `json:"index,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID     string                `json:"ruleId"`
+	RuleIndex  int                   `json:"ruleIndex"`
+	Level      string                `json:"level"`
+	Message    sarifMessage          `json:"message"`
+	Locations  []sarifLocation       `json:"locations"`
+	Properties sarifResultProperties `json:"properties"`
+}
+
+type sarifResultProperties struct {
+	FixAvailable bool   `json:"fixAvailable"`
+	Cookbook     string `json:"cookbook"`
+	Version      string `json:"version"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// MakeCookbooksReportSARIF exports the cookstyle violations of a cookbooks
+// report that was generated running cookstyle, errors are reported as tool
+// execution notifications inside the report itself
+func MakeCookbooksReportSARIF(state *reporting.CookbooksStatus) *FormattedResult {
+	if state == nil || len(state.Records) == 0 {
+		// nothing to do
+		return &FormattedResult{"", ""}
+	}
+
+	var (
+		run = sarifRun{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "cookstyle",
+				InformationURI: "https://docs.chef.io/workstation/cookstyle/",
+				Rules:          make([]sarifRule, 0),
+			}},
+			Invocations: []sarifInvocation{{
+				ExecutionSuccessful:        true,
+				ToolExecutionNotifications: make([]sarifNotification, 0),
+			}},
+			Artifacts: make([]sarifArtifact, 0),
+			Results:   make([]sarifResult, 0),
+		}
+		rules = sarifRules(state.Records)
+	)
+
+	for _, id := range rules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: id})
+	}
+
+	// policies are additional usage information, the analysis itself succeeded
+	for _, e := range state.PolicyErrors {
+		run.Invocations[0].ToolExecutionNotifications = append(run.Invocations[0].ToolExecutionNotifications,
+			sarifNotification{Level: "warning", Message: sarifMessage{fmt.Sprintf("policies: %v", e)}},
+		)
+	}
+
+	for _, record := range state.Records {
+		for _, e := range record.Errors() {
+			run.Invocations[0].ExecutionSuccessful = false
+			run.Invocations[0].ToolExecutionNotifications = append(run.Invocations[0].ToolExecutionNotifications,
+				sarifNotification{
+					Level:   "error",
+					Message: sarifMessage{fmt.Sprintf("%s (%s): %v", record.Name, record.Version, e)},
+				},
+			)
+		}
+
+		// we only have metadata when cookstyle was run successfully
+		if run.Tool.Driver.Properties == nil && record.CookstyleError == nil && record.DownloadError == nil &&
+			record.CookstyleMetadata.RubocopVersion != "" {
+			run.Tool.Driver.Properties = map[string]string{
+				"rubocopVersion": record.CookstyleMetadata.RubocopVersion,
+				"rubyEngine":     record.CookstyleMetadata.RubyEngine,
+				"rubyVersion":    record.CookstyleMetadata.RubyVersion,
+				"rubyPatchlevel": record.CookstyleMetadata.RubyPatchlevel,
+				"rubyPlatform":   record.CookstyleMetadata.RubyPlatform,
+			}
+		}
+
+		if record.NumOffenses() == 0 {
+			continue
+		}
+
+		// every cookbook version is an artifact and the files with violations are its children
+		parentIndex := len(run.Artifacts)
+		run.Artifacts = append(run.Artifacts, sarifArtifact{
+			Location:   sarifArtifactLocation{URI: record.Name + "/" + record.Version + "/", URIBaseID: sarifURIBaseID},
+			Properties: map[string]string{"cookbook": record.Name, "version": record.Version},
+		})
+
+		for _, file := range record.Files {
+			if len(file.Offenses) == 0 {
+				continue
+			}
+
+			artifactIndex := len(run.Artifacts)
+			location := sarifArtifactLocation{
+				URI:       path.Join(record.Name, record.Version, file.Path),
+				URIBaseID: sarifURIBaseID,
+				Index:     &artifactIndex,
+			}
+			run.Artifacts = append(run.Artifacts, sarifArtifact{
+				Location:    sarifArtifactLocation{URI: location.URI, URIBaseID: sarifURIBaseID},
+				ParentIndex: &parentIndex,
+			})
+
+			for _, offense := range file.Offenses {
+				run.Results = append(run.Results, sarifResult{
+					RuleID:    offense.CopName,
+					RuleIndex: sort.SearchStrings(rules, offense.CopName),
+					Level:     sarifLevel(offense.Severity),
+					Message:   sarifMessage{offense.Message},
+					Locations: []sarifLocation{{
+						PhysicalLocation: sarifPhysicalLocation{
+							ArtifactLocation: location,
+							Region:           sarifOffenseRegion(offense),
+						},
+					}},
+					Properties: sarifResultProperties{
+						FixAvailable: offense.Correctable,
+						Cookbook:     record.Name,
+						Version:      record.Version,
+					},
+				})
+			}
+		}
+	}
+
+	return marshalJSONReport(sarifReport{Schema: sarifSchema, Version: SARIFVersion, Runs: []sarifRun{run}})
+}
+
+// returns the sorted list of cops with violations, they are the rules of the report
+func sarifRules(records []*reporting.CookbookRecord) []string {
+	cops := map[string]bool{}
+	for _, record := range records {
+		for _, file := range record.Files {
+			for _, offense := range file.Offenses {
+				cops[offense.CopName] = true
+			}
+		}
+	}
+
+	rules := make([]string, 0, len(cops))
+	for cop := range cops {
+		rules = append(rules, cop)
+	}
+	sort.Strings(rules)
+	return rules
+}
+
+// maps the severity of a cookstyle offense to the level of a SARIF result
+// https://docs.rubocop.org/en/latest/configuration/#severity
+func sarifLevel(severity string) string {
+	switch severity {
+	case "error", "fatal":
+		return "error"
+	case "info", "refactor", "convention":
+		return "note"
+	default:
+		return "warning"
+	}
+}
+
+// cookstyle columns are 1-based and the last column is inclusive, SARIF
+// end columns are exclusive, unknown positions are left out of the region
+func sarifOffenseRegion(offense reporting.CookstyleOffense) *sarifRegion {
+	if offense.Location.StartLine == 0 {
+		return nil
+	}
+
+	region := &sarifRegion{
+		StartLine:   offense.Location.StartLine,
+		StartColumn: offense.Location.StartColumn,
+		EndLine:     offense.Location.LastLine,
+	}
+	if offense.Location.LastColumn != 0 {
+		region.EndColumn = offense.Location.LastColumn + 1
+	}
+	return region
+}
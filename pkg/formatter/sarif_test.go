@@ -0,0 +1,164 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package formatter_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/formatter"
+	"github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func TestMakeCookbooksReportSARIF_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeCookbooksReportSARIF(nil))
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeCookbooksReportSARIF(&reporting.CookbooksStatus{}))
+}
+
+func TestMakeCookbooksReportSARIF(t *testing.T) {
+	deprecation := reporting.CookstyleOffense{
+		CopName: "ChefDeprecations/NodeSet", Message: "Do not use node.set", Severity: "warning", Correctable: true,
+	}
+	deprecation.Location.StartLine = 3
+	deprecation.Location.StartColumn = 1
+	deprecation.Location.LastLine = 3
+	deprecation.Location.LastColumn = 8
+
+	style := reporting.CookstyleOffense{CopName: "Chef/Style", Message: "Use single quotes", Severity: "convention"}
+	style.Location.StartLine = 10
+	style.Location.StartColumn = 5
+	style.Location.LastLine = 10
+	style.Location.LastColumn = 5
+
+	cbStatus := reporting.CookbooksStatus{
+		RunCookstyle: true,
+		Records: []*reporting.CookbookRecord{
+			&reporting.CookbookRecord{Name: "my-cookbook", Version: "1.0",
+				CookstyleMetadata: reporting.CookstyleMetadata{RubocopVersion: "0.75.1", RubyEngine: "ruby",
+					RubyVersion: "2.6.5", RubyPatchlevel: "114", RubyPlatform: "x86_64-linux"},
+				Files: []reporting.CookbookFile{
+					reporting.CookbookFile{Path: "recipes/default.rb",
+						Offenses: []reporting.CookstyleOffense{deprecation, style}},
+					reporting.CookbookFile{Path: "metadata.rb"},
+				},
+			},
+			&reporting.CookbookRecord{Name: "clean", Version: "2.0"},
+			&reporting.CookbookRecord{Name: "their-cookbook", Version: "1.1",
+				DownloadError: errors.New("could not download"),
+			},
+		},
+	}
+
+	actual := subject.MakeCookbooksReportSARIF(&cbStatus)
+	assert.Empty(t, actual.Errors, "errors should be embedded in the SARIF report")
+	assert.JSONEq(t, `{
+  "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+  "version": "2.1.0",
+  "runs": [
+    {
+      "tool": {
+        "driver": {
+          "name": "cookstyle",
+          "informationUri": "https://docs.chef.io/workstation/cookstyle/",
+          "rules": [{"id": "Chef/Style"}, {"id": "ChefDeprecations/NodeSet"}],
+          "properties": {
+            "rubocopVersion": "0.75.1",
+            "rubyEngine": "ruby",
+            "rubyVersion": "2.6.5",
+            "rubyPatchlevel": "114",
+            "rubyPlatform": "x86_64-linux"
+          }
+        }
+      },
+      "invocations": [
+        {
+          "executionSuccessful": false,
+          "toolExecutionNotifications": [
+            {"level": "error", "message": {"text": "their-cookbook (1.1): could not download"}}
+          ]
+        }
+      ],
+      "artifacts": [
+        {
+          "location": {"uri": "my-cookbook/1.0/", "uriBaseId": "COOKBOOKS"},
+          "properties": {"cookbook": "my-cookbook", "version": "1.0"}
+        },
+        {
+          "location": {"uri": "my-cookbook/1.0/recipes/default.rb", "uriBaseId": "COOKBOOKS"},
+          "parentIndex": 0
+        }
+      ],
+      "results": [
+        {
+          "ruleId": "ChefDeprecations/NodeSet",
+          "ruleIndex": 1,
+          "level": "warning",
+          "message": {"text": "Do not use node.set"},
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {"uri": "my-cookbook/1.0/recipes/default.rb", "uriBaseId": "COOKBOOKS", "index": 1},
+                "region": {"startLine": 3, "startColumn": 1, "endLine": 3, "endColumn": 9}
+              }
+            }
+          ],
+          "properties": {"fixAvailable": true, "cookbook": "my-cookbook", "version": "1.0"}
+        },
+        {
+          "ruleId": "Chef/Style",
+          "ruleIndex": 0,
+          "level": "note",
+          "message": {"text": "Use single quotes"},
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {"uri": "my-cookbook/1.0/recipes/default.rb", "uriBaseId": "COOKBOOKS", "index": 1},
+                "region": {"startLine": 10, "startColumn": 5, "endLine": 10, "endColumn": 6}
+              }
+            }
+          ],
+          "properties": {"fixAvailable": false, "cookbook": "my-cookbook", "version": "1.0"}
+        }
+      ]
+    }
+  ]
+}`, actual.Report)
+}
+
+func TestMakeCookbooksReportSARIF_Levels(t *testing.T) {
+	for severity, level := range map[string]string{
+		"fatal": "error", "error": "error", "warning": "warning",
+		"convention": "note", "refactor": "note", "info": "note", "": "warning",
+	} {
+		offense := reporting.CookstyleOffense{CopName: "Chef/Cop", Severity: severity}
+		actual := subject.MakeCookbooksReportSARIF(&reporting.CookbooksStatus{
+			RunCookstyle: true,
+			Records: []*reporting.CookbookRecord{&reporting.CookbookRecord{Name: "cb", Version: "1.0",
+				Files: []reporting.CookbookFile{reporting.CookbookFile{Path: "a.rb",
+					Offenses: []reporting.CookstyleOffense{offense}}},
+			}},
+		})
+		assert.Containsf(t, actual.Report, `"level": "`+level+`"`, "severity %s should be level %s", severity, level)
+		assert.NotContains(t, actual.Report, `"region"`, "unknown positions should be left out")
+	}
+}
@@ -49,17 +49,7 @@ func CookbooksReportSummary(state *reporting.CookbooksStatus) FormattedResult {
 
 	CookbooksReportHeader = append(CookbooksReportHeader, "Nodes Affected")
 
-	table.SetAutoWrapText(true)
-	table.SetReflowDuringAutoWrap(true)
-	table.SetHeader(CookbooksReportHeader)
-	table.SetAutoFormatHeaders(false) // don't make our headers capitalized
-	table.SetRowLine(false)           // don't show row seps
-	table.SetColumnSeparator(" ")
-	table.SetBorder(false)
-
-	// sets max for each col to 30 chars, this is not strictly enforced.
-	// unwrappable content will expand beyond this limit.
-	table.SetColWidth(MinTermWidth / len(CookbooksReportHeader))
+	setupSummaryTable(table, CookbooksReportHeader)
 
 	for _, record := range state.Records {
 		row := []string{record.Name, record.Version}
@@ -79,23 +69,7 @@ func CookbooksReportSummary(state *reporting.CookbooksStatus) FormattedResult {
 
 	table.Render()
 
-	var (
-		errMsg            strings.Builder
-		bufStr            = buffer.String()
-		lines             = strings.SplitN(bufStr, "\n", 2)
-		width             = tablewriter.DisplayWidth(lines[0])
-		termWidth, _, err = terminal.GetSize(int(os.Stdout.Fd()))
-	)
-	if err != nil {
-		termWidth = MinTermWidth
-	}
-
-	if termWidth < width {
-		errMsg.WriteString("\nNote:  To view the report with correct formatting, please expand")
-		errMsg.WriteString(fmt.Sprintf("\n       your terminal window to be at least %v characters wide\n", width))
-	}
-
-	return FormattedResult{buffer.String(), errMsg.String()}
+	return FormattedResult{buffer.String(), terminalWidthNote(buffer.String())}
 }
 
 func NodesReportSummary(records []*reporting.NodeReportItem) FormattedResult {
@@ -106,43 +80,493 @@ func NodesReportSummary(records []*reporting.NodeReportItem) FormattedResult {
 	var (
 		buffer           = bytes.NewBufferString("\n-- REPORT SUMMARY --\n\n")
 		table            = tablewriter.NewWriter(buffer)
-		NodeReportHeader = []string{"Node Name", "Chef Version", "Operating System", "Cookbooks"}
+		NodeReportHeader = []string{"Node Name", "Chef Version", "Operating System", "Cookbooks", "Last Check-In"}
 	)
 
-	// Let's look at content to pre-determine the best column widths
-	table.SetAutoWrapText(true)
-	table.SetReflowDuringAutoWrap(true)
-	table.SetHeader(NodeReportHeader)
-	table.SetAutoFormatHeaders(false) // don't make our headers capitalized
-	table.SetRowLine(false)           // don't show row seps
-	table.SetColumnSeparator(" ")
-	table.SetBorder(false)
-
-	// sets max for each col to 30 chars, this is not strictly enforced
-	// unwrappable content will expand beyond this limit
-	table.SetColWidth(MinTermWidth / len(NodeReportHeader))
+	setupSummaryTable(table, NodeReportHeader)
 
 	for _, record := range records {
+		checkIn := stringOrEmptyPlaceholder(lastCheckIn(record.LastCheckIn()))
+		if record.Stale {
+			checkIn += " (stale)"
+		}
+
 		table.Append(
 			[]string{
 				record.Name,
 				stringOrEmptyPlaceholder(record.ChefVersion),
 				stringOrEmptyPlaceholder(record.OSVersionPretty()),
 				strconv.Itoa(len(record.CookbooksList())),
+				checkIn,
+			},
+		)
+	}
+
+	table.Render()
+
+	return FormattedResult{buffer.String(), terminalWidthNote(buffer.String())}
+}
+
+// NodesHygieneSummary lists the stale nodes and the nodes that share the same
+// fqdn, hostname or IP address, it is displayed after the nodes summary and
+// appended to the text report
+func NodesHygieneSummary(hygiene *reporting.NodesHygiene) FormattedResult {
+	if hygiene == nil {
+		return FormattedResult{"", ""}
+	}
+
+	buffer := bytes.NewBufferString("\n-- NODES HYGIENE --\n\n")
+	if hygiene.IsClean() {
+		buffer.WriteString("No stale or duplicate nodes found\n")
+		return FormattedResult{buffer.String(), ""}
+	}
+
+	if hygiene.StaleAfter > 0 {
+		if len(hygiene.StaleNodes) == 0 {
+			buffer.WriteString(
+				fmt.Sprintf("Stale Nodes (no check-in for %s): none\n", humanDuration(hygiene.StaleAfter)),
+			)
+		} else {
+			buffer.WriteString(
+				fmt.Sprintf("Stale Nodes (no check-in for %s):\n", humanDuration(hygiene.StaleAfter)),
+			)
+		}
+		for _, node := range hygiene.StaleNodes {
+			buffer.WriteString(
+				fmt.Sprintf(" - %s (last check-in: %s)\n", node.Name, staleCheckIn(node.LastCheckIn())),
+			)
+		}
+	}
+
+	if len(hygiene.Duplicates) == 0 {
+		buffer.WriteString("Duplicate Nodes: none\n")
+	} else {
+		buffer.WriteString("Duplicate Nodes:\n")
+	}
+	for _, dup := range hygiene.Duplicates {
+		buffer.WriteString(
+			fmt.Sprintf(" - %s %s: %s\n", dup.Attribute, dup.Value, strings.Join(dup.Nodes, ", ")),
+		)
+	}
+
+	return FormattedResult{buffer.String(), ""}
+}
+
+// EffortEstimateSummary displays the ranked backlog of cookbook versions to
+// upgrade and the hours rolled up by team and organization, it is displayed
+// after the cookbooks summary and appended to the text report
+func EffortEstimateSummary(estimate *reporting.EffortEstimate) FormattedResult {
+	if estimate == nil {
+		return FormattedResult{"", ""}
+	}
+
+	buffer := bytes.NewBufferString("\n-- EFFORT ESTIMATE --\n\n")
+	if len(estimate.Cookbooks) == 0 {
+		buffer.WriteString("No cookbooks to estimate\n")
+		return FormattedResult{buffer.String(), ""}
+	}
+
+	table := tablewriter.NewWriter(buffer)
+	setupSummaryTable(table,
+		[]string{"Rank", "Cookbook", "Version", "Team", "Violations", "Nodes Affected", "Multiplier", "Hours"},
+	)
+	for i, effort := range estimate.Cookbooks {
+		table.Append(
+			[]string{
+				strconv.Itoa(i + 1),
+				effort.Name,
+				effort.Version,
+				effort.Team,
+				strconv.Itoa(effort.NumOffenses),
+				strconv.Itoa(effort.NumNodes),
+				fmt.Sprintf("x%g", effort.Multiplier),
+				humanHours(effort.Hours()),
+			},
+		)
+	}
+	table.Render()
+
+	buffer.WriteString(fmt.Sprintf("\nTotal: %s hours\n", humanHours(estimate.TotalHours)))
+	for _, team := range estimate.Teams {
+		buffer.WriteString(
+			fmt.Sprintf(" - %s: %s hours (%d cookbooks)\n", team.Name, humanHours(team.Hours), team.NumCookbooks),
+		)
+	}
+	if len(estimate.Unestimated) != 0 {
+		buffer.WriteString(
+			fmt.Sprintf("%d cookbooks could not be estimated, see the errors report\n", len(estimate.Unestimated)),
+		)
+	}
+
+	return FormattedResult{buffer.String(), terminalWidthNote(buffer.String())}
+}
+
+func RolesReportSummary(state *reporting.RolesStatus) FormattedResult {
+	if state == nil || len(state.Records) == 0 {
+		return FormattedResult{"No roles found to analyze.", ""}
+	}
+
+	var (
+		buffer           = bytes.NewBufferString("\n-- REPORT SUMMARY --\n\n")
+		table            = tablewriter.NewWriter(buffer)
+		RoleReportHeader = []string{"Role", "Run List Items", "Nested Roles", "Missing Items", "Nodes"}
+	)
+
+	setupSummaryTable(table, RoleReportHeader)
+
+	for _, record := range state.Records {
+		missing := len(record.MissingRoles) + len(record.MissingCookbooks) + len(record.MissingRecipes)
+		nodes := strconv.Itoa(record.NumNodes())
+		if record.Unused() {
+			nodes = "0 (unused)"
+		}
+
+		table.Append(
+			[]string{
+				record.Name,
+				strconv.Itoa(len(record.RunList)),
+				strconv.Itoa(len(record.NestedRoles)),
+				strconv.Itoa(missing),
+				nodes,
+			},
+		)
+	}
+
+	table.Render()
+
+	return FormattedResult{buffer.String(), terminalWidthNote(buffer.String())}
+}
+
+func EnvironmentsReportSummary(state *reporting.EnvironmentsStatus) FormattedResult {
+	if state == nil || len(state.Records) == 0 {
+		return FormattedResult{"No environments found to analyze.", ""}
+	}
+
+	var (
+		buffer                  = bytes.NewBufferString("\n-- REPORT SUMMARY --\n\n")
+		table                   = tablewriter.NewWriter(buffer)
+		EnvironmentReportHeader = []string{"Environment", "Constraints", "Unsatisfied", "Solvable"}
+	)
+
+	if state.RunCookstyle {
+		EnvironmentReportHeader = append(EnvironmentReportHeader, "Violations", "Auto-correctable")
+	}
+
+	EnvironmentReportHeader = append(EnvironmentReportHeader, "Nodes")
+
+	setupSummaryTable(table, EnvironmentReportHeader)
+
+	for _, record := range state.Records {
+		row := []string{
+			record.Name,
+			strconv.Itoa(len(record.Constraints)),
+			strconv.Itoa(record.NumUnsatisfied()),
+			environmentSolvable(record),
+		}
+
+		// only include violations if we ran cookstyle
+		if state.RunCookstyle {
+			offenses, correctable := 0, 0
+			for _, c := range record.Constraints {
+				if c.Cookstyle != nil {
+					offenses += c.Cookstyle.NumOffenses()
+					correctable += c.Cookstyle.NumCorrectable()
+				}
+			}
+			row = append(row, strconv.Itoa(offenses), strconv.Itoa(correctable))
+		}
+
+		row = append(row, strconv.Itoa(record.NumNodes))
+
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return FormattedResult{buffer.String(), terminalWidthNote(buffer.String())}
+}
+
+func environmentSolvable(record *reporting.EnvironmentRecord) string {
+	switch {
+	case record.Solution == nil:
+		return unknownValuePlaceholder
+	case record.Solvable():
+		return "Y"
+	default:
+		return "N"
+	}
+}
+
+func DataBagsReportSummary(state *reporting.DataBagsStatus) FormattedResult {
+	if state == nil || len(state.Records) == 0 {
+		return FormattedResult{"No data bags found to analyze.", ""}
+	}
+
+	var (
+		buffer              = bytes.NewBufferString("\n-- REPORT SUMMARY --\n\n")
+		table               = tablewriter.NewWriter(buffer)
+		DataBagReportHeader = []string{"Data Bag", "Items", "Encrypted", "Legacy Encryption"}
+	)
+
+	setupSummaryTable(table, DataBagReportHeader)
+
+	for _, record := range state.Records {
+		table.Append(
+			[]string{
+				record.Name,
+				strconv.Itoa(record.NumItems()),
+				strconv.Itoa(record.NumEncrypted()),
+				strconv.Itoa(record.NumLegacyEncrypted()),
+			},
+		)
+	}
+
+	table.Render()
+
+	return FormattedResult{buffer.String(), terminalWidthNote(buffer.String())}
+}
+
+// number of characters of the policy revision IDs to display in the summary
+const shortRevisionIDLength = 10
+
+func PoliciesReportSummary(state *reporting.PoliciesStatus) FormattedResult {
+	if state == nil || len(state.Records) == 0 {
+		return FormattedResult{"No policy groups found to analyze.", ""}
+	}
+
+	var (
+		buffer             = bytes.NewBufferString("\n-- REPORT SUMMARY --\n\n")
+		table              = tablewriter.NewWriter(buffer)
+		PolicyReportHeader = []string{"Policy Group", "Policy", "Revision", "Cookbooks", "Nodes"}
+	)
+
+	setupSummaryTable(table, PolicyReportHeader)
+
+	for _, record := range state.Records {
+		for _, policy := range record.Policies {
+			revision := policy.RevisionID
+			if len(revision) > shortRevisionIDLength {
+				revision = revision[:shortRevisionIDLength]
+			}
+
+			table.Append(
+				[]string{
+					record.Name,
+					policy.Name,
+					stringOrEmptyPlaceholder(revision),
+					strconv.Itoa(len(policy.CookbookLocks)),
+					strconv.Itoa(policy.NumNodes),
+				},
+			)
+		}
+	}
+
+	table.Render()
+
+	return FormattedResult{buffer.String(), terminalWidthNote(buffer.String())}
+}
+
+func FixesReportSummary(state *reporting.FixStatus) FormattedResult {
+	if state == nil || len(state.Fixes) == 0 {
+		return FormattedResult{"No available cookbooks to fix", ""}
+	}
+
+	var (
+		buffer            = bytes.NewBufferString("\n-- FIX SUMMARY --\n\n")
+		table             = tablewriter.NewWriter(buffer)
+		FixesReportHeader = []string{"Cookbook", "Version", "Corrected", "Remaining", "Files Changed"}
+	)
+
+	setupSummaryTable(table, FixesReportHeader)
+
+	for _, fix := range state.Fixes {
+		row := []string{fix.Name, fix.Version}
+		if len(fix.Errors()) != 0 {
+			row = append(row, unknownValuePlaceholder, unknownValuePlaceholder, unknownValuePlaceholder)
+		} else {
+			row = append(row,
+				strconv.Itoa(fix.NumCorrected()),
+				strconv.Itoa(fix.NumRemaining()),
+				strconv.Itoa(len(fix.ChangedFiles())),
+			)
+		}
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return FormattedResult{buffer.String(), terminalWidthNote(buffer.String())}
+}
+
+func DependenciesReportSummary(graph *reporting.DependencyGraph) FormattedResult {
+	if graph == nil || len(graph.Cookbooks) == 0 {
+		return FormattedResult{"No available cookbooks to generate a report", ""}
+	}
+
+	var (
+		buffer                   = bytes.NewBufferString("\n-- REPORT SUMMARY --\n\n")
+		table                    = tablewriter.NewWriter(buffer)
+		DependenciesReportHeader = []string{"Cookbook", "Version", "Dependencies", "Unsatisfied", "Dependents"}
+	)
+
+	setupSummaryTable(table, DependenciesReportHeader)
+
+	for _, cd := range graph.Cookbooks {
+		row := []string{cd.Name, cd.Version}
+		if cd.GetError != nil {
+			row = append(row, unknownValuePlaceholder, unknownValuePlaceholder)
+		} else {
+			row = append(row, strconv.Itoa(len(cd.Dependencies)), strconv.Itoa(len(cd.Unsatisfied())))
+		}
+		row = append(row, strconv.Itoa(len(cd.Dependents)))
+		table.Append(row)
+	}
+
+	table.Render()
+
+	buffer.WriteString(fmt.Sprintf("\n%d unsatisfied dependencies, %d circular dependencies, %d leaf dependencies\n",
+		graph.NumUnsatisfied(), len(graph.Cycles), len(graph.LeafDependencies)))
+
+	return FormattedResult{buffer.String(), terminalWidthNote(buffer.String())}
+}
+
+func ClientVersionsReportSummary(state *reporting.ClientVersionsStatus) FormattedResult {
+	if state == nil || len(state.Records) == 0 {
+		return FormattedResult{"No nodes found to analyze.", ""}
+	}
+
+	var (
+		buffer                     = bytes.NewBufferString("\n-- REPORT SUMMARY --\n\n")
+		table                      = tablewriter.NewWriter(buffer)
+		ClientVersionsReportHeader = []string{
+			"Chef Version", "Support", "EOL", "Nodes", "Percentage", "Ohai Versions", "Ruby Versions",
+		}
+	)
+
+	setupSummaryTable(table, ClientVersionsReportHeader)
+
+	for _, record := range state.Records {
+		table.Append(
+			[]string{
+				stringOrUnknownPlaceholder(record.Version),
+				record.Status,
+				stringOrEmptyPlaceholder(record.EOL),
+				strconv.Itoa(record.NumNodes()),
+				fmt.Sprintf("%.1f%%", state.Percentage(record)),
+				stringOrEmptyPlaceholder(strings.Join(record.OhaiVersions, ", ")),
+				stringOrEmptyPlaceholder(strings.Join(record.RubyVersions, ", ")),
 			},
 		)
 	}
 
 	table.Render()
 
+	unsupported := len(state.UnsupportedNodes())
+	buffer.WriteString(fmt.Sprintf("\n%d of %d nodes run an unsupported Chef Infra Client release\n",
+		unsupported, state.TotalNodes))
+
+	return FormattedResult{buffer.String(), terminalWidthNote(buffer.String())}
+}
+
+func OSSupportReportSummary(state *reporting.OSSupportStatus) FormattedResult {
+	if state == nil || len(state.Records) == 0 {
+		return FormattedResult{"No nodes found to analyze.", ""}
+	}
+
+	var (
+		buffer                = bytes.NewBufferString("\n-- REPORT SUMMARY --\n\n")
+		table                 = tablewriter.NewWriter(buffer)
+		OSSupportReportHeader = []string{"Operating System", "Support", "EOL", "Nodes"}
+		count                 = state.NodesByStatus()
+	)
+
+	setupSummaryTable(table, OSSupportReportHeader)
+
+	for _, record := range state.Records {
+		table.Append(
+			[]string{
+				stringOrUnknownPlaceholder(record.String()),
+				record.Status,
+				stringOrEmptyPlaceholder(record.EOL),
+				strconv.Itoa(record.NumNodes()),
+			},
+		)
+	}
+
+	table.Render()
+
+	buffer.WriteString(fmt.Sprintf("\n%d supported, %d near end-of-life (within %s), %d end-of-life, %d unknown nodes\n",
+		count[reporting.SupportStatusSupported], count[reporting.SupportStatusNearEOL], humanDuration(state.NearEOL),
+		count[reporting.SupportStatusEOL], count[reporting.SupportStatusUnknown]))
+
+	return FormattedResult{buffer.String(), terminalWidthNote(buffer.String())}
+}
+
+func CacheListSummary(usage *reporting.CacheUsage) FormattedResult {
+	if usage == nil || len(usage.Cookbooks) == 0 {
+		return FormattedResult{"The cache is empty.", ""}
+	}
+
+	var (
+		buffer          = bytes.NewBufferString("\n-- CACHED COOKBOOKS --\n\n")
+		table           = tablewriter.NewWriter(buffer)
+		CacheListHeader = []string{"Cookbook", "Version", "Files", "Size", "Last Used"}
+	)
+
+	setupSummaryTable(table, CacheListHeader)
+
+	for _, cached := range usage.Cookbooks {
+		lastUsed := unknownValuePlaceholder
+		if !cached.LastUsed.IsZero() {
+			lastUsed = cached.LastUsed.Format("2006-01-02 15:04")
+		}
+		table.Append([]string{
+			cached.Name,
+			cached.Version,
+			strconv.Itoa(cached.NumFiles),
+			humanBytes(cached.Size),
+			lastUsed,
+		})
+	}
+
+	table.Render()
+
+	apparentSize := usage.ApparentSize()
+	buffer.WriteString(fmt.Sprintf("\n%d cookbook versions, %d unique files using %s",
+		len(usage.Cookbooks), usage.NumFiles, humanBytes(usage.Size)))
+	if apparentSize > usage.Size {
+		buffer.WriteString(fmt.Sprintf(" (%s saved by deduplication)", humanBytes(apparentSize-usage.Size)))
+	}
+	buffer.WriteString("\n")
+
+	return FormattedResult{buffer.String(), terminalWidthNote(buffer.String())}
+}
+
+// common settings of all our summary tables
+func setupSummaryTable(table *tablewriter.Table, header []string) {
+	table.SetAutoWrapText(true)
+	table.SetReflowDuringAutoWrap(true)
+	table.SetHeader(header)
+	table.SetAutoFormatHeaders(false) // don't make our headers capitalized
+	table.SetRowLine(false)           // don't show row seps
+	table.SetColumnSeparator(" ")
+	table.SetBorder(false)
+
+	// sets max for each col to 30 chars, this is not strictly enforced
+	// unwrappable content will expand beyond this limit
+	table.SetColWidth(MinTermWidth / len(header))
+}
+
+// returns a note to display when the terminal is not wide enough to display
+// the rendered table
+func terminalWidthNote(renderedTable string) string {
 	// A bit of a hack to find the actual width of the string used to render a line
 	// of the table. We get the first line only  - this is the minimum width needed
 	// to avoid wrapping  the teriminal line and making the table look bad.
 	// multibyte characters accounted for by using DisplayWidth.
 	var (
 		errMsg            strings.Builder
-		bufStr            = buffer.String()
-		lines             = strings.SplitN(bufStr, "\n", 2)
+		lines             = strings.SplitN(renderedTable, "\n", 2)
 		width             = tablewriter.DisplayWidth(lines[0])
 		termWidth, _, err = terminal.GetSize(int(os.Stdout.Fd()))
 	)
@@ -155,5 +579,5 @@ func NodesReportSummary(records []*reporting.NodeReportItem) FormattedResult {
 		errMsg.WriteString(fmt.Sprintf("\n       your terminal window to be at least %v characters wide\n", width))
 	}
 
-	return FormattedResult{buffer.String(), errMsg.String()}
+	return errMsg.String()
 }
@@ -17,7 +17,10 @@
 package formatter_test
 
 import (
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -281,3 +284,333 @@ func TestCookbooksReportSummary_withRecords_NoNodes(t *testing.T) {
 		)
 	}
 }
+
+func TestRolesReportSummary_Nil(t *testing.T) {
+	expected := subject.FormattedResult{"No roles found to analyze.", ""}
+	assert.Equal(t, expected, subject.RolesReportSummary(nil))
+	assert.Equal(t, expected, subject.RolesReportSummary(&reporting.RolesStatus{}))
+}
+
+func TestRolesReportSummary_withRecords(t *testing.T) {
+	rs := &reporting.RolesStatus{
+		Records: []*reporting.RoleRecord{
+			&reporting.RoleRecord{
+				Name:             "base",
+				RunList:          []string{"recipe[ntp]", "role[security]"},
+				NestedRoles:      []string{"security"},
+				MissingCookbooks: []string{"ntp"},
+				Nodes:            []string{"node1", "node2"},
+			},
+			&reporting.RoleRecord{Name: "orphan"},
+		},
+	}
+	report := subject.RolesReportSummary(rs)
+
+	for _, s := range []string{"REPORT SUMMARY", "Role", "Run List Items", "Nested Roles",
+		"Missing Items", "Nodes", "base", "orphan", "0 (unused)"} {
+		assert.Containsf(t, report.Report, s,
+			"there is something missing in the stdout: '%s' is missing", s,
+		)
+	}
+}
+
+func TestEnvironmentsReportSummary_Nil(t *testing.T) {
+	expected := subject.FormattedResult{"No environments found to analyze.", ""}
+	assert.Equal(t, expected, subject.EnvironmentsReportSummary(nil))
+}
+
+func TestEnvironmentsReportSummary_withRecords(t *testing.T) {
+	es := &reporting.EnvironmentsStatus{
+		RunCookstyle: true,
+		Records: []*reporting.EnvironmentRecord{
+			&reporting.EnvironmentRecord{Name: "production", NumNodes: 12,
+				Constraints: []*reporting.ConstraintRecord{
+					&reporting.ConstraintRecord{Cookbook: "foo", Constraint: "= 1.0.0", PinnedVersion: "1.0.0"},
+					&reporting.ConstraintRecord{Cookbook: "bar", Constraint: "~> 2.0"},
+				},
+				Solution: &reporting.Solution{Conflict: &reporting.SolverConflict{Cookbook: "bar"}},
+			},
+		},
+	}
+	report := subject.EnvironmentsReportSummary(es)
+
+	for _, s := range []string{"REPORT SUMMARY", "Environment", "Constraints", "Unsatisfied", "Solvable",
+		"Violations", "Auto-correctable", "Nodes", "production", "12"} {
+		assert.Containsf(t, report.Report, s,
+			"there is something missing in the stdout: '%s' is missing", s,
+		)
+	}
+}
+
+func TestDataBagsReportSummary_Nil(t *testing.T) {
+	expected := subject.FormattedResult{"No data bags found to analyze.", ""}
+	assert.Equal(t, expected, subject.DataBagsReportSummary(nil))
+}
+
+func TestDataBagsReportSummary_withRecords(t *testing.T) {
+	ds := &reporting.DataBagsStatus{
+		Records: []*reporting.DataBagRecord{
+			&reporting.DataBagRecord{Name: "secrets",
+				Items: []*reporting.DataBagItemRecord{
+					&reporting.DataBagItemRecord{Name: "aws", Encrypted: true, EncryptionVersion: 1},
+				},
+			},
+		},
+	}
+	report := subject.DataBagsReportSummary(ds)
+
+	for _, s := range []string{"REPORT SUMMARY", "Data Bag", "Items", "Encrypted", "Legacy Encryption", "secrets"} {
+		assert.Containsf(t, report.Report, s,
+			"there is something missing in the stdout: '%s' is missing", s,
+		)
+	}
+}
+
+func TestPoliciesReportSummary_Nil(t *testing.T) {
+	expected := subject.FormattedResult{"No policy groups found to analyze.", ""}
+	assert.Equal(t, expected, subject.PoliciesReportSummary(nil))
+}
+
+func TestPoliciesReportSummary_withRecords(t *testing.T) {
+	ps := &reporting.PoliciesStatus{
+		Records: []*reporting.PolicyGroupRecord{
+			&reporting.PolicyGroupRecord{Name: "prod",
+				Policies: []*reporting.PolicyRecord{
+					&reporting.PolicyRecord{Name: "app", NumNodes: 7,
+						RevisionID: "1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef"},
+				},
+			},
+		},
+	}
+	report := subject.PoliciesReportSummary(ps)
+
+	for _, s := range []string{"REPORT SUMMARY", "Policy Group", "Policy", "Revision", "Cookbooks", "Nodes",
+		"prod", "app", "1234567890", "7"} {
+		assert.Containsf(t, report.Report, s,
+			"there is something missing in the stdout: '%s' is missing", s,
+		)
+	}
+	assert.NotContains(t, report.Report, "1234567890a", "revision IDs should be shortened")
+}
+
+func TestFixesReportSummary_Nil(t *testing.T) {
+	expected := subject.FormattedResult{"No available cookbooks to fix", ""}
+	assert.Equal(t, expected, subject.FixesReportSummary(nil))
+}
+
+func TestFixesReportSummary_withFixes(t *testing.T) {
+	offense := reporting.CookstyleOffense{CopName: "ChefDeprecations/Blah", Corrected: true}
+	fs := &reporting.FixStatus{
+		Fixes: []*reporting.CookbookFix{
+			&reporting.CookbookFix{Name: "foo", Version: "1.2.3",
+				Patch: "--- a/recipes/default.rb\n+++ b/recipes/default.rb\n",
+				Corrected: []reporting.CookbookFile{
+					reporting.CookbookFile{Path: "recipes/default.rb", Offenses: []reporting.CookstyleOffense{offense}},
+				},
+			},
+			&reporting.CookbookFix{Name: "bar", Version: "4.5.6", DownloadError: errors.New("download error")},
+		},
+	}
+	report := subject.FixesReportSummary(fs)
+
+	for _, s := range []string{"FIX SUMMARY", "Cookbook", "Version", "Corrected", "Remaining", "Files Changed",
+		"foo", "1.2.3", "bar", "4.5.6", "unknown"} {
+		assert.Containsf(t, report.Report, s,
+			"there is something missing in the stdout: '%s' is missing", s,
+		)
+	}
+}
+
+func TestCacheListSummary_Empty(t *testing.T) {
+	expected := subject.FormattedResult{"The cache is empty.", ""}
+	assert.Equal(t, expected, subject.CacheListSummary(nil))
+	assert.Equal(t, expected, subject.CacheListSummary(&reporting.CacheUsage{}))
+}
+
+func TestCacheListSummary_withCookbooks(t *testing.T) {
+	usage := &reporting.CacheUsage{
+		Cookbooks: []reporting.CachedCookbook{
+			reporting.CachedCookbook{Name: "foo", Version: "1.0.0", NumFiles: 3, Size: 2048,
+				LastUsed: time.Date(2019, 12, 24, 10, 30, 0, 0, time.UTC)},
+			reporting.CachedCookbook{Name: "legacy", Version: "0.1.0", NumFiles: 1, Size: 100},
+		},
+		NumFiles: 3,
+		Size:     1024,
+	}
+	report := subject.CacheListSummary(usage)
+
+	for _, s := range []string{"CACHED COOKBOOKS", "Cookbook", "Version", "Files", "Size", "Last Used",
+		"foo", "1.0.0", "2.0 KB", "2019-12-24 10:30", "legacy", "unknown",
+		"2 cookbook versions, 3 unique files using 1.0 KB (1.1 KB saved by deduplication)"} {
+		assert.Containsf(t, report.Report, s,
+			"there is something missing in the stdout: '%s' is missing", s,
+		)
+	}
+}
+
+func TestNodesHygieneSummary_Nil(t *testing.T) {
+	assert.Equal(t, subject.FormattedResult{"", ""}, subject.NodesHygieneSummary(nil))
+}
+
+func TestNodesHygieneSummary_Clean(t *testing.T) {
+	report := subject.NodesHygieneSummary(&reporting.NodesHygiene{StaleAfter: 30 * 24 * time.Hour})
+	assert.Equal(t, "\n-- NODES HYGIENE --\n\nNo stale or duplicate nodes found\n", report.Report)
+}
+
+func TestNodesHygieneSummary_withNodes(t *testing.T) {
+	hygiene := &reporting.NodesHygiene{
+		StaleAfter: 30 * 24 * time.Hour,
+		StaleNodes: []*reporting.NodeReportItem{
+			&reporting.NodeReportItem{Name: "new", Stale: true},
+			&reporting.NodeReportItem{Name: "web01", OhaiTime: 1576492200, Stale: true},
+		},
+		Duplicates: []*reporting.DuplicateNodes{
+			{Attribute: reporting.DuplicateFQDN, Value: "web01.example.com", Nodes: []string{"web01", "web01-rebuilt"}},
+			{Attribute: reporting.DuplicateIPAddress, Value: "10.0.0.2", Nodes: []string{"db01", "web01-rebuilt"}},
+		},
+	}
+
+	assert.Equal(t, `
+-- NODES HYGIENE --
+
+Stale Nodes (no check-in for 30d):
+ - new (last check-in: never)
+ - web01 (last check-in: 2019-12-16 10:30 UTC)
+Duplicate Nodes:
+ - fqdn web01.example.com: web01, web01-rebuilt
+ - ipaddress 10.0.0.2: db01, web01-rebuilt
+`, subject.NodesHygieneSummary(hygiene).Report)
+
+	hygiene.StaleAfter = 0
+	hygiene.StaleNodes = nil
+	assert.NotContains(t, subject.NodesHygieneSummary(hygiene).Report, "Stale Nodes")
+}
+
+func TestDependenciesReportSummary_Nil(t *testing.T) {
+	expected := subject.FormattedResult{"No available cookbooks to generate a report", ""}
+	assert.Equal(t, expected, subject.DependenciesReportSummary(nil))
+}
+
+func TestDependenciesReportSummary_withGraph(t *testing.T) {
+	report := subject.DependenciesReportSummary(dependencyGraphFixture())
+
+	for _, s := range []string{"REPORT SUMMARY", "Cookbook", "Version", "Dependencies", "Unsatisfied", "Dependents",
+		"app", "1.2.3", "broken", "unknown",
+		"1 unsatisfied dependencies, 1 circular dependencies, 0 leaf dependencies"} {
+		assert.Containsf(t, report.Report, s,
+			"there is something missing in the stdout: '%s' is missing", s,
+		)
+	}
+}
+
+func TestClientVersionsReportSummary_Nil(t *testing.T) {
+	expected := subject.FormattedResult{"No nodes found to analyze.", ""}
+	assert.Equal(t, expected, subject.ClientVersionsReportSummary(nil))
+}
+
+func TestClientVersionsReportSummary_withRecords(t *testing.T) {
+	report := subject.ClientVersionsReportSummary(clientVersionsFixture())
+
+	for _, s := range []string{"REPORT SUMMARY", "Chef Version", "Support", "EOL", "Nodes", "Percentage",
+		"Ohai Versions", "Ruby Versions",
+		"15.4", "supported", "2021-04-30", "66.7%", "15.3.1", "2.6.3, 2.6.5",
+		"14.12", "eol", "2020-04-30", "33.3%",
+		"1 of 3 nodes run an unsupported Chef Infra Client release"} {
+		assert.Containsf(t, report.Report, s,
+			"there is something missing in the stdout: '%s' is missing", s,
+		)
+	}
+}
+
+// two nodes on a supported release and one node on an end-of-life release
+func clientVersionsFixture() *reporting.ClientVersionsStatus {
+	return reporting.NewClientVersions(
+		[]*reporting.NodeReportItem{
+			&reporting.NodeReportItem{Name: "web01", ChefVersion: "15.4.2", OhaiVersion: "15.3.1", RubyVersion: "2.6.3"},
+			&reporting.NodeReportItem{Name: "web02", ChefVersion: "15.4.45", OhaiVersion: "15.3.1", RubyVersion: "2.6.5"},
+			&reporting.NodeReportItem{Name: "db01", ChefVersion: "14.12.9"},
+		},
+		[]reporting.ChefClientRelease{{Major: 14, EOL: "2020-04-30"}, {Major: 15, EOL: "2021-04-30"}},
+		time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+	)
+}
+
+func TestOSSupportReportSummary_Nil(t *testing.T) {
+	expected := subject.FormattedResult{"No nodes found to analyze.", ""}
+	assert.Equal(t, expected, subject.OSSupportReportSummary(nil))
+}
+
+func TestOSSupportReportSummary_withRecords(t *testing.T) {
+	report := subject.OSSupportReportSummary(osSupportFixture())
+
+	for _, s := range []string{"REPORT SUMMARY", "Operating System", "Support", "EOL", "Nodes",
+		"centos 7", "eol", "2020-04-30", "ubuntu 18.04", "near-eol", "Windows Server 2016", "supported", "unknown",
+		"1 supported, 1 near end-of-life (within 180d), 1 end-of-life, 1 unknown nodes"} {
+		assert.Containsf(t, report.Report, s,
+			"there is something missing in the stdout: '%s' is missing", s,
+		)
+	}
+}
+
+// one node per support status
+func osSupportFixture() *reporting.OSSupportStatus {
+	return reporting.NewOSSupport(
+		[]*reporting.NodeReportItem{
+			&reporting.NodeReportItem{Name: "web01", OS: "ubuntu", OSVersion: "18.04"},
+			&reporting.NodeReportItem{Name: "db01", OS: "centos", OSVersion: "7.8.2003"},
+			&reporting.NodeReportItem{Name: "win01", OS: "windows", OSVersion: "10.0.14393"},
+			&reporting.NodeReportItem{Name: "new"},
+		},
+		[]reporting.OSRelease{
+			{Platform: "centos", Version: "7", EOL: "2020-04-30"},
+			{Platform: "ubuntu", Version: "18.04", EOL: "2020-10-31"},
+			{Platform: "windows", Version: "10.0.14393", Name: "Windows Server 2016", EOL: "2027-01-12"},
+		},
+		180*24*time.Hour,
+		time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+	)
+}
+
+func TestEffortEstimateSummary_Nil(t *testing.T) {
+	assert.Equal(t, subject.FormattedResult{"", ""}, subject.EffortEstimateSummary(nil))
+}
+
+func TestEffortEstimateSummary_Empty(t *testing.T) {
+	assert.Equal(t,
+		subject.FormattedResult{"\n-- EFFORT ESTIMATE --\n\nNo cookbooks to estimate\n", ""},
+		subject.EffortEstimateSummary(&reporting.EffortEstimate{}),
+	)
+}
+
+func TestEffortEstimateSummary_withCookbooks(t *testing.T) {
+	estimate := &reporting.EffortEstimate{
+		Cookbooks: []*reporting.CookbookEffort{
+			&reporting.CookbookEffort{Name: "apache2", Version: "2.0.0", Team: "web",
+				NumOffenses: 4, NumNodes: 12, Minutes: 100, Multiplier: 1.5},
+			&reporting.CookbookEffort{Name: "mysql", Version: "1.0.0", Team: "unassigned",
+				NumOffenses: 1, NumNodes: 0, Minutes: 30, Multiplier: 1},
+		},
+		Teams: []*reporting.TeamEffort{
+			&reporting.TeamEffort{Name: "web", Hours: 2.5, NumCookbooks: 1},
+			&reporting.TeamEffort{Name: "unassigned", Hours: 0.5, NumCookbooks: 1},
+		},
+		TotalHours:  3,
+		Unestimated: []*reporting.CookbookRecord{&reporting.CookbookRecord{Name: "broken"}},
+	}
+	report := subject.EffortEstimateSummary(estimate)
+
+	for _, s := range []string{"EFFORT ESTIMATE", "Rank", "Cookbook", "Version", "Team", "Violations",
+		"Nodes Affected", "Multiplier", "Hours",
+		"apache2", "2.0.0", "web", "x1.5", "2.5", "mysql", "x1", "0.5",
+		"Total: 3 hours",
+		" - web: 2.5 hours (1 cookbooks)",
+		" - unassigned: 0.5 hours (1 cookbooks)",
+		"1 cookbooks could not be estimated, see the errors report"} {
+		assert.Containsf(t, report.Report, s,
+			"there is something missing in the stdout: '%s' is missing", s,
+		)
+	}
+	assert.True(t,
+		strings.Index(report.Report, "apache2") < strings.Index(report.Report, "mysql"),
+		"the backlog should be ranked by hours")
+}
@@ -46,6 +46,13 @@ func MakeCookbooksReportTXT(state *reporting.CookbooksStatus) *FormattedResult {
 			strBuilder.WriteString("\n")
 		}
 
+		// cookbooks locked by active policy revisions
+		if len(record.Policies) != 0 {
+			strBuilder.WriteString("  Policies: ")
+			strBuilder.WriteString(strings.Join(record.Policies, ", "))
+			strBuilder.WriteString("\n")
+		}
+
 		if state.RunCookstyle {
 			strBuilder.WriteString(fmt.Sprintf("  Violations: %v\n", record.NumOffenses()))
 			strBuilder.WriteString(fmt.Sprintf("  Auto correctable: %v\n", record.NumCorrectable()))
@@ -73,10 +80,19 @@ func MakeCookbooksReportTXT(state *reporting.CookbooksStatus) *FormattedResult {
 		}
 
 	}
+	writePolicyErrors(&errorBuilder, state)
+
 	return &FormattedResult{strBuilder.String(), errorBuilder.String()}
 }
 
-func MakeNodesReportTXT(records []*reporting.NodeReportItem) *FormattedResult {
+// errors looking up the policies don't belong to a single cookbook record
+func writePolicyErrors(errorBuilder *strings.Builder, state *reporting.CookbooksStatus) {
+	for _, e := range state.PolicyErrors {
+		errorBuilder.WriteString(fmt.Sprintf(" - policies: %v\n", e))
+	}
+}
+
+func MakeNodesReportTXT(records []*reporting.NodeReportItem, filter reporting.NodesFilter) *FormattedResult {
 	var (
 		errorBuilder strings.Builder
 		strBuilder   strings.Builder
@@ -87,6 +103,11 @@ func MakeNodesReportTXT(records []*reporting.NodeReportItem) *FormattedResult {
 		return &FormattedResult{"", ""}
 	}
 
+	// record the filter used to generate the report, if any
+	if !filter.IsEmpty() {
+		strBuilder.WriteString(fmt.Sprintf("Search query: %s\n\n", filter.SearchStatement()))
+	}
+
 	for _, record := range records {
 		strBuilder.WriteString(fmt.Sprintf("> Node: %s\n", record.Name))
 		strBuilder.WriteString(
@@ -97,6 +118,24 @@ func MakeNodesReportTXT(records []*reporting.NodeReportItem) *FormattedResult {
 			fmt.Sprintf("  Operating System: %s\n",
 				stringOrUnknownPlaceholder(record.OSVersionPretty())),
 		)
+		if record.FQDN != "" {
+			strBuilder.WriteString(fmt.Sprintf("  FQDN: %s\n", record.FQDN))
+		}
+		if record.IPAddress != "" {
+			strBuilder.WriteString(fmt.Sprintf("  IP Address: %s\n", record.IPAddress))
+		}
+		if checkIn := lastCheckIn(record.LastCheckIn()); checkIn != "" {
+			if record.Stale {
+				checkIn += " (stale)"
+			}
+			strBuilder.WriteString(fmt.Sprintf("  Last Check-In: %s\n", checkIn))
+		}
+		if record.PolicyName != "" {
+			strBuilder.WriteString(
+				fmt.Sprintf("  Policy: %s (%s)\n", record.PolicyName,
+					stringOrUnknownPlaceholder(record.PolicyGroup)),
+			)
+		}
 
 		if len(record.CookbooksList()) == 0 {
 			strBuilder.WriteString("  Cookbooks Applied: none\n")
@@ -109,3 +148,444 @@ func MakeNodesReportTXT(records []*reporting.NodeReportItem) *FormattedResult {
 
 	return &FormattedResult{strBuilder.String(), errorBuilder.String()}
 }
+
+func MakeRolesReportTXT(state *reporting.RolesStatus) *FormattedResult {
+	var (
+		errorBuilder strings.Builder
+		strBuilder   strings.Builder
+	)
+
+	if state == nil || len(state.Records) == 0 {
+		// nothing to do
+		return &FormattedResult{"", ""}
+	}
+
+	for _, record := range state.Records {
+		strBuilder.WriteString(fmt.Sprintf("> Role: %s\n", record.Name))
+		strBuilder.WriteString(
+			fmt.Sprintf("  Description: %s\n", stringOrEmptyPlaceholder(record.Description)),
+		)
+		strBuilder.WriteString(fmt.Sprintf("  Run List: %s\n", listOrNone(record.RunList)))
+		strBuilder.WriteString(fmt.Sprintf("  Nested Roles: %s\n", listOrNone(record.NestedRoles)))
+		strBuilder.WriteString(fmt.Sprintf("  Missing Roles: %s\n", listOrNone(record.MissingRoles)))
+		strBuilder.WriteString(fmt.Sprintf("  Missing Cookbooks: %s\n", listOrNone(record.MissingCookbooks)))
+		strBuilder.WriteString(fmt.Sprintf("  Missing Recipes: %s\n", listOrNone(record.MissingRecipes)))
+		strBuilder.WriteString(
+			fmt.Sprintf("  Attributes: %d default, %d override\n",
+				record.NumDefaultAttributes, record.NumOverrideAttributes),
+		)
+
+		if record.Unused() {
+			strBuilder.WriteString("  Nodes: none (unused)\n")
+		} else {
+			strBuilder.WriteString(
+				fmt.Sprintf("  Nodes (%d): %s\n", record.NumNodes(), strings.Join(record.Nodes, ", ")),
+			)
+		}
+
+		for _, e := range record.Errors() {
+			errorBuilder.WriteString(fmt.Sprintf(" - %s: %v\n", record.Name, e))
+		}
+	}
+
+	return &FormattedResult{strBuilder.String(), errorBuilder.String()}
+}
+
+func listOrNone(list []string) string {
+	if len(list) == 0 {
+		return "none"
+	}
+	return strings.Join(list, ", ")
+}
+
+func MakeEnvironmentsReportTXT(state *reporting.EnvironmentsStatus) *FormattedResult {
+	var (
+		errorBuilder strings.Builder
+		strBuilder   strings.Builder
+	)
+
+	if state == nil || len(state.Records) == 0 {
+		// nothing to do
+		return &FormattedResult{"", ""}
+	}
+
+	for _, record := range state.Records {
+		strBuilder.WriteString(fmt.Sprintf("> Environment: %s\n", record.Name))
+		strBuilder.WriteString(
+			fmt.Sprintf("  Description: %s\n", stringOrEmptyPlaceholder(record.Description)),
+		)
+		strBuilder.WriteString(fmt.Sprintf("  Nodes: %d\n", record.NumNodes))
+
+		if len(record.Constraints) == 0 {
+			strBuilder.WriteString("  Cookbook Constraints: none\n")
+		} else {
+			strBuilder.WriteString("  Cookbook Constraints:\n")
+		}
+		for _, c := range record.Constraints {
+			strBuilder.WriteString(fmt.Sprintf("   - %s %s", c.Cookbook, c.Constraint))
+
+			if !c.Satisfied() {
+				strBuilder.WriteString(" => unsatisfied\n")
+				continue
+			}
+
+			strBuilder.WriteString(fmt.Sprintf(" => %s", c.PinnedVersion))
+			if state.RunCookstyle && c.Cookstyle != nil {
+				strBuilder.WriteString(
+					fmt.Sprintf(" (violations: %d, auto correctable: %d)",
+						c.Cookstyle.NumOffenses(), c.Cookstyle.NumCorrectable()),
+				)
+			}
+			strBuilder.WriteString("\n")
+		}
+
+		writeEnvironmentSolution(&strBuilder, record.Solution)
+
+		for _, e := range record.Errors() {
+			errorBuilder.WriteString(fmt.Sprintf(" - %s: %v\n", record.Name, e))
+		}
+	}
+
+	return &FormattedResult{strBuilder.String(), errorBuilder.String()}
+}
+
+func writeEnvironmentSolution(strBuilder *strings.Builder, solution *reporting.Solution) {
+	switch {
+	case solution == nil:
+		// the environment couldn't be solved, the error is reported instead
+	case !solution.Solved():
+		strBuilder.WriteString(fmt.Sprintf("  Solution: unsatisfiable, %s\n", solution.Conflict))
+	case len(solution.Cookbooks) == 0:
+		strBuilder.WriteString("  Solution: none\n")
+	default:
+		strBuilder.WriteString("  Solution:\n")
+		for _, cbv := range solution.Cookbooks {
+			strBuilder.WriteString(fmt.Sprintf("   - %s (%s)\n", cbv.Name, cbv.Version))
+		}
+	}
+}
+
+func MakeDataBagsReportTXT(state *reporting.DataBagsStatus) *FormattedResult {
+	var (
+		errorBuilder strings.Builder
+		strBuilder   strings.Builder
+	)
+
+	if state == nil || len(state.Records) == 0 {
+		// nothing to do
+		return &FormattedResult{"", ""}
+	}
+
+	for _, record := range state.Records {
+		strBuilder.WriteString(fmt.Sprintf("> Data Bag: %s\n", record.Name))
+		strBuilder.WriteString(
+			fmt.Sprintf("  Items: %d (%d encrypted)\n", record.NumItems(), record.NumEncrypted()),
+		)
+
+		for _, item := range record.Items {
+			strBuilder.WriteString(fmt.Sprintf("   - %s: ", item.Name))
+			switch {
+			case item.GetError != nil:
+				strBuilder.WriteString(unknownValuePlaceholder)
+			case item.Encrypted:
+				strBuilder.WriteString(
+					fmt.Sprintf("encrypted (v%d, %s)", item.EncryptionVersion, item.Cipher),
+				)
+				if item.LegacyEncryption() {
+					strBuilder.WriteString(" - legacy encryption format")
+				}
+			default:
+				strBuilder.WriteString("not encrypted")
+			}
+			strBuilder.WriteString("\n")
+		}
+
+		for _, e := range record.Errors() {
+			errorBuilder.WriteString(fmt.Sprintf(" - %s: %v\n", record.Name, e))
+		}
+	}
+
+	return &FormattedResult{strBuilder.String(), errorBuilder.String()}
+}
+
+func MakePoliciesReportTXT(state *reporting.PoliciesStatus) *FormattedResult {
+	var (
+		errorBuilder strings.Builder
+		strBuilder   strings.Builder
+	)
+
+	if state == nil || len(state.Records) == 0 {
+		// nothing to do
+		return &FormattedResult{"", ""}
+	}
+
+	for _, record := range state.Records {
+		strBuilder.WriteString(fmt.Sprintf("> Policy Group: %s\n", record.Name))
+
+		if len(record.Policies) == 0 {
+			strBuilder.WriteString("  Policies: none\n")
+		} else {
+			strBuilder.WriteString("  Policies:\n")
+		}
+		for _, policy := range record.Policies {
+			strBuilder.WriteString(fmt.Sprintf("   - %s\n", policy.Name))
+			strBuilder.WriteString(fmt.Sprintf("     Revision: %s (%d available)\n", policy.RevisionID, policy.NumRevisions))
+			strBuilder.WriteString(fmt.Sprintf("     Nodes: %d\n", policy.NumNodes))
+
+			switch {
+			case policy.GetError != nil:
+				strBuilder.WriteString(fmt.Sprintf("     Cookbooks Locked: %s\n", unknownValuePlaceholder))
+			case len(policy.CookbookLocks) == 0:
+				strBuilder.WriteString("     Cookbooks Locked: none\n")
+			default:
+				strBuilder.WriteString("     Cookbooks Locked: ")
+				strBuilder.WriteString(strings.Join(policy.CookbooksList(), ", "))
+				strBuilder.WriteString("\n")
+			}
+		}
+
+		for _, e := range record.Errors() {
+			errorBuilder.WriteString(fmt.Sprintf(" - %s: %v\n", record.Name, e))
+		}
+	}
+
+	return &FormattedResult{strBuilder.String(), errorBuilder.String()}
+}
+
+func MakeFixesReportTXT(state *reporting.FixStatus) *FormattedResult {
+	var (
+		errorBuilder strings.Builder
+		strBuilder   strings.Builder
+	)
+
+	if state == nil || len(state.Fixes) == 0 {
+		// nothing to do
+		return &FormattedResult{"", ""}
+	}
+
+	for _, fix := range state.Fixes {
+		strBuilder.WriteString(fmt.Sprintf("> Cookbook: %v (%v)\n", fix.Name, fix.Version))
+
+		if len(fix.Errors()) != 0 {
+			strBuilder.WriteString(fmt.Sprintf("  Corrected: %s\n", unknownValuePlaceholder))
+			strBuilder.WriteString(fmt.Sprintf("  Remaining: %s\n", unknownValuePlaceholder))
+		} else {
+			strBuilder.WriteString(fmt.Sprintf("  Corrected: %d\n", fix.NumCorrected()))
+			writeFixOffenses(&strBuilder, fix.Corrected)
+			strBuilder.WriteString(fmt.Sprintf("  Remaining (manual work required): %d\n", fix.NumRemaining()))
+			writeFixOffenses(&strBuilder, fix.Remaining)
+		}
+
+		for _, e := range fix.Errors() {
+			errorBuilder.WriteString(fmt.Sprintf(" - %s (%s): %v\n", fix.Name, fix.Version, e))
+		}
+	}
+
+	return &FormattedResult{strBuilder.String(), errorBuilder.String()}
+}
+
+func writeFixOffenses(strBuilder *strings.Builder, files []reporting.CookbookFile) {
+	for _, f := range files {
+		strBuilder.WriteString(fmt.Sprintf("   - %s:\n", f.Path))
+		for _, o := range f.Offenses {
+			strBuilder.WriteString(fmt.Sprintf("\t%d:%d %s %s\n", o.Location.StartLine, o.Location.StartColumn, o.CopName, o.Message))
+		}
+	}
+}
+
+func MakeDependenciesReportTXT(graph *reporting.DependencyGraph) *FormattedResult {
+	var (
+		errorBuilder strings.Builder
+		strBuilder   strings.Builder
+	)
+
+	if graph == nil || len(graph.Cookbooks) == 0 {
+		// nothing to do
+		return &FormattedResult{"", ""}
+	}
+
+	for _, cd := range graph.Cookbooks {
+		strBuilder.WriteString(fmt.Sprintf("> Cookbook: %s (%s)\n", cd.Name, cd.Version))
+
+		if len(cd.Dependencies) == 0 {
+			strBuilder.WriteString("  Dependencies: none\n")
+		} else {
+			strBuilder.WriteString("  Dependencies:\n")
+		}
+		for _, d := range cd.Dependencies {
+			strBuilder.WriteString(fmt.Sprintf("   - %s %s", d.Cookbook, d.Constraint))
+			if d.Satisfied() {
+				strBuilder.WriteString(fmt.Sprintf(" => %s\n", d.Resolved()))
+			} else {
+				strBuilder.WriteString(" => unsatisfied\n")
+			}
+		}
+
+		dependents := make([]string, 0, len(cd.Dependents))
+		for _, dependent := range cd.Dependents {
+			dependents = append(dependents, fmt.Sprintf("%s (%s)", dependent.Name, dependent.Version))
+		}
+		strBuilder.WriteString(fmt.Sprintf("  Dependents: %s\n", listOrNone(dependents)))
+
+		for _, e := range cd.Errors() {
+			errorBuilder.WriteString(fmt.Sprintf(" - %s (%s): %v\n", cd.Name, cd.Version, e))
+		}
+	}
+
+	if len(graph.Cycles) == 0 {
+		strBuilder.WriteString("\nCircular Dependencies: none\n")
+	} else {
+		strBuilder.WriteString("\nCircular Dependencies:\n")
+	}
+	for _, cycle := range graph.Cycles {
+		strBuilder.WriteString(fmt.Sprintf(" - %s\n", strings.Join(cycle, ", ")))
+	}
+	strBuilder.WriteString(
+		fmt.Sprintf("Leaf Dependencies (depended on, without dependencies of their own): %s\n",
+			listOrNone(graph.LeafDependencies)),
+	)
+
+	return &FormattedResult{strBuilder.String(), errorBuilder.String()}
+}
+
+func MakeClientVersionsReportTXT(state *reporting.ClientVersionsStatus) *FormattedResult {
+	var strBuilder strings.Builder
+
+	if state == nil || len(state.Records) == 0 {
+		// nothing to do
+		return &FormattedResult{"", ""}
+	}
+
+	for _, record := range state.Records {
+		strBuilder.WriteString(
+			fmt.Sprintf("> Chef Infra Client: %s\n", stringOrUnknownPlaceholder(record.Version)),
+		)
+		strBuilder.WriteString(fmt.Sprintf("  Support: %s\n", record.Status))
+		if record.EOL != "" {
+			strBuilder.WriteString(fmt.Sprintf("  EOL: %s\n", record.EOL))
+		}
+		strBuilder.WriteString(fmt.Sprintf("  Ohai Versions: %s\n", listOrNone(record.OhaiVersions)))
+		strBuilder.WriteString(fmt.Sprintf("  Ruby Versions: %s\n", listOrNone(record.RubyVersions)))
+		strBuilder.WriteString(
+			fmt.Sprintf("  Nodes (%d, %.1f%%): %s\n",
+				record.NumNodes(), state.Percentage(record), strings.Join(record.Nodes, ", ")),
+		)
+	}
+
+	strBuilder.WriteString(
+		fmt.Sprintf("\nNodes on unsupported releases: %s\n", listOrNone(state.UnsupportedNodes())),
+	)
+
+	return &FormattedResult{strBuilder.String(), ""}
+}
+
+func MakeOSSupportReportTXT(state *reporting.OSSupportStatus) *FormattedResult {
+	var strBuilder strings.Builder
+
+	if state == nil || len(state.Records) == 0 {
+		// nothing to do
+		return &FormattedResult{"", ""}
+	}
+
+	for _, record := range state.Records {
+		strBuilder.WriteString(
+			fmt.Sprintf("> Operating System: %s\n", stringOrUnknownPlaceholder(record.String())),
+		)
+		strBuilder.WriteString(fmt.Sprintf("  Support: %s\n", record.Status))
+		if record.EOL != "" {
+			strBuilder.WriteString(fmt.Sprintf("  EOL: %s\n", record.EOL))
+		}
+		strBuilder.WriteString(
+			fmt.Sprintf("  Nodes (%d): %s\n", record.NumNodes(), strings.Join(record.Nodes, ", ")),
+		)
+	}
+
+	return &FormattedResult{strBuilder.String(), ""}
+}
+
+func MakeExplainNodeTXT(expansion *reporting.NodeExpansion) *FormattedResult {
+	var (
+		errorBuilder strings.Builder
+		strBuilder   strings.Builder
+	)
+
+	if expansion == nil {
+		// nothing to do
+		return &FormattedResult{"", ""}
+	}
+
+	strBuilder.WriteString(fmt.Sprintf("> Node: %s\n", expansion.Node.Name))
+	strBuilder.WriteString(fmt.Sprintf("  Environment: %s\n", expansion.Environment))
+	strBuilder.WriteString(fmt.Sprintf("  Run List: %s\n", listOrNone(expansion.RunList)))
+
+	recipes := make([]string, 0, len(expansion.Recipes))
+	for _, recipe := range expansion.Recipes {
+		recipes = append(recipes, recipe.Recipe())
+	}
+	strBuilder.WriteString(fmt.Sprintf("  Expanded Run List: %s\n", listOrNone(recipes)))
+
+	if len(expansion.Cookbooks) == 0 {
+		strBuilder.WriteString("  Cookbooks: none\n")
+	} else {
+		strBuilder.WriteString("  Cookbooks:\n")
+	}
+	for _, cookbook := range expansion.Cookbooks {
+		strBuilder.WriteString(fmt.Sprintf("   - %s (%s)\n", cookbook.Name, cookbook.Version))
+		if cookbook.InRunList() {
+			strBuilder.WriteString(fmt.Sprintf("     %s\n", cookbook.ChainString()))
+		} else {
+			strBuilder.WriteString("     not in the expanded run list\n")
+		}
+	}
+
+	if len(expansion.MissingRoles) != 0 {
+		strBuilder.WriteString(fmt.Sprintf("  Missing Roles: %s\n", strings.Join(expansion.MissingRoles, ", ")))
+	}
+	for _, cycle := range expansion.RoleCycles {
+		strBuilder.WriteString(fmt.Sprintf("  Role Cycle: %s\n", strings.Join(cycle, " -> ")))
+	}
+
+	for _, e := range expansion.Errors {
+		errorBuilder.WriteString(fmt.Sprintf(" - %s: %v\n", expansion.Node.Name, e))
+	}
+
+	return &FormattedResult{strBuilder.String(), errorBuilder.String()}
+}
+
+func MakeConfigStatusTXT(status *reporting.ConfigStatus) *FormattedResult {
+	if status == nil || len(status.Checks) == 0 {
+		// nothing to do
+		return &FormattedResult{"", ""}
+	}
+
+	var strBuilder strings.Builder
+
+	strBuilder.WriteString(fmt.Sprintf("Profile: %s\n", stringOrUnknownPlaceholder(status.Profile)))
+	strBuilder.WriteString(fmt.Sprintf("Chef Infra Server URL: %s\n", stringOrUnknownPlaceholder(status.ChefServerURL)))
+	strBuilder.WriteString(fmt.Sprintf("Client Name: %s\n\n", stringOrUnknownPlaceholder(status.ClientName)))
+
+	for _, check := range status.Checks {
+		switch {
+		case check.Failed():
+			strBuilder.WriteString(fmt.Sprintf("[FAIL] %s\n", check.Description))
+			for _, line := range strings.Split(strings.TrimSpace(check.Error.Error()), "\n") {
+				strBuilder.WriteString(fmt.Sprintf("       %s\n", strings.TrimSpace(line)))
+			}
+		case check.Skipped:
+			strBuilder.WriteString(fmt.Sprintf("[SKIP] %s\n", check.Description))
+		default:
+			strBuilder.WriteString(fmt.Sprintf("[ OK ] %s\n", check.Description))
+		}
+		if check.Hint != "" {
+			strBuilder.WriteString(fmt.Sprintf("       hint: %s\n", check.Hint))
+		}
+	}
+
+	if status.Passed() {
+		strBuilder.WriteString("\nYour configuration is ready to generate reports.\n")
+	} else {
+		strBuilder.WriteString(fmt.Sprintf("\n%d check(s) failed.\n", status.NumFailed()))
+	}
+
+	return &FormattedResult{strBuilder.String(), ""}
+}
@@ -101,13 +101,13 @@ func TestMakeCookbooksReportTXT_ErrorReport(t *testing.T) {
 func TestMakeNodesReportTXT_Nil(t *testing.T) {
 	assert.Equal(t,
 		&subject.FormattedResult{Report: "", Errors: ""},
-		subject.MakeNodesReportTXT(nil))
+		subject.MakeNodesReportTXT(nil, reporting.NodesFilter{}))
 }
 
 func TestMakeNodesReportTXT_Empty(t *testing.T) {
 	assert.Equal(t,
 		&subject.FormattedResult{Report: "", Errors: ""},
-		subject.MakeNodesReportTXT([]*reporting.NodeReportItem{}))
+		subject.MakeNodesReportTXT([]*reporting.NodeReportItem{}, reporting.NodesFilter{}))
 }
 
 func TestMakeNodesReportTXT_WithRecords(t *testing.T) {
@@ -127,7 +127,7 @@ func TestMakeNodesReportTXT_WithRecords(t *testing.T) {
 	}
 
 	var (
-		actual         = subject.MakeNodesReportTXT(nodesReport)
+		actual         = subject.MakeNodesReportTXT(nodesReport, reporting.NodesFilter{})
 		lines          = strings.Split(actual.Report, "\n")
 		expectedReport = `> Node: node1
   Chef Version: 12.22
@@ -147,3 +147,533 @@ func TestMakeNodesReportTXT_WithRecords(t *testing.T) {
 		assert.Equal(t, expectedReport, actual.Report)
 	}
 }
+
+func TestMakeNodesReportTXT_WithHygieneAttributes(t *testing.T) {
+	nodesReport := []*reporting.NodeReportItem{
+		&reporting.NodeReportItem{Name: "node1", ChefVersion: "15.00", OS: "ubuntu", OSVersion: "18.04",
+			FQDN: "node1.example.com", IPAddress: "10.0.0.1", OhaiTime: 1576492200, Stale: true},
+	}
+
+	actual := subject.MakeNodesReportTXT(nodesReport, reporting.NodesFilter{})
+	assert.Equal(t, `> Node: node1
+  Chef Version: 15.00
+  Operating System: ubuntu v18.04
+  FQDN: node1.example.com
+  IP Address: 10.0.0.1
+  Last Check-In: 2019-12-16 10:30 UTC (stale)
+  Cookbooks Applied: none
+`, actual.Report)
+}
+
+func TestMakeNodesReportTXT_WithFilter(t *testing.T) {
+	nodesReport := []*reporting.NodeReportItem{
+		&reporting.NodeReportItem{Name: "node1", ChefVersion: "12.22", OS: "windows", OSVersion: "10.1"},
+	}
+
+	var (
+		actual         = subject.MakeNodesReportTXT(nodesReport, reporting.NodesFilter{Environment: "qa", Role: "web"})
+		expectedReport = `Search query: chef_environment:qa AND role:web
+
+> Node: node1
+  Chef Version: 12.22
+  Operating System: windows v10.1
+  Cookbooks Applied: none
+`
+	)
+	assert.Equal(t, expectedReport, actual.Report)
+}
+
+func TestMakeRolesReportTXT_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeRolesReportTXT(nil))
+}
+
+func TestMakeRolesReportTXT_WithRecords(t *testing.T) {
+	rs := &reporting.RolesStatus{
+		Records: []*reporting.RoleRecord{
+			&reporting.RoleRecord{
+				Name:                  "base",
+				Description:           "the base role",
+				RunList:               []string{"recipe[ntp]", "recipe[users::missing]", "role[security]"},
+				NestedRoles:           []string{"security"},
+				MissingRecipes:        []string{"users::missing"},
+				NumOverrideAttributes: 1,
+				Nodes:                 []string{"node1", "node2"},
+			},
+			&reporting.RoleRecord{Name: "orphan", RunListError: errors.New("invalid run list item(s): [foo[bar]]")},
+		},
+	}
+
+	var (
+		actual         = subject.MakeRolesReportTXT(rs)
+		expectedReport = `> Role: base
+  Description: the base role
+  Run List: recipe[ntp], recipe[users::missing], role[security]
+  Nested Roles: security
+  Missing Roles: none
+  Missing Cookbooks: none
+  Missing Recipes: users::missing
+  Attributes: 0 default, 1 override
+  Nodes (2): node1, node2
+> Role: orphan
+  Description: -
+  Run List: none
+  Nested Roles: none
+  Missing Roles: none
+  Missing Cookbooks: none
+  Missing Recipes: none
+  Attributes: 0 default, 0 override
+  Nodes: none (unused)
+`
+	)
+	assert.Equal(t, expectedReport, actual.Report)
+	assert.Equal(t, " - orphan: invalid run list item(s): [foo[bar]]\n", actual.Errors)
+}
+
+func TestMakeEnvironmentsReportTXT_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeEnvironmentsReportTXT(nil))
+}
+
+func TestMakeEnvironmentsReportTXT_WithRecords(t *testing.T) {
+	es := &reporting.EnvironmentsStatus{
+		RunCookstyle: true,
+		Records: []*reporting.EnvironmentRecord{
+			&reporting.EnvironmentRecord{Name: "_default", Description: "The default environment", NumNodes: 1},
+			&reporting.EnvironmentRecord{Name: "production", NumNodes: 3,
+				Constraints: []*reporting.ConstraintRecord{
+					&reporting.ConstraintRecord{Cookbook: "bar", Constraint: "~> 2.0"},
+					&reporting.ConstraintRecord{Cookbook: "baz", Constraint: "~> one",
+						ParseError: errors.New("invalid version constraint '~> one'")},
+					&reporting.ConstraintRecord{Cookbook: "foo", Constraint: "= 1.0.0", PinnedVersion: "1.0.0",
+						Cookstyle: &reporting.CookbookRecord{Name: "foo", Version: "1.0.0"},
+					},
+				},
+			},
+		},
+	}
+
+	var (
+		actual         = subject.MakeEnvironmentsReportTXT(es)
+		expectedReport = `> Environment: _default
+  Description: The default environment
+  Nodes: 1
+  Cookbook Constraints: none
+> Environment: production
+  Description: -
+  Nodes: 3
+  Cookbook Constraints:
+   - bar ~> 2.0 => unsatisfied
+   - baz ~> one => unsatisfied
+   - foo = 1.0.0 => 1.0.0 (violations: 0, auto correctable: 0)
+`
+	)
+	assert.Equal(t, expectedReport, actual.Report)
+	assert.Equal(t, " - production: invalid version constraint '~> one'\n", actual.Errors)
+}
+
+func TestMakeEnvironmentsReportTXT_WithSolutions(t *testing.T) {
+	es := &reporting.EnvironmentsStatus{
+		Records: []*reporting.EnvironmentRecord{
+			&reporting.EnvironmentRecord{Name: "_default", Solution: &reporting.Solution{}},
+			&reporting.EnvironmentRecord{Name: "production",
+				Constraints: []*reporting.ConstraintRecord{
+					&reporting.ConstraintRecord{Cookbook: "app", Constraint: "= 1.2.3", PinnedVersion: "1.2.3"},
+					&reporting.ConstraintRecord{Cookbook: "mysql", Constraint: "~> 8.0", PinnedVersion: "8.1.0"},
+				},
+				Solution: &reporting.Solution{Conflict: &reporting.SolverConflict{Cookbook: "mysql",
+					Constraints: []reporting.SourcedConstraint{
+						{Constraint: "~> 8.0", Source: "environment production"},
+						{Constraint: "< 8.0", Source: "app 1.2.3"},
+					},
+				}},
+			},
+			&reporting.EnvironmentRecord{Name: "staging",
+				Constraints: []*reporting.ConstraintRecord{
+					&reporting.ConstraintRecord{Cookbook: "app", Constraint: ">= 1.0", PinnedVersion: "1.2.3"},
+				},
+				Solution: &reporting.Solution{Cookbooks: []reporting.CookbookVersion{
+					{Name: "app", Version: "1.2.3"}, {Name: "mysql", Version: "7.4.0"},
+				}},
+			},
+		},
+	}
+
+	var (
+		actual         = subject.MakeEnvironmentsReportTXT(es)
+		expectedReport = `> Environment: _default
+  Description: -
+  Nodes: 0
+  Cookbook Constraints: none
+  Solution: none
+> Environment: production
+  Description: -
+  Nodes: 0
+  Cookbook Constraints:
+   - app = 1.2.3 => 1.2.3
+   - mysql ~> 8.0 => 8.1.0
+  Solution: unsatisfiable, no uploaded version of mysql satisfies ~> 8.0 (environment production), < 8.0 (app 1.2.3)
+> Environment: staging
+  Description: -
+  Nodes: 0
+  Cookbook Constraints:
+   - app >= 1.0 => 1.2.3
+  Solution:
+   - app (1.2.3)
+   - mysql (7.4.0)
+`
+	)
+	assert.Equal(t, expectedReport, actual.Report)
+	assert.Empty(t, actual.Errors)
+}
+
+func TestMakeDataBagsReportTXT_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeDataBagsReportTXT(nil))
+}
+
+func TestMakeDataBagsReportTXT_WithRecords(t *testing.T) {
+	ds := &reporting.DataBagsStatus{
+		Records: []*reporting.DataBagRecord{
+			&reporting.DataBagRecord{Name: "secrets",
+				Items: []*reporting.DataBagItemRecord{
+					&reporting.DataBagItemRecord{Name: "api", Encrypted: true, EncryptionVersion: 3, Cipher: "aes-256-gcm"},
+					&reporting.DataBagItemRecord{Name: "aws", Encrypted: true, EncryptionVersion: 1, Cipher: "aes-256-cbc"},
+					&reporting.DataBagItemRecord{Name: "db", GetError: errors.New("unable to get item db")},
+					&reporting.DataBagItemRecord{Name: "notes"},
+				},
+			},
+		},
+	}
+
+	var (
+		actual         = subject.MakeDataBagsReportTXT(ds)
+		expectedReport = `> Data Bag: secrets
+  Items: 4 (2 encrypted)
+   - api: encrypted (v3, aes-256-gcm)
+   - aws: encrypted (v1, aes-256-cbc) - legacy encryption format
+   - db: unknown
+   - notes: not encrypted
+`
+	)
+	assert.Equal(t, expectedReport, actual.Report)
+	assert.Equal(t, " - secrets: unable to get item db\n", actual.Errors)
+}
+
+func TestMakePoliciesReportTXT_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakePoliciesReportTXT(nil))
+}
+
+func TestMakePoliciesReportTXT_WithRecords(t *testing.T) {
+	ps := &reporting.PoliciesStatus{
+		Records: []*reporting.PolicyGroupRecord{
+			&reporting.PolicyGroupRecord{Name: "empty"},
+			&reporting.PolicyGroupRecord{Name: "prod",
+				Policies: []*reporting.PolicyRecord{
+					&reporting.PolicyRecord{Name: "app", RevisionID: "abc123", NumRevisions: 3, NumNodes: 2,
+						CookbookLocks: []reporting.CookbookVersion{
+							reporting.CookbookVersion{Name: "apache2", Version: "5.0.1"},
+							reporting.CookbookVersion{Name: "mysql", Version: "8.0.0"},
+						},
+					},
+					&reporting.PolicyRecord{Name: "db", RevisionID: "def456", NumRevisions: 1,
+						GetError: errors.New("unable to get revision def456 of policy db")},
+				},
+			},
+		},
+	}
+
+	var (
+		actual         = subject.MakePoliciesReportTXT(ps)
+		expectedReport = `> Policy Group: empty
+  Policies: none
+> Policy Group: prod
+  Policies:
+   - app
+     Revision: abc123 (3 available)
+     Nodes: 2
+     Cookbooks Locked: apache2(5.0.1), mysql(8.0.0)
+   - db
+     Revision: def456 (1 available)
+     Nodes: 0
+     Cookbooks Locked: unknown
+`
+	)
+	assert.Equal(t, expectedReport, actual.Report)
+	assert.Equal(t, " - prod: unable to get revision def456 of policy db\n", actual.Errors)
+}
+
+func TestMakeFixesReportTXT_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeFixesReportTXT(nil))
+}
+
+func TestMakeFixesReportTXT_WithFixes(t *testing.T) {
+	corrected := reporting.CookstyleOffense{CopName: "ChefDeprecations/Fixed", Message: "fixed it", Corrected: true}
+	corrected.Location.StartLine = 1
+	corrected.Location.StartColumn = 1
+	remaining := reporting.CookstyleOffense{CopName: "ChefCorrectness/Manual", Message: "fix it yourself"}
+	remaining.Location.StartLine = 5
+	remaining.Location.StartColumn = 3
+
+	fs := &reporting.FixStatus{
+		Fixes: []*reporting.CookbookFix{
+			&reporting.CookbookFix{Name: "foo", Version: "1.0.0",
+				Corrected: []reporting.CookbookFile{
+					reporting.CookbookFile{Path: "recipes/default.rb", Offenses: []reporting.CookstyleOffense{corrected}},
+				},
+				Remaining: []reporting.CookbookFile{
+					reporting.CookbookFile{Path: "recipes/service.rb", Offenses: []reporting.CookstyleOffense{remaining}},
+				},
+			},
+			&reporting.CookbookFix{Name: "bar", Version: "2.0.0", CookstyleError: errors.New("cookstyle error")},
+		},
+	}
+
+	var (
+		actual         = subject.MakeFixesReportTXT(fs)
+		expectedReport = `> Cookbook: foo (1.0.0)
+  Corrected: 1
+   - recipes/default.rb:
+	1:1 ChefDeprecations/Fixed fixed it
+  Remaining (manual work required): 1
+   - recipes/service.rb:
+	5:3 ChefCorrectness/Manual fix it yourself
+> Cookbook: bar (2.0.0)
+  Corrected: unknown
+  Remaining: unknown
+`
+		expectedErrors = " - bar (2.0.0): cookstyle error\n"
+	)
+	assert.Equal(t, expectedReport, actual.Report)
+	assert.Equal(t, expectedErrors, actual.Errors)
+}
+
+func TestMakeExplainNodeTXT_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeExplainNodeTXT(nil))
+}
+
+func TestMakeExplainNodeTXT(t *testing.T) {
+	expansion := &reporting.NodeExpansion{
+		Node:        &reporting.NodeReportItem{Name: "web01"},
+		Environment: "production",
+		RunList:     []string{"role[webserver]"},
+		Recipes: []reporting.ExpandedRecipe{
+			reporting.ExpandedRecipe{RunListItem: reporting.RunListItem{Type: "recipe", Name: "apache2"}},
+		},
+		Cookbooks: []reporting.CookbookProvenance{
+			reporting.CookbookProvenance{Name: "apache2", Version: "5.0.1", Chain: []reporting.ProvenanceStep{
+				reporting.ProvenanceStep{Type: "role", Name: "webserver"},
+				reporting.ProvenanceStep{Type: "recipe", Name: "apache2::default"},
+			}},
+			reporting.CookbookProvenance{Name: "build-essential", Version: "8.2.1", Chain: []reporting.ProvenanceStep{
+				reporting.ProvenanceStep{Type: "role", Name: "webserver", Detail: "production"},
+				reporting.ProvenanceStep{Type: "recipe", Name: "apache2::default"},
+				reporting.ProvenanceStep{Type: "depends", Name: "build-essential", Detail: ">= 0.0.0"},
+			}},
+			reporting.CookbookProvenance{Name: "leftover", Version: "0.1.0"},
+		},
+		MissingRoles: []string{"ghost"},
+		RoleCycles:   [][]string{[]string{"base", "security", "base"}},
+		Errors:       []error{errors.New("unable to get metadata of cookbook foo (1.0.0)")},
+	}
+
+	var (
+		actual         = subject.MakeExplainNodeTXT(expansion)
+		expectedReport = `> Node: web01
+  Environment: production
+  Run List: role[webserver]
+  Expanded Run List: apache2::default
+  Cookbooks:
+   - apache2 (5.0.1)
+     role[webserver] -> recipe[apache2::default]
+   - build-essential (8.2.1)
+     role[webserver] (production run list) -> recipe[apache2::default] -> depends 'build-essential', '>= 0.0.0'
+   - leftover (0.1.0)
+     not in the expanded run list
+  Missing Roles: ghost
+  Role Cycle: base -> security -> base
+`
+	)
+	assert.Equal(t, expectedReport, actual.Report)
+	assert.Equal(t, " - web01: unable to get metadata of cookbook foo (1.0.0)\n", actual.Errors)
+}
+
+func TestMakeNodesReportTXT_WithPolicies(t *testing.T) {
+	nodesReport := []*reporting.NodeReportItem{
+		&reporting.NodeReportItem{Name: "node1", ChefVersion: "15.4", OS: "ubuntu", OSVersion: "18.04",
+			PolicyName: "app", PolicyGroup: "prod"},
+	}
+
+	expectedReport := `> Node: node1
+  Chef Version: 15.4
+  Operating System: ubuntu v18.04
+  Policy: app (prod)
+  Cookbooks Applied: none
+`
+	assert.Equal(t, expectedReport, subject.MakeNodesReportTXT(nodesReport, reporting.NodesFilter{}).Report)
+}
+
+func TestMakeCookbooksReportTXT_WithPolicies(t *testing.T) {
+	cbStatus := reporting.CookbooksStatus{
+		Records: []*reporting.CookbookRecord{
+			&reporting.CookbookRecord{Name: "my-cookbook", Version: "1.0", Policies: []string{"dev/app", "prod/app"}},
+		},
+	}
+
+	expectedReport := `> Cookbook: my-cookbook (1.0)
+  Nodes affected: none
+  Policies: dev/app, prod/app
+`
+	assert.Equal(t, expectedReport, subject.MakeCookbooksReportTXT(&cbStatus).Report)
+}
+
+func TestMakeConfigStatusTXT_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeConfigStatusTXT(nil))
+}
+
+func TestMakeConfigStatusTXT(t *testing.T) {
+	status := reporting.ConfigStatus{
+		Profile:       "dev",
+		ChefServerURL: "https://chef.example.com/organizations/dev",
+		ClientName:    "admin",
+		Checks: []*reporting.ConfigCheck{
+			&reporting.ConfigCheck{Description: "Credentials profile loaded"},
+			&reporting.ConfigCheck{Description: "Signed requests are accepted",
+				Error: errors.New("GET https://chef.example.com: 401"), Hint: "check your key"},
+			&reporting.ConfigCheck{Description: "Permission to search nodes", Skipped: true},
+		},
+	}
+
+	expectedReport := `Profile: dev
+Chef Infra Server URL: https://chef.example.com/organizations/dev
+Client Name: admin
+
+[ OK ] Credentials profile loaded
+[FAIL] Signed requests are accepted
+       GET https://chef.example.com: 401
+       hint: check your key
+[SKIP] Permission to search nodes
+
+1 check(s) failed.
+`
+	assert.Equal(t, expectedReport, subject.MakeConfigStatusTXT(&status).Report)
+
+	status.Checks[1].Error = nil
+	status.Checks[1].Hint = ""
+	assert.Contains(t,
+		subject.MakeConfigStatusTXT(&status).Report,
+		"[ OK ] Signed requests are accepted\n[SKIP] Permission to search nodes\n\nYour configuration is ready to generate reports.\n")
+}
+
+func TestMakeDependenciesReportTXT_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeDependenciesReportTXT(nil))
+}
+
+func TestMakeDependenciesReportTXT(t *testing.T) {
+	actual := subject.MakeDependenciesReportTXT(dependencyGraphFixture())
+	assert.Equal(t, ` - broken (0.1.0): not found
+`, actual.Errors)
+	assert.Equal(t, `> Cookbook: app (1.2.3)
+  Dependencies:
+   - base ~> 2.0 => 2.1.0
+   - mysql >= 8.0 => unsatisfied
+  Dependents: none
+> Cookbook: base (2.1.0)
+  Dependencies:
+   - common >= 0.0.0 => 1.0.0
+  Dependents: app (1.2.3), common (1.0.0)
+> Cookbook: broken (0.1.0)
+  Dependencies: none
+  Dependents: none
+> Cookbook: common (1.0.0)
+  Dependencies:
+   - base >= 2.0 => 2.1.0
+  Dependents: base (2.1.0)
+
+Circular Dependencies:
+ - base, common
+Leaf Dependencies (depended on, without dependencies of their own): none
+`, actual.Report)
+}
+
+func TestMakeClientVersionsReportTXT_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeClientVersionsReportTXT(nil))
+}
+
+func TestMakeClientVersionsReportTXT(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{
+			Report: `> Chef Infra Client: 15.4
+  Support: supported
+  EOL: 2021-04-30
+  Ohai Versions: 15.3.1
+  Ruby Versions: 2.6.3, 2.6.5
+  Nodes (2, 66.7%): web01, web02
+> Chef Infra Client: 14.12
+  Support: eol
+  EOL: 2020-04-30
+  Ohai Versions: none
+  Ruby Versions: none
+  Nodes (1, 33.3%): db01
+
+Nodes on unsupported releases: db01
+`,
+			Errors: "",
+		},
+		subject.MakeClientVersionsReportTXT(clientVersionsFixture()))
+}
+
+func TestMakeOSSupportReportTXT_Nil(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{Report: "", Errors: ""},
+		subject.MakeOSSupportReportTXT(nil))
+}
+
+func TestMakeOSSupportReportTXT(t *testing.T) {
+	assert.Equal(t,
+		&subject.FormattedResult{
+			Report: `> Operating System: centos 7
+  Support: eol
+  EOL: 2020-04-30
+  Nodes (1): db01
+> Operating System: ubuntu 18.04
+  Support: near-eol
+  EOL: 2020-10-31
+  Nodes (1): web01
+> Operating System: Windows Server 2016
+  Support: supported
+  EOL: 2027-01-12
+  Nodes (1): win01
+> Operating System: unknown
+  Support: unknown
+  Nodes (1): new
+`,
+			Errors: "",
+		},
+		subject.MakeOSSupportReportTXT(osSupportFixture()))
+}
+
+func TestMakeCookbooksReportTXT_PolicyErrors(t *testing.T) {
+	cbStatus := reporting.CookbooksStatus{
+		Records:      []*reporting.CookbookRecord{&reporting.CookbookRecord{Name: "foo", Version: "0.1.0"}},
+		PolicyErrors: []error{errors.New("unable to retrieve policy groups: 403 Forbidden")},
+	}
+	actual := subject.MakeCookbooksReportTXT(&cbStatus)
+	assert.Equal(t, " - policies: unable to retrieve policy groups: 403 Forbidden\n", actual.Errors)
+	assert.Equal(t, actual.Errors, subject.MakeCookbooksReportCSV(&cbStatus).Errors)
+}
@@ -0,0 +1,623 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	chef "github.com/chef/go-chef"
+	"github.com/pkg/errors"
+)
+
+// the default location of the cache, relative to the current directory
+const DefaultCacheDir = ".analyze-cache"
+
+// sub-directories of the cache directory
+const (
+	cacheCookbooksDir = "cookbooks"
+	cacheManifestsDir = "manifests"
+	cacheFilesDir     = "files"
+	cacheCookstyleDir = "cookstyle"
+	cacheRunsDir      = "runs"
+)
+
+// CookbookCache is a persistent, content-addressed cache of cookbook versions
+//
+// every cookbook file is stored once inside 'files/', named by the checksum that
+// the Chef Infra Server reports, and cookbook versions are materialized inside
+// 'cookbooks/<name>-<version>/' by hard linking those files, this deduplicates
+// the files shared between versions. a manifest per cookbook version, inside
+// 'manifests/', records the checksums of its files and when it was last used
+//
+//	.analyze-cache/
+//	├── cookbooks/apache2-5.0.1/recipes/default.rb
+//	├── files/0d/0d3a6c4c1f0ba1d8e4e8f42b0b7f1d8c
+//	└── manifests/apache2-5.0.1.json
+type CookbookCache struct {
+	Dir       string
+	Cookbooks CookbookInterface
+}
+
+// CookbookManifest records the files of a cached cookbook version
+type CookbookManifest struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// relative path -> checksum
+	Files    map[string]string `json:"files"`
+	LastUsed time.Time         `json:"last_used"`
+}
+
+// CachedCookbook is a cookbook version stored in the cache
+type CachedCookbook struct {
+	Name     string
+	Version  string
+	NumFiles int
+	// files shared with other versions count towards the size of every version
+	Size int64
+	// zero when unknown, that is, the cookbook version has no manifest
+	LastUsed time.Time
+}
+
+// CacheUsage describes the content of the cache
+type CacheUsage struct {
+	Cookbooks []CachedCookbook
+	// number of unique files and their size on disk
+	NumFiles int
+	Size     int64
+}
+
+// the sum of the size of every cookbook version, as if files were not deduplicated
+func (cu *CacheUsage) ApparentSize() int64 {
+	var size int64
+	for _, cb := range cu.Cookbooks {
+		size += cb.Size
+	}
+	return size
+}
+
+// PruneResult describes what was removed from the cache
+type PruneResult struct {
+	Removed  []CachedCookbook
+	NumFiles int
+	Freed    int64
+	// number of memoized cookstyle results removed
+	NumResults int
+	// number of run journals removed
+	NumRuns int
+}
+
+func NewCookbookCache(dir string, cbi CookbookInterface) *CookbookCache {
+	if dir == "" {
+		dir = DefaultCacheDir
+	}
+	return &CookbookCache{Dir: dir, Cookbooks: cbi}
+}
+
+// returns the directory where the provided cookbook version is materialized
+func (cc *CookbookCache) CookbookPath(name, version string) string {
+	return filepath.Join(cc.Dir, cacheCookbooksDir, fmt.Sprintf("%s-%s", name, version))
+}
+
+// makes sure the provided cookbook version is available inside the cache and
+// returns its location, only the files whose checksums are not in the cache
+// already are downloaded from the Chef Infra Server
+func (cc *CookbookCache) Fetch(name, version string) (string, error) {
+	cookbook, err := cc.Cookbooks.GetVersion(name, version)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		cbPath  = cc.CookbookPath(name, version)
+		files   = cookbookFiles(cookbook)
+		missing = make([]string, 0)
+	)
+
+	for rel, checksum := range files {
+		target := filepath.Join(cbPath, rel)
+		if fileHasChecksum(target, checksum) {
+			// cookbooks cached before we stored files by checksum
+			if !fileExists(cc.blobPath(checksum)) {
+				cc.storeBlob(target, checksum)
+			}
+			continue
+		}
+
+		// the target could be a link to a file of another version, never write through it
+		os.Remove(target)
+
+		if blob := cc.blobPath(checksum); fileExists(blob) {
+			if err := linkOrCopy(blob, target); err == nil {
+				continue
+			}
+		}
+		missing = append(missing, rel)
+	}
+
+	if len(missing) != 0 || !fileExists(cbPath) {
+		// NOTE: the go-chef library skips the files that already have the right checksum
+		err = cc.Cookbooks.DownloadTo(name, version, filepath.Join(cc.Dir, cacheCookbooksDir))
+		if err != nil {
+			return cbPath, err
+		}
+
+		for _, rel := range missing {
+			cc.storeBlob(filepath.Join(cbPath, rel), files[rel])
+		}
+	}
+
+	// nothing to record for empty cookbooks
+	if len(files) == 0 {
+		return cbPath, nil
+	}
+
+	removeUnknownFiles(cbPath, files)
+
+	return cbPath, cc.writeManifest(CookbookManifest{
+		Name:     name,
+		Version:  version,
+		Files:    files,
+		LastUsed: time.Now(),
+	})
+}
+
+// returns the content of the cache
+func (cc *CookbookCache) List() (*CacheUsage, error) {
+	usage := &CacheUsage{Cookbooks: make([]CachedCookbook, 0)}
+
+	entries, err := ioutil.ReadDir(filepath.Join(cc.Dir, cacheCookbooksDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "unable to list cached cookbooks")
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		cached := CachedCookbook{}
+		if manifest, err := cc.readManifest(entry.Name()); err == nil {
+			cached.Name = manifest.Name
+			cached.Version = manifest.Version
+			cached.LastUsed = manifest.LastUsed
+		} else {
+			// cookbooks downloaded before we had a manifest
+			cached.Name, cached.Version = splitCookbookDirName(entry.Name())
+		}
+
+		cached.NumFiles, cached.Size = dirUsage(filepath.Join(cc.Dir, cacheCookbooksDir, entry.Name()))
+		usage.Cookbooks = append(usage.Cookbooks, cached)
+	}
+
+	usage.NumFiles, usage.Size = dirUsage(filepath.Join(cc.Dir, cacheFilesDir))
+
+	sort.Slice(usage.Cookbooks, func(i, j int) bool {
+		if usage.Cookbooks[i].Name == usage.Cookbooks[j].Name {
+			return usage.Cookbooks[i].Version < usage.Cookbooks[j].Version
+		}
+		return usage.Cookbooks[i].Name < usage.Cookbooks[j].Name
+	})
+
+	return usage, nil
+}
+
+// removes the cookbook versions that have not been used for longer than the
+// provided age, then, the least recently used ones until the total size of the
+// cache is below maxSize, and finally, the files no cookbook version uses
+//
+// an age or a maxSize of zero disables that criteria
+func (cc *CookbookCache) Prune(olderThan time.Duration, maxSize int64) (*PruneResult, error) {
+	usage, err := cc.List()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PruneResult{Removed: make([]CachedCookbook, 0)}
+
+	// least recently used first
+	cookbooks := usage.Cookbooks
+	sort.SliceStable(cookbooks, func(i, j int) bool {
+		return cookbooks[i].LastUsed.Before(cookbooks[j].LastUsed)
+	})
+
+	// the files shared between cookbook versions count once towards the size
+	// of the cache, and they are freed only when no remaining version uses them
+	var (
+		files = make([]map[string]int64, len(cookbooks))
+		refs  = map[string]int{}
+		size  int64
+	)
+	for i, cached := range cookbooks {
+		files[i] = cc.cookbookFileSizes(cached)
+		for key, fileSize := range files[i] {
+			if refs[key] == 0 {
+				size += fileSize
+			}
+			refs[key]++
+		}
+	}
+
+	for i, cached := range cookbooks {
+		expired := olderThan > 0 && time.Since(cached.LastUsed) > olderThan
+		oversized := maxSize > 0 && size > maxSize
+		if !expired && !oversized {
+			continue
+		}
+
+		if err := cc.remove(cached.Name, cached.Version); err != nil {
+			return result, err
+		}
+		for key, fileSize := range files[i] {
+			refs[key]--
+			if refs[key] == 0 {
+				size -= fileSize
+			}
+		}
+		result.Removed = append(result.Removed, cached)
+	}
+
+	result.NumFiles, result.Freed, err = cc.removeUnusedBlobs()
+	if err != nil {
+		return result, err
+	}
+
+	result.NumResults, err = removeExpiredFiles(cc.CookstyleDir(), olderThan)
+	if err != nil {
+		return result, errors.Wrap(err, "unable to prune cookstyle results")
+	}
+
+	result.NumRuns, err = removeExpiredFiles(cc.RunsDir(), olderThan)
+	if err != nil {
+		return result, errors.Wrap(err, "unable to prune run journals")
+	}
+	return result, nil
+}
+
+// returns the directory where the cookstyle results are memoized
+func (cc *CookbookCache) CookstyleDir() string {
+	return filepath.Join(cc.Dir, cacheCookstyleDir)
+}
+
+// returns the directory where the journals of the runs are stored
+func (cc *CookbookCache) RunsDir() string {
+	return filepath.Join(cc.Dir, cacheRunsDir)
+}
+
+// removes every cookbook version, file, cookstyle result and run journal from the cache
+func (cc *CookbookCache) Clean() error {
+	for _, dir := range []string{cacheCookbooksDir, cacheManifestsDir, cacheFilesDir, cacheCookstyleDir, cacheRunsDir} {
+		if err := os.RemoveAll(filepath.Join(cc.Dir, dir)); err != nil {
+			return errors.Wrapf(err, "unable to clean cache directory '%s'", filepath.Join(cc.Dir, dir))
+		}
+	}
+	return nil
+}
+
+func (cc *CookbookCache) remove(name, version string) error {
+	dirName := fmt.Sprintf("%s-%s", name, version)
+	if err := os.RemoveAll(filepath.Join(cc.Dir, cacheCookbooksDir, dirName)); err != nil {
+		return errors.Wrapf(err, "unable to remove cookbook %s (%s) from the cache", name, version)
+	}
+	err := os.Remove(cc.manifestPath(dirName))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "unable to remove cookbook %s (%s) from the cache", name, version)
+	}
+	return nil
+}
+
+// returns the size of the files of a cached cookbook version keyed by their
+// checksum, cookbooks without a manifest don't share their files with others
+func (cc *CookbookCache) cookbookFileSizes(cached CachedCookbook) map[string]int64 {
+	cbPath := cc.CookbookPath(cached.Name, cached.Version)
+	manifest, err := cc.readManifest(filepath.Base(cbPath))
+	if err != nil {
+		return map[string]int64{cbPath: cached.Size}
+	}
+
+	sizes := make(map[string]int64, len(manifest.Files))
+	for rel, checksum := range manifest.Files {
+		key, file := checksum, cc.blobPath(checksum)
+		if !fileExists(file) {
+			// the file couldn't be stored by its checksum, it is only inside the cookbook
+			key = filepath.Join(cbPath, rel)
+			file = key
+		}
+		if info, err := os.Stat(file); err == nil {
+			sizes[key] = info.Size()
+		}
+	}
+	return sizes
+}
+
+// removes the files that are not listed in any manifest
+func (cc *CookbookCache) removeUnusedBlobs() (int, int64, error) {
+	inUse := map[string]bool{}
+	manifests, err := ioutil.ReadDir(filepath.Join(cc.Dir, cacheManifestsDir))
+	if err != nil && !os.IsNotExist(err) {
+		return 0, 0, errors.Wrap(err, "unable to list cache manifests")
+	}
+	for _, m := range manifests {
+		manifest, err := cc.readManifest(strings.TrimSuffix(m.Name(), ".json"))
+		if err != nil {
+			// we can't tell which files this cookbook uses, keep them all
+			return 0, 0, err
+		}
+		for _, checksum := range manifest.Files {
+			inUse[checksum] = true
+		}
+	}
+
+	var (
+		removed int
+		freed   int64
+	)
+	err = filepath.Walk(filepath.Join(cc.Dir, cacheFilesDir), func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || inUse[info.Name()] {
+			return nil
+		}
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+		removed++
+		freed += info.Size()
+		return nil
+	})
+	if err != nil {
+		return removed, freed, errors.Wrap(err, "unable to remove unused cache files")
+	}
+
+	return removed, freed, nil
+}
+
+// removes the files inside a directory that have not been modified for longer than olderThan,
+// the modification time of a memoized cookstyle result is updated every time it is used
+func removeExpiredFiles(dir string, olderThan time.Duration) (int, error) {
+	if olderThan <= 0 {
+		return 0, nil
+	}
+
+	removed := 0
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || time.Since(info.ModTime()) <= olderThan {
+			return nil
+		}
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+		removed++
+		return nil
+	})
+	return removed, err
+}
+
+// files are spread in sub-directories by the first two characters of their checksum
+func (cc *CookbookCache) blobPath(checksum string) string {
+	if len(checksum) < 2 {
+		return filepath.Join(cc.Dir, cacheFilesDir, checksum)
+	}
+	return filepath.Join(cc.Dir, cacheFilesDir, checksum[:2], checksum)
+}
+
+// stores a downloaded file inside the files directory, or, if the cache has
+// that file already, replaces the downloaded file with a link to it
+func (cc *CookbookCache) storeBlob(file, checksum string) {
+	if !fileHasChecksum(file, checksum) {
+		return
+	}
+
+	blob := cc.blobPath(checksum)
+	if !fileExists(blob) {
+		linkOrCopy(file, blob)
+		return
+	}
+
+	if sameFile(file, blob) {
+		return
+	}
+	if os.Remove(file) == nil {
+		linkOrCopy(blob, file)
+	}
+}
+
+func (cc *CookbookCache) manifestPath(dirName string) string {
+	return filepath.Join(cc.Dir, cacheManifestsDir, dirName+".json")
+}
+
+func (cc *CookbookCache) readManifest(dirName string) (CookbookManifest, error) {
+	manifest := CookbookManifest{}
+	content, err := ioutil.ReadFile(cc.manifestPath(dirName))
+	if err != nil {
+		return manifest, errors.Wrapf(err, "unable to read cache manifest of %s", dirName)
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return manifest, errors.Wrapf(err, "malformed cache manifest of %s", dirName)
+	}
+	return manifest, nil
+}
+
+func (cc *CookbookCache) writeManifest(manifest CookbookManifest) error {
+	content, err := json.Marshal(manifest)
+	if err != nil {
+		return errors.Wrap(err, "unable to generate cache manifest")
+	}
+
+	dirName := fmt.Sprintf("%s-%s", manifest.Name, manifest.Version)
+	if err := os.MkdirAll(filepath.Join(cc.Dir, cacheManifestsDir), os.ModePerm); err != nil {
+		return errors.Wrap(err, "unable to create cache manifests directory")
+	}
+	if err := ioutil.WriteFile(cc.manifestPath(dirName), content, 0644); err != nil {
+		return errors.Wrapf(err, "unable to write cache manifest of %s", dirName)
+	}
+	return nil
+}
+
+// returns the files of a cookbook version with their checksums, the relative
+// paths match the location where the go-chef library downloads them
+func cookbookFiles(cookbook chef.Cookbook) map[string]string {
+	files := map[string]string{}
+	segments := []struct {
+		dir   string
+		items []chef.CookbookItem
+	}{
+		{"", cookbook.RootFiles},
+		{"files", cookbook.Files},
+		{"templates", cookbook.Templates},
+		{"attributes", cookbook.Attributes},
+		{"recipes", cookbook.Recipes},
+		{"definitions", cookbook.Definitions},
+		{"libraries", cookbook.Libraries},
+		{"providers", cookbook.Providers},
+		{"resources", cookbook.Resources},
+	}
+
+	for _, segment := range segments {
+		for _, item := range segment.items {
+			files[filepath.FromSlash(path.Join(segment.dir, item.Name))] = strings.ToLower(item.Checksum)
+		}
+	}
+	return files
+}
+
+// removes the files inside the directory that don't belong to the cookbook
+// version anymore, this could happen when a version is uploaded again
+func removeUnknownFiles(dir string, files map[string]string) {
+	filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if rel, err := filepath.Rel(dir, p); err == nil {
+			if _, ok := files[rel]; !ok {
+				os.Remove(p)
+			}
+		}
+		return nil
+	})
+}
+
+// splits a directory name like 'apache2-5.0.1' into name and version,
+// versions never have dashes but cookbook names do
+func splitCookbookDirName(dirName string) (string, string) {
+	i := strings.LastIndex(dirName, "-")
+	if i == -1 {
+		return dirName, ""
+	}
+	return dirName[:i], dirName[i+1:]
+}
+
+// returns the number of files inside a directory and their total size
+func dirUsage(dir string) (int, int64) {
+	var (
+		count int
+		size  int64
+	)
+	filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			count++
+			size += info.Size()
+		}
+		return nil
+	})
+	return count, size
+}
+
+func fileExists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
+
+func sameFile(a, b string) bool {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(aInfo, bInfo)
+}
+
+// verifies the MD5 checksum of a file, the Chef Infra Server uses MD5
+// checksums to identify cookbook files
+func fileHasChecksum(p, checksum string) bool {
+	f, err := os.Open(p)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return false
+	}
+	return hex.EncodeToString(h.Sum(nil)) == checksum
+}
+
+// hard links the source file to the destination, when that is not possible,
+// like on file systems without hard links, the file is copied instead
+func linkOrCopy(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return err
+	}
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+	// somebody else created it already, never write through an existing link
+	if fileExists(dst) {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,245 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func newCacheFixture(t *testing.T) (*subject.CookbookCache, *CookbookFilesMock, func()) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock := &CookbookFilesMock{
+		desiredFiles: map[string]map[string]string{
+			"foo-1.0.0": map[string]string{
+				"metadata.rb":        "name 'foo'\nversion '1.0.0'\n",
+				"recipes/default.rb": "package 'foo'\n",
+				"recipes/service.rb": "service 'foo'\n",
+			},
+			"foo-1.1.0": map[string]string{
+				"metadata.rb":        "name 'foo'\nversion '1.1.0'\n",
+				"recipes/default.rb": "package 'foo'\n",
+				"recipes/service.rb": "service 'foo' do\n  action :start\nend\n",
+			},
+		},
+	}
+
+	return subject.NewCookbookCache(dir, mock), mock, func() { os.RemoveAll(dir) }
+}
+
+func TestCookbookCacheFetch(t *testing.T) {
+	cache, mock, cleanup := newCacheFixture(t)
+	defer cleanup()
+
+	cbPath, err := cache.Fetch("foo", "1.0.0")
+	if assert.Nil(t, err) {
+		assert.Equal(t, filepath.Join(cache.Dir, "cookbooks", "foo-1.0.0"), cbPath)
+		content, err := ioutil.ReadFile(filepath.Join(cbPath, "recipes", "default.rb"))
+		assert.Nil(t, err)
+		assert.Equal(t, "package 'foo'\n", string(content))
+	}
+	assert.Equal(t, 3, mock.downloadedFiles)
+
+	// a second fetch is served from the cache
+	_, err = cache.Fetch("foo", "1.0.0")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, mock.downloadedFiles, "cached files should not be downloaded again")
+
+	// files shared between versions are not downloaded again and are deduplicated
+	_, err = cache.Fetch("foo", "1.1.0")
+	assert.Nil(t, err)
+	assert.Equal(t, 5, mock.downloadedFiles, "only the files that changed should be downloaded")
+
+	v1, err := os.Stat(filepath.Join(cache.CookbookPath("foo", "1.0.0"), "recipes", "default.rb"))
+	assert.Nil(t, err)
+	v2, err := os.Stat(filepath.Join(cache.CookbookPath("foo", "1.1.0"), "recipes", "default.rb"))
+	assert.Nil(t, err)
+	assert.True(t, os.SameFile(v1, v2), "shared files should be stored only once")
+
+	usage, err := cache.List()
+	if assert.Nil(t, err) {
+		assert.Equal(t, 5, usage.NumFiles, "unique files")
+		assert.Equal(t, 2, len(usage.Cookbooks))
+		assert.Equal(t, "1.0.0", usage.Cookbooks[0].Version)
+		assert.Equal(t, 3, usage.Cookbooks[0].NumFiles)
+		assert.False(t, usage.Cookbooks[0].LastUsed.IsZero())
+		assert.True(t, usage.ApparentSize() > usage.Size)
+	}
+}
+
+func TestCookbookCacheFetchChangedFiles(t *testing.T) {
+	cache, mock, cleanup := newCacheFixture(t)
+	defer cleanup()
+
+	_, err := cache.Fetch("foo", "1.0.0")
+	assert.Nil(t, err)
+	_, err = cache.Fetch("foo", "1.1.0")
+	assert.Nil(t, err)
+
+	// the version is uploaded again with a different shared file and without
+	// one of its recipes
+	mock.desiredFiles["foo-1.1.0"] = map[string]string{
+		"metadata.rb":        "name 'foo'\nversion '1.1.0'\n",
+		"recipes/default.rb": "package 'bar'\n",
+	}
+	cbPath, err := cache.Fetch("foo", "1.1.0")
+	assert.Nil(t, err)
+
+	content, err := ioutil.ReadFile(filepath.Join(cbPath, "recipes", "default.rb"))
+	assert.Nil(t, err)
+	assert.Equal(t, "package 'bar'\n", string(content))
+	_, err = os.Stat(filepath.Join(cbPath, "recipes", "service.rb"))
+	assert.True(t, os.IsNotExist(err), "files removed from the version should be removed from the cache")
+
+	// the other version must not be affected by the new download
+	content, err = ioutil.ReadFile(filepath.Join(cache.CookbookPath("foo", "1.0.0"), "recipes", "default.rb"))
+	assert.Nil(t, err)
+	assert.Equal(t, "package 'foo'\n", string(content))
+}
+
+func TestCookbookCacheFetchErrors(t *testing.T) {
+	cache, _, cleanup := newCacheFixture(t)
+	defer cleanup()
+
+	_, err := cache.Fetch("bar", "1.0.0")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "404 not found", err.Error())
+	}
+
+	cache.Cookbooks = CookbookMock{desiredDownloadError: errors.New("download error")}
+	_, err = cache.Fetch("bar", "1.0.0")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "download error", err.Error())
+	}
+}
+
+func TestCookbookCacheListLegacyDirectories(t *testing.T) {
+	cache, _, cleanup := newCacheFixture(t)
+	defer cleanup()
+
+	// cookbooks downloaded before the cache had manifests
+	legacy := filepath.Join(cache.Dir, "cookbooks", "my-cookbook-0.1.0")
+	if err := os.MkdirAll(legacy, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(legacy, "metadata.rb"), []byte("name 'my-cookbook'"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	usage, err := cache.List()
+	if assert.Nil(t, err) && assert.Equal(t, 1, len(usage.Cookbooks)) {
+		assert.Equal(t, "my-cookbook", usage.Cookbooks[0].Name)
+		assert.Equal(t, "0.1.0", usage.Cookbooks[0].Version)
+		assert.True(t, usage.Cookbooks[0].LastUsed.IsZero())
+	}
+}
+
+func TestCookbookCacheListEmpty(t *testing.T) {
+	cache := subject.NewCookbookCache(filepath.Join(os.TempDir(), "does-not-exist"), nil)
+	usage, err := cache.List()
+	if assert.Nil(t, err) {
+		assert.Empty(t, usage.Cookbooks)
+		assert.Equal(t, 0, usage.NumFiles)
+	}
+}
+
+func TestCookbookCachePrune(t *testing.T) {
+	cache, _, cleanup := newCacheFixture(t)
+	defer cleanup()
+
+	_, err := cache.Fetch("foo", "1.0.0")
+	assert.Nil(t, err)
+	time.Sleep(10 * time.Millisecond)
+	_, err = cache.Fetch("foo", "1.1.0")
+	assert.Nil(t, err)
+
+	// nothing is old enough
+	result, err := cache.Prune(time.Hour, 0)
+	if assert.Nil(t, err) {
+		assert.Empty(t, result.Removed)
+		assert.Equal(t, 0, result.NumFiles)
+	}
+
+	// shared files count once, the cache fits in its size on disk
+	usage, err := cache.List()
+	assert.Nil(t, err)
+	result, err = cache.Prune(0, usage.Size)
+	if assert.Nil(t, err) {
+		assert.Empty(t, result.Removed)
+		assert.Equal(t, 0, result.NumFiles)
+	}
+
+	// the least recently used version is removed to fit in the max size
+	result, err = cache.Prune(0, usage.Size-1)
+	if assert.Nil(t, err) && assert.Equal(t, 1, len(result.Removed)) {
+		assert.Equal(t, "1.0.0", result.Removed[0].Version)
+		assert.Equal(t, 2, result.NumFiles, "files only used by the removed version")
+	}
+
+	usage, err = cache.List()
+	if assert.Nil(t, err) && assert.Equal(t, 1, len(usage.Cookbooks)) {
+		assert.Equal(t, "1.1.0", usage.Cookbooks[0].Version)
+		assert.Equal(t, 3, usage.NumFiles)
+	}
+
+	// memoized cookstyle results expire as well
+	resultPath := filepath.Join(cache.CookstyleDir(), "ab", "abcd.json")
+	assert.Nil(t, os.MkdirAll(filepath.Dir(resultPath), os.ModePerm))
+	assert.Nil(t, ioutil.WriteFile(resultPath, []byte("{}"), 0644))
+
+	// everything is older than a nanosecond
+	result, err = cache.Prune(time.Nanosecond, 0)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 1, len(result.Removed))
+		assert.Equal(t, 3, result.NumFiles)
+		assert.Equal(t, 1, result.NumResults)
+	}
+	_, err = os.Stat(resultPath)
+	assert.True(t, os.IsNotExist(err), "expired cookstyle result should be removed")
+}
+
+func TestCookbookCacheClean(t *testing.T) {
+	cache, mock, cleanup := newCacheFixture(t)
+	defer cleanup()
+
+	_, err := cache.Fetch("foo", "1.0.0")
+	assert.Nil(t, err)
+	assert.Nil(t, cache.Clean())
+
+	usage, err := cache.List()
+	if assert.Nil(t, err) {
+		assert.Empty(t, usage.Cookbooks)
+		assert.Equal(t, 0, usage.NumFiles)
+	}
+
+	// everything is downloaded again
+	_, err = cache.Fetch("foo", "1.0.0")
+	assert.Nil(t, err)
+	assert.Equal(t, 6, mock.downloadedFiles)
+}
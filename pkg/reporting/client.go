@@ -25,8 +25,46 @@ import chef "github.com/chef/go-chef"
 type CookbookInterface interface {
 	ListAvailableVersions(numVersions string) (chef.CookbookListResult, error)
 	DownloadTo(name, version, localDir string) error
+	GetVersion(name, version string) (chef.Cookbook, error)
+	ListAllRecipes() (chef.CookbookRecipesResult, error)
 }
 
+type DataBagInterface interface {
+	List() (*chef.DataBagListResult, error)
+	ListItems(name string) (*chef.DataBagListResult, error)
+	GetItem(databagName, databagItem string) (chef.DataBagItem, error)
+}
+
+type EnvironmentInterface interface {
+	List() (*chef.EnvironmentResult, error)
+	Get(name string) (*chef.Environment, error)
+}
+
+type NodeInterface interface {
+	Get(name string) (chef.Node, error)
+}
+
+// NOTE: go-chef doesn't expose the /policies and /policy_groups endpoints,
+// use NewChefPolicies(client) to get an implementation of this interface
+type PolicyInterface interface {
+	ListPolicyGroups() (PolicyGroupsResult, error)
+	ListPolicies() (PoliciesResult, error)
+	GetPolicyRevision(name, revisionID string) (PolicyLock, error)
+}
+
+type RoleInterface interface {
+	List() (*chef.RoleListResult, error)
+	Get(name string) (*chef.Role, error)
+}
+
+// NOTE: go-chef doesn't expose the env_run_lists of a role, use
+// NewChefRoleRunLists(client) to get an implementation of this interface
+type RoleRunListsInterface interface {
+	GetRunLists(name string) (RoleRunLists, error)
+}
+
+// NOTE: the chef.Client.Search service doesn't allow us to paginate partial
+// searches, use NewChefSearch(client) to get an implementation of this interface
 type SearchInterface interface {
-	PartialExec(idx, statement string, params map[string]interface{}) (res chef.SearchResult, err error)
+	PartialExecPage(idx, statement string, params map[string]interface{}, start, rows int) (res chef.SearchResult, err error)
 }
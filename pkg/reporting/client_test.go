@@ -17,9 +17,23 @@
 package reporting_test
 
 import (
+	"crypto/md5"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	chef "github.com/chef/go-chef"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
 )
 
 // This contains mocks implementations for the client interfaces
@@ -30,14 +44,51 @@ type SearchMock struct {
 	desiredError   error
 }
 
-func (sm SearchMock) PartialExec(idx, statement string, params map[string]interface{}) (res chef.SearchResult, err error) {
+func (sm SearchMock) PartialExecPage(idx, statement string, params map[string]interface{}, start, rows int) (res chef.SearchResult, err error) {
 	return sm.desiredResults, sm.desiredError
 }
 
+// a search mock that paginates the desired rows the same way the Chef Infra Server does,
+// it also allows us to emulate rows that the requestor doesn't have permissions to view
+type PaginatedSearchMock struct {
+	desiredRows     []interface{}
+	hiddenRows      int
+	desiredError    error
+	errorOnPage     int
+	requestedStarts []int
+	// the last search statement requested
+	requestedStatement string
+}
+
+func (psm *PaginatedSearchMock) PartialExecPage(idx, statement string, params map[string]interface{}, start, rows int) (res chef.SearchResult, err error) {
+	psm.requestedStarts = append(psm.requestedStarts, start)
+	psm.requestedStatement = statement
+	if psm.desiredError != nil && len(psm.requestedStarts) == psm.errorOnPage {
+		return res, psm.desiredError
+	}
+
+	res.Start = start
+	res.Total = len(psm.desiredRows) + psm.hiddenRows
+	if start >= len(psm.desiredRows) {
+		return res, nil
+	}
+
+	end := start + rows
+	if end > len(psm.desiredRows) {
+		end = len(psm.desiredRows)
+	}
+	res.Rows = psm.desiredRows[start:end]
+	return res, nil
+}
+
 type CookbookMock struct {
 	desiredCookbookList      chef.CookbookListResult
 	desiredCookbookListError error
 	desiredDownloadError     error
+	desiredCookbook          chef.Cookbook
+	desiredGetVersionError   error
+	desiredRecipes           chef.CookbookRecipesResult
+	desiredRecipesError      error
 }
 
 func (cm CookbookMock) ListAvailableVersions(limit string) (chef.CookbookListResult, error) {
@@ -54,6 +105,192 @@ func (cm CookbookMock) DownloadTo(name, version, localDir string) error {
 	return cm.desiredDownloadError
 }
 
+func (cm CookbookMock) GetVersion(name, version string) (chef.Cookbook, error) {
+	return cm.desiredCookbook, cm.desiredGetVersionError
+}
+
+func (cm CookbookMock) ListAllRecipes() (chef.CookbookRecipesResult, error) {
+	return cm.desiredRecipes, cm.desiredRecipesError
+}
+
+// CookbookFilesMock serves cookbook versions from memory and, like the go-chef
+// library, DownloadTo only writes the files that don't have the right checksum
+type CookbookFilesMock struct {
+	CookbookMock
+	// cookbook name-version -> relative path -> content
+	desiredFiles map[string]map[string]string
+	// number of files written by DownloadTo
+	downloadedFiles int
+}
+
+func (cm *CookbookFilesMock) GetVersion(name, version string) (chef.Cookbook, error) {
+	cookbook := chef.Cookbook{CookbookName: name, Name: name + "-" + version, Version: version}
+	files, ok := cm.desiredFiles[name+"-"+version]
+	if !ok {
+		return cookbook, errors.New("404 not found")
+	}
+
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		sum := md5.Sum([]byte(files[p]))
+		item := chef.CookbookItem{Name: filepath.Base(p), Path: p, Checksum: hex.EncodeToString(sum[:])}
+		if strings.HasPrefix(p, "recipes/") {
+			cookbook.Recipes = append(cookbook.Recipes, item)
+		} else {
+			cookbook.RootFiles = append(cookbook.RootFiles, item)
+		}
+	}
+	return cookbook, nil
+}
+
+func (cm *CookbookFilesMock) DownloadTo(name, version, localDir string) error {
+	files, ok := cm.desiredFiles[name+"-"+version]
+	if !ok {
+		return fmt.Errorf("cookbook %s-%s not found", name, version)
+	}
+
+	for p, content := range files {
+		target := filepath.Join(localDir, name+"-"+version, p)
+		if current, err := ioutil.ReadFile(target); err == nil && string(current) == content {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(target, []byte(content), 0644); err != nil {
+			return err
+		}
+		cm.downloadedFiles++
+	}
+	return nil
+}
+
+type DataBagMock struct {
+	// data bag name -> item name -> item JSON
+	desiredItems          map[string]map[string]string
+	desiredListError      error
+	desiredListItemsError error
+	desiredGetItemError   error
+}
+
+func (dbm DataBagMock) List() (*chef.DataBagListResult, error) {
+	if dbm.desiredListError != nil {
+		return nil, dbm.desiredListError
+	}
+	list := chef.DataBagListResult{}
+	for name := range dbm.desiredItems {
+		list[name] = "https://chef.example.com/data/" + name
+	}
+	return &list, nil
+}
+
+func (dbm DataBagMock) ListItems(name string) (*chef.DataBagListResult, error) {
+	if dbm.desiredListItemsError != nil {
+		return nil, dbm.desiredListItemsError
+	}
+	list := chef.DataBagListResult{}
+	for item := range dbm.desiredItems[name] {
+		list[item] = "https://chef.example.com/data/" + name + "/" + item
+	}
+	return &list, nil
+}
+
+func (dbm DataBagMock) GetItem(databagName, databagItem string) (chef.DataBagItem, error) {
+	if dbm.desiredGetItemError != nil {
+		return nil, dbm.desiredGetItemError
+	}
+	var item interface{}
+	err := json.Unmarshal([]byte(dbm.desiredItems[databagName][databagItem]), &item)
+	return item, err
+}
+
+type EnvironmentMock struct {
+	desiredEnvironments map[string]*chef.Environment
+	desiredListError    error
+	desiredGetError     error
+}
+
+func (em EnvironmentMock) List() (*chef.EnvironmentResult, error) {
+	if em.desiredListError != nil {
+		return nil, em.desiredListError
+	}
+	list := chef.EnvironmentResult{}
+	for name := range em.desiredEnvironments {
+		list[name] = "https://chef.example.com/environments/" + name
+	}
+	return &list, nil
+}
+
+func (em EnvironmentMock) Get(name string) (*chef.Environment, error) {
+	if em.desiredGetError != nil {
+		return nil, em.desiredGetError
+	}
+	env, ok := em.desiredEnvironments[name]
+	if !ok {
+		return nil, errors.New("404 Not Found")
+	}
+	return env, nil
+}
+
+type PolicyMock struct {
+	desiredPolicyGroups    subject.PolicyGroupsResult
+	desiredPolicies        subject.PoliciesResult
+	desiredLocks           map[string]subject.PolicyLock // key: 'name@revision'
+	desiredGroupsError     error
+	desiredPoliciesError   error
+	desiredRevisionError   error
+	requestedRevisionsKeys []string
+}
+
+func (pm *PolicyMock) ListPolicyGroups() (subject.PolicyGroupsResult, error) {
+	return pm.desiredPolicyGroups, pm.desiredGroupsError
+}
+
+func (pm *PolicyMock) ListPolicies() (subject.PoliciesResult, error) {
+	return pm.desiredPolicies, pm.desiredPoliciesError
+}
+
+func (pm *PolicyMock) GetPolicyRevision(name, revisionID string) (subject.PolicyLock, error) {
+	pm.requestedRevisionsKeys = append(pm.requestedRevisionsKeys, name+"@"+revisionID)
+	if pm.desiredRevisionError != nil {
+		return subject.PolicyLock{}, pm.desiredRevisionError
+	}
+	return pm.desiredLocks[name+"@"+revisionID], nil
+}
+
+type RoleMock struct {
+	desiredRoles     map[string]*chef.Role
+	desiredListError error
+	desiredGetError  error
+}
+
+func (rm RoleMock) List() (*chef.RoleListResult, error) {
+	if rm.desiredListError != nil {
+		return nil, rm.desiredListError
+	}
+	list := chef.RoleListResult{}
+	for name := range rm.desiredRoles {
+		list[name] = "https://chef.example.com/roles/" + name
+	}
+	return &list, nil
+}
+
+func (rm RoleMock) Get(name string) (*chef.Role, error) {
+	if rm.desiredGetError != nil {
+		return nil, rm.desiredGetError
+	}
+	role, ok := rm.desiredRoles[name]
+	if !ok {
+		return nil, errors.New("404 Not Found")
+	}
+	return role, nil
+}
+
 func newMockCookbook(cookbookList chef.CookbookListResult, desiredCbListErr, desiredDownloadErr error) *CookbookMock {
 	return &CookbookMock{
 		desiredCookbookList:      cookbookList,
@@ -63,6 +300,20 @@ func newMockCookbook(cookbookList chef.CookbookListResult, desiredCbListErr, des
 }
 
 func makeMockSearch(searchResultJSON string, desiredError error) *SearchMock {
+	return &SearchMock{
+		desiredResults: chef.SearchResult{Rows: convertSearchRows(searchResultJSON)},
+		desiredError:   desiredError,
+	}
+}
+
+func makeMockPaginatedSearch(searchResultJSON string, hiddenRows int) *PaginatedSearchMock {
+	return &PaginatedSearchMock{
+		desiredRows: convertSearchRows(searchResultJSON),
+		hiddenRows:  hiddenRows,
+	}
+}
+
+func convertSearchRows(searchResultJSON string) []interface{} {
 	var convertedSearchResult []interface{}
 
 	if searchResultJSON != "" {
@@ -77,8 +328,62 @@ func makeMockSearch(searchResultJSON string, desiredError error) *SearchMock {
 		}
 	}
 
-	return &SearchMock{
-		desiredResults: chef.SearchResult{Rows: convertedSearchResult},
-		desiredError:   desiredError,
+	return convertedSearchResult
+}
+
+type NodeMock struct {
+	desiredNodes map[string]chef.Node
+}
+
+func (nm NodeMock) Get(name string) (chef.Node, error) {
+	node, ok := nm.desiredNodes[name]
+	if !ok {
+		return node, notFoundError("nodes/" + name)
 	}
+	return node, nil
+}
+
+type RoleRunListsMock struct {
+	desiredRunLists map[string]subject.RoleRunLists
+	desiredGetError error
+}
+
+func (rm RoleRunListsMock) GetRunLists(name string) (subject.RoleRunLists, error) {
+	if rm.desiredGetError != nil {
+		return subject.RoleRunLists{}, rm.desiredGetError
+	}
+	runLists, ok := rm.desiredRunLists[name]
+	if !ok {
+		return runLists, notFoundError("roles/" + name)
+	}
+	return runLists, nil
+}
+
+// CookbookMetadataMock serves the dependencies of every cookbook version
+type CookbookMetadataMock struct {
+	CookbookMock
+	// cookbook name@version -> dependency -> version constraint
+	desiredDepends map[string]map[string]string
+	// cookbook versions requested
+	requested      []string
+	requestedMutex sync.Mutex
+}
+
+func (cm *CookbookMetadataMock) GetVersion(name, version string) (chef.Cookbook, error) {
+	cm.requestedMutex.Lock()
+	cm.requested = append(cm.requested, name+"@"+version)
+	cm.requestedMutex.Unlock()
+	depends, ok := cm.desiredDepends[name+"@"+version]
+	if !ok {
+		return chef.Cookbook{}, notFoundError("cookbooks/" + name + "/" + version)
+	}
+	return chef.Cookbook{Name: name, Version: version, Metadata: chef.CookbookMeta{Depends: depends}}, nil
+}
+
+// returns the error that go-chef returns when the Chef Infra Server responds with a 404
+func notFoundError(path string) error {
+	return &chef.ErrorResponse{Response: &http.Response{
+		StatusCode: http.StatusNotFound,
+		Request:    httptest.NewRequest("GET", "/organizations/acme/"+path, nil),
+	}}
 }
@@ -0,0 +1,271 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// support status of a release
+const (
+	SupportStatusSupported = "supported"
+	SupportStatusNearEOL   = "near-eol"
+	SupportStatusEOL       = "eol"
+	SupportStatusUnknown   = "unknown"
+)
+
+// ChefClientRelease is a major release of the Chef Infra Client and its end-of-life date
+type ChefClientRelease struct {
+	Major int `json:"major"`
+	// date the release stops being supported (YYYY-MM-DD), empty if not announced
+	EOL string `json:"eol,omitempty"`
+}
+
+// ChefClientReleases is the support lifecycle of the Chef Infra Client, releases
+// older than the oldest one listed are end-of-life, it can be extended or
+// overridden with a local file, see LoadChefClientReleases()
+//
+// NOTE: update this table when a new major release ships or an EOL date is
+// announced, see https://docs.chef.io/versions/
+var ChefClientReleases = []ChefClientRelease{
+	{Major: 12, EOL: "2018-04-30"},
+	{Major: 13, EOL: "2019-04-30"},
+	{Major: 14, EOL: "2020-04-30"},
+	{Major: 15, EOL: "2021-04-30"},
+	{Major: 16, EOL: "2022-11-30"},
+	{Major: 17, EOL: "2023-10-31"},
+	{Major: 18},
+}
+
+// LoadChefClientReleases reads a JSON list of Chef Infra Client major releases from
+// the provided file and merges it with the embedded table, entries of the file
+// override the embedded ones with the same major version
+//
+// example:
+//
+//	[
+//	  {"major": 18, "eol": "2025-11-30"},
+//	  {"major": 19}
+//	]
+func LoadChefClientReleases(path string) ([]ChefClientRelease, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read Chef Infra Client end-of-life data '%s'", path)
+	}
+
+	var overrides []ChefClientRelease
+	if err := json.Unmarshal(content, &overrides); err != nil {
+		return nil, errors.Wrapf(err, "malformed Chef Infra Client end-of-life data '%s'", path)
+	}
+
+	for _, release := range overrides {
+		if release.Major <= 0 {
+			return nil, errors.Errorf(
+				"malformed Chef Infra Client end-of-life data '%s': every release requires a major version", path,
+			)
+		}
+		if release.EOL == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", release.EOL); err != nil {
+			return nil, errors.Errorf(
+				"malformed Chef Infra Client end-of-life data '%s': invalid EOL date '%s' of release %d (expected YYYY-MM-DD)",
+				path, release.EOL, release.Major,
+			)
+		}
+	}
+
+	return MergeChefClientReleases(ChefClientReleases, overrides), nil
+}
+
+// returns the provided releases with the overrides applied on top of them
+func MergeChefClientReleases(releases, overrides []ChefClientRelease) []ChefClientRelease {
+	merged := make([]ChefClientRelease, 0, len(releases)+len(overrides))
+	for _, release := range releases {
+		overridden := false
+		for _, o := range overrides {
+			if o.Major == release.Major {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged = append(merged, release)
+		}
+	}
+	return append(merged, overrides...)
+}
+
+// ClientVersionsStatus groups the nodes by the major.minor version of the Chef
+// Infra Client they run
+type ClientVersionsStatus struct {
+	Records    []*ClientVersionRecord
+	TotalNodes int
+}
+
+type ClientVersionRecord struct {
+	// major.minor version, empty for nodes that never reported it
+	Version string
+	// support status of the major release (supported, eol or unknown)
+	Status string
+	// end-of-life date of the major release, empty if not announced
+	EOL          string
+	Nodes        []string
+	OhaiVersions []string
+	RubyVersions []string
+	// used to sort the records, the latest version first
+	version *Version
+}
+
+func (cvr *ClientVersionRecord) NumNodes() int {
+	return len(cvr.Nodes)
+}
+
+// returns true if the release is no longer supported
+func (cvr *ClientVersionRecord) Unsupported() bool {
+	return cvr.Status == SupportStatusEOL
+}
+
+// returns the percentage of the fleet running this version
+func (cvs *ClientVersionsStatus) Percentage(record *ClientVersionRecord) float64 {
+	if cvs.TotalNodes == 0 {
+		return 0
+	}
+	return float64(record.NumNodes()) * 100 / float64(cvs.TotalNodes)
+}
+
+// returns the nodes running a release that is no longer supported
+func (cvs *ClientVersionsStatus) UnsupportedNodes() []string {
+	nodes := make([]string, 0)
+	for _, record := range cvs.Records {
+		if record.Unsupported() {
+			nodes = append(nodes, record.Nodes...)
+		}
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// NewClientVersions groups the nodes by Chef Infra Client version and looks up the
+// support status of every version in the provided releases at the provided time
+func NewClientVersions(records []*NodeReportItem, releases []ChefClientRelease, now time.Time) *ClientVersionsStatus {
+	var (
+		status = &ClientVersionsStatus{Records: make([]*ClientVersionRecord, 0), TotalNodes: len(records)}
+		groups = map[string]*ClientVersionRecord{}
+		ohai   = map[string]map[string]bool{}
+		ruby   = map[string]map[string]bool{}
+	)
+
+	for _, node := range records {
+		key := node.ChefVersion
+		var version *Version
+		if v, err := ParseVersion(node.ChefVersion); err == nil {
+			version = &v
+			key = fmt.Sprintf("%d.%d", v.Major, v.Minor)
+		}
+
+		record, ok := groups[key]
+		if !ok {
+			record = &ClientVersionRecord{Version: key, Status: SupportStatusUnknown, Nodes: []string{}, version: version}
+			if version != nil {
+				record.Status, record.EOL = chefClientSupport(version.Major, releases, now)
+			}
+			groups[key] = record
+			ohai[key] = map[string]bool{}
+			ruby[key] = map[string]bool{}
+			status.Records = append(status.Records, record)
+		}
+
+		record.Nodes = append(record.Nodes, node.Name)
+		if node.OhaiVersion != "" {
+			ohai[key][node.OhaiVersion] = true
+		}
+		if node.RubyVersion != "" {
+			ruby[key][node.RubyVersion] = true
+		}
+	}
+
+	for key, record := range groups {
+		sort.Strings(record.Nodes)
+		record.OhaiVersions = sortedKeys(ohai[key])
+		record.RubyVersions = sortedKeys(ruby[key])
+	}
+
+	// the latest version first, versions we couldn't parse at the end
+	sort.Slice(status.Records, func(i, j int) bool {
+		a, b := status.Records[i], status.Records[j]
+		switch {
+		case a.version != nil && b.version != nil:
+			return a.version.Compare(*b.version) > 0
+		case a.version != nil || b.version != nil:
+			return a.version != nil
+		default:
+			return a.Version < b.Version
+		}
+	})
+
+	return status
+}
+
+// returns the support status and the end-of-life date of a major release
+func chefClientSupport(major int, releases []ChefClientRelease, now time.Time) (string, string) {
+	oldest := -1
+	for _, release := range releases {
+		if oldest == -1 || release.Major < oldest {
+			oldest = release.Major
+		}
+		if release.Major != major {
+			continue
+		}
+
+		return supportStatus(release.EOL, 0, now), release.EOL
+	}
+
+	if oldest != -1 && major < oldest {
+		return SupportStatusEOL, ""
+	}
+	return SupportStatusUnknown, ""
+}
+
+// returns the support status of a release at the provided time given its end-of-life
+// date (YYYY-MM-DD), releases reaching their EOL within the nearEOL period are near-eol
+func supportStatus(eol string, nearEOL time.Duration, now time.Time) string {
+	if eol == "" {
+		return SupportStatusSupported
+	}
+	date, err := time.Parse("2006-01-02", eol)
+	if err != nil {
+		return SupportStatusUnknown
+	}
+
+	// a release is supported until the end of its EOL day
+	end := date.AddDate(0, 0, 1)
+	switch {
+	case !now.Before(end):
+		return SupportStatusEOL
+	case nearEOL > 0 && !now.Add(nearEOL).Before(end):
+		return SupportStatusNearEOL
+	default:
+		return SupportStatusSupported
+	}
+}
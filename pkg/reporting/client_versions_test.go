@@ -0,0 +1,162 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func TestNewClientVersions(t *testing.T) {
+	var (
+		now      = time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+		releases = []subject.ChefClientRelease{
+			{Major: 13, EOL: "2019-04-30"},
+			{Major: 14, EOL: "2020-04-30"},
+			{Major: 15, EOL: "2021-04-30"},
+			{Major: 16},
+		}
+		records = []*subject.NodeReportItem{
+			&subject.NodeReportItem{Name: "web02", ChefVersion: "15.4.45", OhaiVersion: "15.3.1", RubyVersion: "2.6.5"},
+			&subject.NodeReportItem{Name: "web01", ChefVersion: "15.4.2", OhaiVersion: "15.3.1", RubyVersion: "2.6.3"},
+			&subject.NodeReportItem{Name: "db01", ChefVersion: "14.12.9", OhaiVersion: "14.8.11"},
+			&subject.NodeReportItem{Name: "legacy", ChefVersion: "11.18.12"},
+			&subject.NodeReportItem{Name: "edge", ChefVersion: "16.1.0"},
+			&subject.NodeReportItem{Name: "new"},
+		}
+	)
+
+	status := subject.NewClientVersions(records, releases, now)
+	assert.Equal(t, 6, status.TotalNodes)
+
+	if assert.Equal(t, 5, len(status.Records)) {
+		assert.Equal(t, "16.1", status.Records[0].Version, "the latest version should be first")
+		assert.Equal(t, subject.SupportStatusSupported, status.Records[0].Status)
+		assert.Equal(t, "", status.Records[0].EOL)
+
+		assert.Equal(t, "15.4", status.Records[1].Version)
+		assert.Equal(t, subject.SupportStatusSupported, status.Records[1].Status)
+		assert.Equal(t, "2021-04-30", status.Records[1].EOL)
+		assert.Equal(t, []string{"web01", "web02"}, status.Records[1].Nodes)
+		assert.Equal(t, []string{"15.3.1"}, status.Records[1].OhaiVersions)
+		assert.Equal(t, []string{"2.6.3", "2.6.5"}, status.Records[1].RubyVersions)
+		assert.InDelta(t, 33.3, status.Percentage(status.Records[1]), 0.1)
+
+		assert.Equal(t, "14.12", status.Records[2].Version)
+		assert.Equal(t, subject.SupportStatusEOL, status.Records[2].Status)
+		assert.Equal(t, []string{}, status.Records[2].RubyVersions)
+
+		assert.Equal(t, "11.18", status.Records[3].Version)
+		assert.Equal(t, subject.SupportStatusEOL, status.Records[3].Status,
+			"releases older than the support table are end-of-life")
+
+		assert.Equal(t, "", status.Records[4].Version, "nodes without a version should be last")
+		assert.Equal(t, subject.SupportStatusUnknown, status.Records[4].Status)
+	}
+
+	assert.Equal(t, []string{"db01", "legacy"}, status.UnsupportedNodes())
+}
+
+func TestNewClientVersionsEOLDay(t *testing.T) {
+	var (
+		releases = []subject.ChefClientRelease{{Major: 15, EOL: "2021-04-30"}}
+		records  = []*subject.NodeReportItem{&subject.NodeReportItem{Name: "web01", ChefVersion: "15.10.12"}}
+	)
+
+	status := subject.NewClientVersions(records, releases, time.Date(2021, 4, 30, 23, 0, 0, 0, time.UTC))
+	assert.Equal(t, subject.SupportStatusSupported, status.Records[0].Status,
+		"a release is supported until the end of its EOL day")
+
+	status = subject.NewClientVersions(records, releases, time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, subject.SupportStatusEOL, status.Records[0].Status)
+}
+
+func TestNewClientVersionsEmpty(t *testing.T) {
+	status := subject.NewClientVersions(nil, subject.ChefClientReleases, time.Now())
+	assert.Equal(t, 0, status.TotalNodes)
+	assert.Empty(t, status.Records)
+	assert.Empty(t, status.UnsupportedNodes())
+	assert.Equal(t, float64(0), status.Percentage(&subject.ClientVersionRecord{}))
+}
+
+func TestMergeChefClientReleases(t *testing.T) {
+	merged := subject.MergeChefClientReleases(
+		[]subject.ChefClientRelease{{Major: 17, EOL: "2023-10-31"}, {Major: 18}},
+		[]subject.ChefClientRelease{{Major: 18, EOL: "2025-11-30"}, {Major: 19}},
+	)
+	assert.Equal(t, []subject.ChefClientRelease{
+		{Major: 17, EOL: "2023-10-31"},
+		{Major: 18, EOL: "2025-11-30"},
+		{Major: 19},
+	}, merged)
+}
+
+func TestLoadChefClientReleases(t *testing.T) {
+	dir, err := ioutil.TempDir("", "client-releases")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "client-eol.json")
+	err = ioutil.WriteFile(path, []byte(`[{"major": 18, "eol": "2025-11-30"}, {"major": 19}]`), 0644)
+	if assert.Nil(t, err) {
+		releases, err := subject.LoadChefClientReleases(path)
+		assert.Nil(t, err)
+		assert.Equal(t, len(subject.ChefClientReleases)+1, len(releases))
+		assert.Contains(t, releases, subject.ChefClientRelease{Major: 18, EOL: "2025-11-30"})
+		assert.Contains(t, releases, subject.ChefClientRelease{Major: 19})
+
+		// the overridden EOL date is used
+		report := subject.NewClientVersions(
+			[]*subject.NodeReportItem{&subject.NodeReportItem{Name: "node1", ChefVersion: "18.2.7"}},
+			releases,
+			time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		)
+		if assert.Equal(t, 1, len(report.Records)) {
+			assert.Equal(t, subject.SupportStatusEOL, report.Records[0].Status)
+		}
+	}
+
+	_, err = subject.LoadChefClientReleases(filepath.Join(dir, "missing.json"))
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "unable to read Chef Infra Client end-of-life data")
+	}
+
+	err = ioutil.WriteFile(path, []byte(`[{"eol": "2025-11-30"}]`), 0644)
+	if assert.Nil(t, err) {
+		_, err = subject.LoadChefClientReleases(path)
+		if assert.NotNil(t, err) {
+			assert.Contains(t, err.Error(), "every release requires a major version")
+		}
+	}
+
+	err = ioutil.WriteFile(path, []byte(`[{"major": 18, "eol": "Nov 2025"}]`), 0644)
+	if assert.Nil(t, err) {
+		_, err = subject.LoadChefClientReleases(path)
+		if assert.NotNil(t, err) {
+			assert.Contains(t, err.Error(), "invalid EOL date 'Nov 2025' of release 18 (expected YYYY-MM-DD)")
+		}
+	}
+}
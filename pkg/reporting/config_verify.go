@@ -0,0 +1,309 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	chef "github.com/chef/go-chef"
+	"github.com/chef/go-libs/credentials"
+	"github.com/pkg/errors"
+)
+
+// ConfigCheck is a single verification of the local Chef configuration
+type ConfigCheck struct {
+	Description string
+	Error       error
+	// an actionable message that helps the user fix a failed check
+	Hint string
+	// checks are skipped when a previous check failed or when there is
+	// nothing to verify, skipped checks do not fail the verification
+	Skipped bool
+}
+
+func (c *ConfigCheck) Passed() bool {
+	return !c.Skipped && c.Error == nil
+}
+
+func (c *ConfigCheck) Failed() bool {
+	return c.Error != nil
+}
+
+// ConfigStatus is the result of verifying a credentials profile
+type ConfigStatus struct {
+	Profile       string
+	ChefServerURL string
+	ClientName    string
+	Checks        []*ConfigCheck
+}
+
+// returns true if none of the checks failed
+func (cs *ConfigStatus) Passed() bool {
+	return cs.NumFailed() == 0
+}
+
+func (cs *ConfigStatus) NumFailed() int {
+	failed := 0
+	for _, check := range cs.Checks {
+		if check.Failed() {
+			failed++
+		}
+	}
+	return failed
+}
+
+// verifies that the credentials returned by the provided function can be used
+// to generate reports, that is:
+//
+//  1. the credentials profile can be loaded
+//  2. the client key is a valid RSA private key
+//  3. the Chef Infra Server is reachable
+//  4. the server accepts requests signed with the client key
+//  5. the organization exists
+//  6. the client has permissions to search nodes
+//  7. the client has permissions to list cookbooks
+//  8. the client has permissions to download cookbooks
+//
+// every check depends on the previous one, once a check fails the rest are skipped
+func VerifyConfig(loadCredentials func() (credentials.Credentials, error), noSSLVerify bool) *ConfigStatus {
+	var (
+		status   = &ConfigStatus{}
+		verifier = configVerifier{status: status}
+	)
+
+	creds, err := loadCredentials()
+	status.Profile = creds.ActiveProfile()
+	status.ChefServerURL = creds.ChefServerUrl
+	status.ClientName = creds.ClientName
+	verifier.check("Credentials profile loaded", func() (string, error) {
+		if err != nil {
+			return "run 'chef-analyze config init' to create a profile or select an existing one with --profile",
+				errors.New(strings.TrimSpace(err.Error()))
+		}
+		return "", nil
+	})
+
+	verifier.check("Client key is a valid RSA private key", func() (string, error) {
+		return fmt.Sprintf("client_key must point to the private key of the API client '%s' in PEM format", creds.ClientName),
+			ValidateClientKey(creds.ClientKey)
+	})
+
+	var (
+		client     *chef.Client
+		statusCode int
+	)
+	// a single request to the default environment, that exists in every
+	// organization, tells us if the server is reachable, if it accepts our
+	// signed requests and if the organization exists
+	verifier.check("Chef Infra Server is reachable", func() (string, error) {
+		client, err = NewChefClient(&Reporting{Credentials: creds, NoSSLVerify: noSSLVerify})
+		if err != nil {
+			return fmt.Sprintf("verify the chef_server_url '%s'", creds.ChefServerUrl), err
+		}
+
+		statusCode, err = probe(client, "environments/_default")
+		if err != nil && statusCode == 0 {
+			if strings.Contains(err.Error(), "x509") || strings.Contains(err.Error(), "certificate") {
+				return "the SSL certificate could not be verified, fetch it with 'knife ssl fetch' or use --ssl-no-verify", err
+			}
+			return fmt.Sprintf("verify the chef_server_url '%s' and your network connectivity", creds.ChefServerUrl), err
+		}
+		switch {
+		case statusCode >= 200 && statusCode < 300:
+			return "", nil
+		case statusCode == http.StatusUnauthorized || statusCode == http.StatusNotFound:
+			// handled by the checks below
+			return "", nil
+		}
+
+		if err == nil {
+			err = errors.Errorf("unexpected response from the Chef Infra Server")
+		}
+		err = errors.Wrapf(err, "HTTP %d %s", statusCode, http.StatusText(statusCode))
+		if statusCode >= 500 {
+			return "the Chef Infra Server is not healthy, verify its status with 'chef-server-ctl status'", err
+		}
+		return fmt.Sprintf("verify the chef_server_url '%s' points to a Chef Infra Server", creds.ChefServerUrl), err
+	})
+
+	verifier.check("Signed requests are accepted", func() (string, error) {
+		if statusCode == http.StatusUnauthorized {
+			return fmt.Sprintf(
+				"the client key doesn't belong to the client '%s' or the clock of this system is out of sync",
+				creds.ClientName,
+			), err
+		}
+		return "", nil
+	})
+
+	org := organizationFromURL(creds.ChefServerUrl)
+	verifier.check(fmt.Sprintf("Organization '%s' exists", org), func() (string, error) {
+		if statusCode == http.StatusNotFound {
+			return "verify the organization name at the end of the chef_server_url", err
+		}
+		return "", nil
+	})
+
+	verifier.check("Permission to search nodes", func() (string, error) {
+		_, err := NewChefSearch(client).PartialExecPage("node", "*:*",
+			map[string]interface{}{"name": []string{"name"}}, 0, 1,
+		)
+		return permissionHint(err, creds.ClientName, "nodes"), err
+	})
+
+	var cookbooks chef.CookbookListResult
+	verifier.check("Permission to list cookbooks", func() (string, error) {
+		cookbooks, err = client.Cookbooks.ListAvailableVersions("1")
+		return permissionHint(err, creds.ClientName, "cookbooks"), err
+	})
+
+	verifier.check("Permission to download cookbooks", func() (string, error) {
+		name, version := firstCookbookVersion(cookbooks)
+		if name == "" {
+			return "there are no cookbooks to download", errSkipCheck
+		}
+
+		err := probeCookbookDownload(client, name, version)
+		return permissionHint(err, creds.ClientName, "cookbooks"), err
+	})
+
+	return status
+}
+
+// returned by a check when there is nothing to verify
+var errSkipCheck = errors.New("nothing to verify")
+
+// runs the checks in order and skips them once one has failed
+type configVerifier struct {
+	status *ConfigStatus
+	failed bool
+}
+
+func (cv *configVerifier) check(description string, fn func() (string, error)) {
+	check := &ConfigCheck{Description: description}
+	cv.status.Checks = append(cv.status.Checks, check)
+
+	if cv.failed {
+		check.Skipped = true
+		return
+	}
+
+	hint, err := fn()
+	switch err {
+	case nil:
+	case errSkipCheck:
+		check.Skipped = true
+		check.Hint = hint
+	default:
+		check.Error = err
+		check.Hint = hint
+		cv.failed = true
+	}
+}
+
+// sends a signed GET request and returns the status code of the response, if any
+func probe(client *chef.Client, path string) (int, error) {
+	req, err := client.NewRequest("GET", path, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := client.Do(req, nil)
+	if res != nil {
+		defer res.Body.Close()
+		// drain the body so the connection can be reused
+		io.Copy(ioutil.Discard, res.Body)
+		return res.StatusCode, err
+	}
+	return 0, err
+}
+
+// downloads the first file of the provided cookbook version, that is enough
+// to verify that we can reach the storage of the cookbook files
+func probeCookbookDownload(client *chef.Client, name, version string) error {
+	cookbook, err := client.Cookbooks.GetVersion(name, version)
+	if err != nil {
+		return err
+	}
+
+	for _, items := range [][]chef.CookbookItem{
+		cookbook.RootFiles, cookbook.Recipes, cookbook.Attributes, cookbook.Files,
+		cookbook.Templates, cookbook.Libraries, cookbook.Resources, cookbook.Providers,
+		cookbook.Definitions,
+	} {
+		if len(items) == 0 {
+			continue
+		}
+		_, err := probe(client, items[0].Url)
+		return errors.Wrapf(err, "unable to download cookbook file '%s'", items[0].Path)
+	}
+
+	return nil
+}
+
+// returns the name and version of the first cookbook, sorted by name
+func firstCookbookVersion(cookbooks chef.CookbookListResult) (string, string) {
+	names := make([]string, 0, len(cookbooks))
+	for name, versions := range cookbooks {
+		if len(versions.Versions) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "", ""
+	}
+
+	sort.Strings(names)
+	return names[0], cookbooks[names[0]].Versions[0].Version
+}
+
+// returns a hint to fix a failed request to the provided container
+func permissionHint(err error, client, container string) string {
+	if err == nil {
+		return ""
+	}
+	if chefErr, ok := errors.Cause(err).(*chef.ErrorResponse); ok && chefErr.Response.StatusCode == http.StatusForbidden {
+		return fmt.Sprintf(
+			"the client '%s' needs read access to %s, grant it with 'knife acl add client %s containers %s read'",
+			client, container, client, container,
+		)
+	}
+	return "verify the logs of the Chef Infra Server for more details"
+}
+
+// returns the organization name from a chef_server_url like
+// https://chef.example.com/organizations/my-org
+func organizationFromURL(serverURL string) string {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return ""
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i, part := range parts {
+		if part == "organizations" && i+1 < len(parts) {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,278 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chef/go-libs/credentials"
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+// a fake Chef Infra Server that responds to the requests of the config
+// verification, the provided status codes override the responses by path
+func newConfigVerifyServer(statusCodes map[string]int) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if code, ok := statusCodes[r.URL.Path]; ok {
+			w.WriteHeader(code)
+			fmt.Fprintln(w, `{"error": ["oops"]}`)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/organizations/bubu/environments/_default":
+			fmt.Fprintln(w, `{"name": "_default"}`)
+		case "/organizations/bubu/search/node":
+			fmt.Fprintln(w, `{"total": 0, "start": 0, "rows": []}`)
+		case "/organizations/bubu/cookbooks":
+			fmt.Fprintln(w, `{"foo": {"versions": [{"version": "1.0.0"}]}}`)
+		case "/organizations/bubu/cookbooks/foo/1.0.0":
+			fmt.Fprintf(w, `{"name": "foo-1.0.0", "version": "1.0.0",
+  "root_files": [{"name": "metadata.rb", "path": "metadata.rb", "url": "%s/bookshelf/metadata.rb"}]}`,
+				server.URL)
+		case "/bookshelf/metadata.rb":
+			fmt.Fprintln(w, `name 'foo'`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, `{"error": ["not found"]}`)
+		}
+	}))
+	return server
+}
+
+func newConfigVerifyCredentials(t *testing.T, dir, serverURL string) func() (credentials.Credentials, error) {
+	keyPath := filepath.Join(dir, "foo.pem")
+	if err := ioutil.WriteFile(keyPath, key(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return func() (credentials.Credentials, error) {
+		creds := credentials.Credentials{
+			Profiles: credentials.Profiles{
+				"default": credentials.CredsDetail{
+					ChefServerUrl: serverURL + "/organizations/bubu",
+					ClientName:    "foo",
+					ClientKey:     keyPath,
+				},
+			},
+		}
+		err := creds.SwitchProfile("default")
+		return creds, err
+	}
+}
+
+func checkResults(status *subject.ConfigStatus) []string {
+	results := make([]string, 0, len(status.Checks))
+	for _, check := range status.Checks {
+		switch {
+		case check.Failed():
+			results = append(results, "fail")
+		case check.Skipped:
+			results = append(results, "skip")
+		default:
+			results = append(results, "ok")
+		}
+	}
+	return results
+}
+
+func TestVerifyConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := newConfigVerifyServer(nil)
+	defer server.Close()
+
+	status := subject.VerifyConfig(newConfigVerifyCredentials(t, dir, server.URL), false)
+	assert.True(t, status.Passed())
+	assert.Equal(t, 0, status.NumFailed())
+	assert.Equal(t, "default", status.Profile)
+	assert.Equal(t, "foo", status.ClientName)
+	assert.Equal(t,
+		[]string{"ok", "ok", "ok", "ok", "ok", "ok", "ok", "ok"},
+		checkResults(status))
+	assert.Equal(t, "Organization 'bubu' exists", status.Checks[4].Description)
+}
+
+func TestVerifyConfigFailures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		name        string
+		statusCodes map[string]int
+		expected    []string
+		hint        string
+	}{
+		{"unauthorized",
+			map[string]int{"/organizations/bubu/environments/_default": http.StatusUnauthorized},
+			[]string{"ok", "ok", "ok", "fail", "skip", "skip", "skip", "skip"},
+			"the client key doesn't belong to the client 'foo'",
+		},
+		{"missing organization",
+			map[string]int{"/organizations/bubu/environments/_default": http.StatusNotFound},
+			[]string{"ok", "ok", "ok", "ok", "fail", "skip", "skip", "skip"},
+			"verify the organization name",
+		},
+		{"server error",
+			map[string]int{"/organizations/bubu/environments/_default": http.StatusBadGateway},
+			[]string{"ok", "ok", "fail", "skip", "skip", "skip", "skip", "skip"},
+			"the Chef Infra Server is not healthy",
+		},
+		{"unexpected status",
+			map[string]int{"/organizations/bubu/environments/_default": http.StatusForbidden},
+			[]string{"ok", "ok", "fail", "skip", "skip", "skip", "skip", "skip"},
+			"points to a Chef Infra Server",
+		},
+		{"forbidden search",
+			map[string]int{"/organizations/bubu/search/node": http.StatusForbidden},
+			[]string{"ok", "ok", "ok", "ok", "ok", "fail", "skip", "skip"},
+			"knife acl add client foo containers nodes read",
+		},
+		{"forbidden cookbooks",
+			map[string]int{"/organizations/bubu/cookbooks": http.StatusForbidden},
+			[]string{"ok", "ok", "ok", "ok", "ok", "ok", "fail", "skip"},
+			"knife acl add client foo containers cookbooks read",
+		},
+		{"broken bookshelf",
+			map[string]int{"/bookshelf/metadata.rb": http.StatusInternalServerError},
+			[]string{"ok", "ok", "ok", "ok", "ok", "ok", "ok", "fail"},
+			"verify the logs of the Chef Infra Server",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server := newConfigVerifyServer(c.statusCodes)
+			defer server.Close()
+
+			status := subject.VerifyConfig(newConfigVerifyCredentials(t, dir, server.URL), false)
+			assert.False(t, status.Passed())
+			assert.Equal(t, 1, status.NumFailed())
+			assert.Equal(t, c.expected, checkResults(status))
+			for _, check := range status.Checks {
+				if check.Failed() && assert.NotNil(t, check.Error) {
+					assert.Contains(t, check.Hint, c.hint)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyConfigNoCookbooks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{}`)
+	}))
+	defer server.Close()
+
+	status := subject.VerifyConfig(newConfigVerifyCredentials(t, dir, server.URL), false)
+	assert.True(t, status.Passed(), "skipped checks should not fail the verification")
+	assert.Equal(t,
+		[]string{"ok", "ok", "ok", "ok", "ok", "ok", "ok", "skip"},
+		checkResults(status))
+	assert.Equal(t, "there are no cookbooks to download", status.Checks[7].Hint)
+}
+
+func TestVerifyConfigCredentialsError(t *testing.T) {
+	status := subject.VerifyConfig(func() (credentials.Credentials, error) {
+		return credentials.Credentials{}, errors.New("\n  credentials file not found.\n")
+	}, false)
+	assert.False(t, status.Passed())
+	assert.Equal(t,
+		[]string{"fail", "skip", "skip", "skip", "skip", "skip", "skip", "skip"},
+		checkResults(status))
+	assert.Equal(t, "credentials file not found.", status.Checks[0].Error.Error())
+	assert.Contains(t, status.Checks[0].Hint, "chef-analyze config init")
+}
+
+func TestVerifyConfigInvalidKey(t *testing.T) {
+	status := subject.VerifyConfig(func() (credentials.Credentials, error) {
+		creds := credentials.Credentials{
+			Profiles: credentials.Profiles{
+				"default": credentials.CredsDetail{ClientName: "foo", ClientKey: "/does/not/exist.pem"},
+			},
+		}
+		return creds, creds.SwitchProfile("default")
+	}, false)
+	assert.Equal(t,
+		[]string{"ok", "fail", "skip", "skip", "skip", "skip", "skip", "skip"},
+		checkResults(status))
+	assert.Contains(t, status.Checks[1].Error.Error(), "couldn't read key '/does/not/exist.pem'")
+}
+
+func TestVerifyConfigUnreachableServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// start and close a server right away to get an address nobody listens to
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	status := subject.VerifyConfig(newConfigVerifyCredentials(t, dir, server.URL), false)
+	assert.Equal(t,
+		[]string{"ok", "ok", "fail", "skip", "skip", "skip", "skip", "skip"},
+		checkResults(status))
+	assert.Contains(t, status.Checks[2].Hint, "network connectivity")
+}
+
+func TestVerifyConfigReachableShowsStatus(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for code, expected := range map[int]string{
+		http.StatusForbidden:  "HTTP 403 Forbidden",
+		http.StatusBadGateway: "HTTP 502 Bad Gateway",
+	} {
+		server := newConfigVerifyServer(map[string]int{"/organizations/bubu/environments/_default": code})
+		status := subject.VerifyConfig(newConfigVerifyCredentials(t, dir, server.URL), false)
+		server.Close()
+
+		reachable := status.Checks[2]
+		assert.Equal(t, "Chef Infra Server is reachable", reachable.Description)
+		if assert.True(t, reachable.Failed()) {
+			assert.Contains(t, reachable.Error.Error(), expected)
+		}
+	}
+}
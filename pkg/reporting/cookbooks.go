@@ -20,36 +20,48 @@ package reporting
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	chef "github.com/chef/go-chef"
 	"github.com/cheggaaa/pb/v3"
 	"github.com/pkg/errors"
 )
 
-// cached directory
-const AnalyzeCacheDir = ".analyze-cache"
-
 type CookbooksStatus struct {
 	Records        []*CookbookRecord
 	RecordsMutex   sync.Mutex
 	TotalCookbooks int
 	OnlyUnused     bool
+	AnalyzeAll     bool // analyze used and unused cookbooks, takes precedence over OnlyUnused
 	RunCookstyle   bool
+	Filters        []CookbookFilter
 	Cookbooks      CookbookInterface
 	Searcher       SearchInterface
+	Policies       PolicyInterface // when set, cookbooks locked by active policy revisions are in use
 	Cookstyle      *CookstyleRunner
-	progress       *pb.ProgressBar
+	Cache          *CookbookCache
+	Journal        *RunJournal // when set, finished records are checkpointed to resume interrupted runs
+	// when set, nodes that haven't checked in within this period don't count as using a cookbook
+	StaleAfter time.Duration
+	// errors looking up the active policy revisions, policies are additional usage
+	// information, the cookbooks used by nodes are reported regardless of them
+	PolicyErrors []error
+	progress     *pb.ProgressBar
+	usage        *CookbooksUsage
+	usageError   error
 }
 
 type CookbookRecord struct {
-	Name             string
-	Version          string
-	Files            []CookbookFile
-	Nodes            []string
-	path             string
-	DownloadError    error
-	UsageLookupError error
-	CookstyleError   error
+	Name              string
+	Version           string
+	Files             []CookbookFile
+	Nodes             []string
+	Policies          []string
+	CookstyleMetadata CookstyleMetadata
+	path              string
+	DownloadError     error
+	UsageLookupError  error
+	CookstyleError    error
 }
 
 func (cr CookbookRecord) Errors() []error {
@@ -76,6 +88,11 @@ func (r *CookbookRecord) NumNodesAffected() int {
 	return len(r.Nodes)
 }
 
+// a cookbook is in use when a node has it applied or an active policy revision locks it
+func (r *CookbookRecord) InUse() bool {
+	return len(r.Nodes) != 0 || len(r.Policies) != 0
+}
+
 func (r *CookbookRecord) NumOffenses() int {
 	i := 0
 	for _, f := range r.Files {
@@ -96,7 +113,31 @@ func (r *CookbookRecord) NumCorrectable() int {
 	return i
 }
 
-func NewCookbooks(cbi CookbookInterface, searcher SearchInterface, runCookstyle, onlyUnused bool, workers int) (*CookbooksStatus, error) {
+// override functions to override any particular setting of the cookbooks status
+// before the analysis starts
+type CookbooksOverrideFunc func(*CookbooksStatus)
+
+func NewCookbooks(cbi CookbookInterface, searcher SearchInterface, runCookstyle, onlyUnused bool, workers int,
+	overrides ...CookbooksOverrideFunc) (*CookbooksStatus, error) {
+
+	cookbooksState := &CookbooksStatus{
+		Cookbooks:    cbi,
+		Searcher:     searcher,
+		Cookstyle:    NewCookstyleRunner(),
+		Cache:        NewCookbookCache(DefaultCacheDir, cbi),
+		RunCookstyle: runCookstyle,
+		OnlyUnused:   onlyUnused,
+	}
+	for _, f := range overrides {
+		f(cookbooksState)
+	}
+
+	if cookbooksState.Journal != nil {
+		if err := cookbooksState.Journal.Start(cookbooksState.runOptions()); err != nil {
+			return nil, err
+		}
+	}
+
 	fmt.Printf("Finding available cookbooks...") // c <- ProgressUpdate(Event: COOKBOOK_FETCH)
 	// Version limit of "0" means fetch all
 	results, err := cbi.ListAvailableVersions("0")
@@ -108,6 +149,9 @@ func NewCookbooks(cbi CookbookInterface, searcher SearchInterface, runCookstyle,
 		return nil, errors.Wrap(err, "unable to retrieve cookbooks")
 	}
 
+	// only analyze the cookbook versions that match the filters, if any
+	results = FilterCookbooks(results, cookbooksState.Filters)
+
 	// get totals so we can accurately report progress and allocate results
 	totalCookbooks := 0
 	for _, versions := range results {
@@ -116,25 +160,53 @@ func NewCookbooks(cbi CookbookInterface, searcher SearchInterface, runCookstyle,
 	fmt.Printf(" (%d found)\n", totalCookbooks)
 
 	var (
-		downloadCh     = make(chan cookbookItem)
-		analyzeCh      = make(chan *CookbookRecord)
-		doneCh         = make(chan bool)
-		cookbooksState = &CookbooksStatus{
-			Records:        make([]*CookbookRecord, 0, totalCookbooks),
-			TotalCookbooks: totalCookbooks,
-			Cookbooks:      cbi,
-			Searcher:       searcher,
-			Cookstyle:      NewCookstyleRunner(),
-			RunCookstyle:   runCookstyle,
-			OnlyUnused:     onlyUnused,
-		}
+		downloadCh = make(chan cookbookItem)
+		analyzeCh  = make(chan *CookbookRecord)
+		doneCh     = make(chan bool)
 	)
+	cookbooksState.Records = make([]*CookbookRecord, 0, totalCookbooks)
+	cookbooksState.TotalCookbooks = totalCookbooks
 
 	if totalCookbooks == 0 {
 		fmt.Println("No cookbooks available for analysis")
 		return cookbooksState, nil
 	}
 
+	// build the cookbooks usage index with a single sweep of all nodes, if we are
+	// unable to, every cookbook record will report the usage lookup error
+	fmt.Printf("Finding cookbooks usage...")
+	cookbooksState.usage, cookbooksState.usageError = NewCookbooksUsage(searcher, cookbooksState.StaleAfter)
+	if cookbooksState.usageError != nil {
+		fmt.Println(" (-)")
+	} else if cookbooksState.StaleAfter > 0 {
+		fmt.Printf(" (%d active nodes found, %d stale nodes ignored)\n",
+			cookbooksState.usage.TotalNodes, cookbooksState.usage.StaleNodes)
+	} else {
+		fmt.Printf(" (%d nodes found)\n", cookbooksState.usage.TotalNodes)
+	}
+	if cookbooksState.usageError == nil && cookbooksState.usage.HiddenNodes != 0 {
+		fmt.Printf("Warning: %s\n", HiddenNodesWarning(cookbooksState.usage.HiddenNodes))
+	}
+
+	// cookbooks locked by active policy revisions are in use, even if no node has converged yet
+	if cookbooksState.Policies != nil && cookbooksState.usageError == nil {
+		fmt.Printf("Finding cookbooks locked by policies...")
+		groups, err := ActivePolicyRevisions(cookbooksState.Policies)
+		if err != nil {
+			// a client without access to the policies, or an older server, must not
+			// turn the whole report into errors, the usage by nodes is still valid
+			cookbooksState.PolicyErrors = append(cookbooksState.PolicyErrors, err)
+			fmt.Println(" (-)")
+			fmt.Printf("Warning: %v, only nodes are considered to find the cookbooks in use\n", err)
+		} else {
+			cookbooksState.usage.AddPolicyGroups(groups)
+			for _, group := range groups {
+				cookbooksState.PolicyErrors = append(cookbooksState.PolicyErrors, group.Errors()...)
+			}
+			fmt.Printf(" (%d policy groups found)\n", len(groups))
+		}
+	}
+
 	// determine how many workers do we need, by default, the total number of cookbooks
 	numWorkers := totalCookbooks
 	if totalCookbooks > workers {
@@ -167,6 +239,23 @@ func NewCookbooks(cbi CookbookInterface, searcher SearchInterface, runCookstyle,
 	return cookbooksState, nil
 }
 
+// the options that change the results of the analysis
+func (cbs *CookbooksStatus) runOptions() RunOptions {
+	options := RunOptions{
+		VerifyUpgrade: cbs.RunCookstyle,
+		OnlyUnused:    cbs.OnlyUnused,
+		AnalyzeAll:    cbs.AnalyzeAll,
+		Filters:       make([]string, 0, len(cbs.Filters)),
+	}
+	if cbs.StaleAfter > 0 {
+		options.StaleAfter = cbs.StaleAfter.String()
+	}
+	for _, f := range cbs.Filters {
+		options.Filters = append(options.Filters, f.String())
+	}
+	return options
+}
+
 func (cbs *CookbooksStatus) addRecord(r *CookbookRecord) {
 	cbs.RecordsMutex.Lock()
 	defer cbs.RecordsMutex.Unlock()
@@ -176,6 +265,12 @@ func (cbs *CookbooksStatus) addRecord(r *CookbookRecord) {
 func (cbs *CookbooksStatus) triggerJobs(cookbooks chef.CookbookListResult, inCh chan<- cookbookItem) {
 	for cookbookName, cookbookVersions := range cookbooks {
 		for _, ver := range cookbookVersions.Versions {
+			// cookbook versions analyzed by a previous attempt of a resumed run
+			if record, ok := cbs.Journal.Completed(cookbookName, ver.Version); ok {
+				cbs.addRecord(record)
+				cbs.progress.Increment()
+				continue
+			}
 			inCh <- cookbookItem{cookbookName, ver.Version}
 		}
 	}
@@ -213,6 +308,10 @@ func (cbs *CookbooksStatus) createAnalyzeWorkerPool(nWorkers int, analyzeCh <-ch
 				if cbs.RunCookstyle {
 					cbs.runCookstyleFor(record)
 				}
+
+				// NOTE: failing to checkpoint a record only means that it
+				// will be analyzed again if the run is resumed
+				cbs.Journal.Checkpoint(record)
 			}
 			wg.Done()
 		}(analyzeCh, &wg)
@@ -224,36 +323,37 @@ func (cbs *CookbooksStatus) createAnalyzeWorkerPool(nWorkers int, analyzeCh <-ch
 }
 
 func (cbs *CookbooksStatus) downloadCookbook(cookbookName, version string, analyzeCh chan<- *CookbookRecord) {
-	cbState := &CookbookRecord{Name: cookbookName,
-		path:    fmt.Sprintf("%s/cookbooks/%v-%v", AnalyzeCacheDir, cookbookName, version),
-		Version: version,
-	}
+	cbState := &CookbookRecord{Name: cookbookName, Version: version}
 
 	nodes, err := cbs.nodesUsingCookbookVersion(cookbookName, version)
 	if err != nil {
 		cbState.UsageLookupError = err
 	}
 	cbState.Nodes = nodes
+	cbState.Policies = cbs.policiesUsingCookbookVersion(cookbookName, version)
 
 	// by default we report only cookbooks that are being used by one or more nodes,
 	// but we also provide a way to report the opposite, that is, only unused cookbooks
-	if cbs.OnlyUnused {
-		// report only unused cookbooks
-		if len(nodes) > 0 {
-			cbs.progress.Increment()
-			return
-		}
-	} else {
-		// report only cookbooks being used
-		if len(nodes) == 0 {
-			cbs.progress.Increment()
-			return
+	if !cbs.AnalyzeAll {
+		if cbs.OnlyUnused {
+			// report only unused cookbooks
+			if cbState.InUse() {
+				cbs.progress.Increment()
+				return
+			}
+		} else {
+			// report only cookbooks being used
+			if !cbState.InUse() {
+				cbs.progress.Increment()
+				return
+			}
 		}
 	}
 
 	// do we need to analyze the cookbooks
 	if cbs.RunCookstyle {
-		err = cbs.Cookbooks.DownloadTo(cookbookName, version, fmt.Sprintf("%s/cookbooks", AnalyzeCacheDir))
+		// only the files that are not in the cache already are downloaded
+		cbState.path, err = cbs.Cache.Fetch(cookbookName, version)
 		if err != nil {
 			cbState.DownloadError = errors.Wrapf(err, "unable to download cookbook %s", cookbookName)
 		}
@@ -263,27 +363,26 @@ func (cbs *CookbooksStatus) downloadCookbook(cookbookName, version string, analy
 	analyzeCh <- cbState
 }
 
+// answers the usage lookups from the cookbooks usage index
 func (cbs *CookbooksStatus) nodesUsingCookbookVersion(cookbook string, version string) ([]string, error) {
-	query := map[string]interface{}{
-		"name": []string{"name"},
+	if cbs.usageError != nil {
+		return nil, cbs.usageError
 	}
 
-	// TODO add pagination
-	pres, err := cbs.Searcher.PartialExec("node", fmt.Sprintf("cookbooks_%s_version:%s", cookbook, version), query)
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to get cookbook usage information")
+	if cbs.usage == nil {
+		return []string{}, nil
 	}
 
-	// If the error is unrelated to returning any results we want to parse them and return them.
-	results := make([]string, 0, len(pres.Rows))
-	for _, element := range pres.Rows {
-		v := element.(map[string]interface{})["data"].(map[string]interface{})
-		if v != nil {
-			results = append(results, safeStringFromMap(v, "name"))
-		}
+	return cbs.usage.NodesUsing(cookbook, version), nil
+}
+
+// answers the policy lookups from the cookbooks usage index
+func (cbs *CookbooksStatus) policiesUsingCookbookVersion(cookbook string, version string) []string {
+	if cbs.usage == nil {
+		return []string{}
 	}
 
-	return results, err
+	return cbs.usage.PoliciesUsing(cookbook, version)
 }
 
 func (cbs *CookbooksStatus) runCookstyleFor(cb *CookbookRecord) {
@@ -300,6 +399,7 @@ func (cbs *CookbooksStatus) runCookstyleFor(cb *CookbookRecord) {
 		return
 	}
 
+	cb.CookstyleMetadata = cookstyleResults.Metadata
 	for _, file := range cookstyleResults.Files {
 		cb.Files = append(cb.Files, file)
 	}
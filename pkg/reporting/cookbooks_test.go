@@ -96,7 +96,7 @@ func TestCookbooksInUseDisplayOnlyUnused(t *testing.T) {
 	}
 	c, err := subject.NewCookbooks(
 		newMockCookbook(cookbookList, nil, nil),
-		makeMockSearch(mockedNodesSearchRows(), nil), // nodes are found
+		makeMockSearch(mockedCookbooksUsageSearchRows(), nil), // nodes are found
 		false,
 		true, // display only unused cookbooks
 		Workers,
@@ -109,7 +109,7 @@ func TestCookbooksInUseDisplayOnlyUnused(t *testing.T) {
 
 	c, err = subject.NewCookbooks(
 		newMockCookbook(cookbookList, nil, nil),
-		makeMockSearch(mockedNodesSearchRows(), nil), // nodes are found
+		makeMockSearch(mockedCookbooksUsageSearchRows(), nil), // nodes are found
 		false,
 		false, // display only used cookbooks
 		Workers,
@@ -233,7 +233,7 @@ func TestCookbooks(t *testing.T) {
 	}
 	c, err := subject.NewCookbooks(
 		newMockCookbook(cookbookList, nil, nil),
-		makeMockSearch(mockedNodesSearchRows(), nil),
+		makeMockSearch(mockedCookbooksUsageSearchRows(), nil),
 		false,
 		false,
 		Workers,
@@ -324,7 +324,7 @@ func TestCookbooks_DownloadErrors(t *testing.T) {
 	}
 	c, err := subject.NewCookbooks(
 		newMockCookbook(cookbookList, nil, errors.New("download error")),
-		makeMockSearch(mockedNodesSearchRows(), nil),
+		makeMockSearch(mockedCookbooksUsageSearchRows(), nil),
 		true, // run cookstyle, which means that we will download the cookbooks
 		false,
 		Workers,
@@ -357,7 +357,7 @@ func TestCookbooks_UsageStatErrors(t *testing.T) {
 	}
 	c, err := subject.NewCookbooks(
 		newMockCookbook(cookbookList, nil, nil),
-		makeMockSearch(mockedNodesSearchRows(), errors.New("lookup error")),
+		makeMockSearch(mockedCookbooksUsageSearchRows(), errors.New("lookup error")),
 		false,
 		// Because the usage error makes it look like no nodes are attached to this cookbook
 		// we need to return only unused cookbooks
@@ -395,3 +395,253 @@ func TestCookbookRecord_ErrorsWhenEmpty(t *testing.T) {
 	errors := cr.Errors()
 	assert.Equal(t, len(errors), 0)
 }
+
+// Given a large number of cookbook versions,
+// verify that we only do a single sweep of all nodes to find their usage
+func TestCookbooksUsageSingleNodeSweep(t *testing.T) {
+	cookbookList := chef.CookbookListResult{
+		"foo": chef.CookbookVersions{
+			Versions: []chef.CookbookVersion{
+				chef.CookbookVersion{Version: "0.1.0"},
+				chef.CookbookVersion{Version: "0.2.0"},
+				chef.CookbookVersion{Version: "0.3.0"},
+				chef.CookbookVersion{Version: "0.4.0"},
+			},
+		},
+		"bar": chef.CookbookVersions{
+			Versions: []chef.CookbookVersion{
+				chef.CookbookVersion{Version: "0.1.0"},
+			},
+		},
+	}
+	mocksearch := makeMockPaginatedSearch(mockedCookbooksUsageSearchRows(), 0)
+	c, err := subject.NewCookbooks(
+		newMockCookbook(cookbookList, nil, nil),
+		mocksearch,
+		false,
+		false,
+		Workers,
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(mocksearch.requestedStarts), "only one search should be done")
+	if assert.NotNil(t, c) {
+		assert.Equal(t, 5, c.TotalCookbooks)
+		// foo 0.4.0 is not used by any node
+		if assert.Equal(t, 4, len(c.Records)) {
+			for _, rec := range c.Records {
+				switch rec.Name + "-" + rec.Version {
+				case "foo-0.1.0":
+					assert.ElementsMatch(t, []string{"node1", "node2"}, rec.Nodes)
+				case "foo-0.2.0":
+					assert.ElementsMatch(t, []string{"node3"}, rec.Nodes)
+				case "foo-0.3.0":
+					assert.ElementsMatch(t, []string{"node4"}, rec.Nodes)
+				case "bar-0.1.0":
+					assert.ElementsMatch(t, []string{"node1", "node3", "node4"}, rec.Nodes)
+				default:
+					t.Fatalf("unexpected cookbook %s(%s)", rec.Name, rec.Version)
+				}
+			}
+		}
+	}
+}
+
+// nodes using the cookbooks foo (0.1.0, 0.2.0 and 0.3.0) and bar (0.1.0)
+func mockedCookbooksUsageSearchRows() string {
+	return `[
+  {
+    "data" : {
+      "name" : "node1",
+      "cookbooks" : {
+        "foo" : { "version" : "0.1.0" },
+        "bar" : { "version" : "0.1.0" }
+      }
+    }
+  },
+  {
+    "data" : {
+      "name" : "node2",
+      "cookbooks" : {
+        "foo" : { "version" : "0.1.0" }
+      }
+    }
+  },
+  {
+    "data" : {
+      "name" : "node3",
+      "cookbooks" : {
+        "foo" : { "version" : "0.2.0" },
+        "bar" : { "version" : "0.1.0" }
+      }
+    }
+  },
+  {
+    "data" : {
+      "name" : "node4",
+      "cookbooks" : {
+        "foo" : { "version" : "0.3.0" },
+        "bar" : { "version" : "0.1.0" }
+      }
+    }
+  },
+  {
+    "data" : {
+      "name" : "node5",
+      "cookbooks" : null
+    }
+  }
+]`
+}
+
+// Given a set of cookbook filters,
+// verify that only the matching cookbook versions are analyzed
+func TestCookbooksWithFilters(t *testing.T) {
+	cookbookList := chef.CookbookListResult{
+		"foo": chef.CookbookVersions{
+			Versions: []chef.CookbookVersion{
+				chef.CookbookVersion{Version: "0.1.0"},
+				chef.CookbookVersion{Version: "0.2.0"},
+				chef.CookbookVersion{Version: "0.3.0"},
+			},
+		},
+		"bar": chef.CookbookVersions{
+			Versions: []chef.CookbookVersion{
+				chef.CookbookVersion{Version: "0.1.0"},
+			},
+		},
+	}
+	filters, err := subject.ParseCookbookFilters([]string{"f*", ">= 0.2.0"})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	c, err := subject.NewCookbooks(
+		newMockCookbook(cookbookList, nil, nil),
+		makeMockSearch(mockedCookbooksUsageSearchRows(), nil),
+		false,
+		false,
+		Workers,
+		func(cbs *subject.CookbooksStatus) {
+			cbs.Filters = filters
+		},
+	)
+	assert.Nil(t, err)
+	if assert.NotNil(t, c) {
+		assert.Equal(t, 2, c.TotalCookbooks)
+		if assert.Equal(t, 2, len(c.Records)) {
+			for _, rec := range c.Records {
+				assert.Equal(t, "foo", rec.Name)
+				assert.Contains(t, []string{"0.2.0", "0.3.0"}, rec.Version)
+			}
+		}
+	}
+}
+
+// Given a set of active policy revisions,
+// verify that the cookbook versions they lock are considered in use
+func TestCookbooksLockedByPolicies(t *testing.T) {
+	cookbookList := chef.CookbookListResult{
+		"foo": chef.CookbookVersions{
+			Versions: []chef.CookbookVersion{
+				chef.CookbookVersion{Version: "0.1.0"},
+				chef.CookbookVersion{Version: "0.4.0"},
+			},
+		},
+		"mysql": chef.CookbookVersions{
+			Versions: []chef.CookbookVersion{
+				chef.CookbookVersion{Version: "8.0.0"},
+				chef.CookbookVersion{Version: "9.0.0"},
+			},
+		},
+	}
+	c, err := subject.NewCookbooks(
+		newMockCookbook(cookbookList, nil, nil),
+		makeMockPaginatedSearch(mockedCookbooksUsageSearchRows(), 0),
+		false,
+		false,
+		Workers,
+		func(cbs *subject.CookbooksStatus) {
+			cbs.Policies = newMockPolicies()
+		},
+	)
+	assert.Nil(t, err)
+	if assert.NotNil(t, c) {
+		assert.Equal(t, 4, c.TotalCookbooks)
+		// foo 0.4.0 and mysql 9.0.0 are not used by any node or policy
+		if assert.Equal(t, 2, len(c.Records)) {
+			for _, rec := range c.Records {
+				switch rec.Name + "-" + rec.Version {
+				case "foo-0.1.0":
+					assert.ElementsMatch(t, []string{"node1", "node2"}, rec.Nodes)
+					assert.Empty(t, rec.Policies)
+				case "mysql-8.0.0":
+					assert.Empty(t, rec.Nodes, "no node has converged with the policy yet")
+					assert.Equal(t, []string{"prod/db"}, rec.Policies)
+					assert.True(t, rec.InUse())
+				default:
+					t.Fatalf("unexpected cookbook %s(%s)", rec.Name, rec.Version)
+				}
+			}
+		}
+	}
+}
+
+func TestCookbooksPoliciesError(t *testing.T) {
+	cookbookList := chef.CookbookListResult{
+		"foo": chef.CookbookVersions{
+			Versions: []chef.CookbookVersion{
+				chef.CookbookVersion{Version: "0.1.0"},
+			},
+		},
+	}
+	policymock := newMockPolicies()
+	policymock.desiredGroupsError = errors.New("groups error")
+	c, err := subject.NewCookbooks(
+		newMockCookbook(cookbookList, nil, nil),
+		makeMockPaginatedSearch(mockedCookbooksUsageSearchRows(), 0),
+		false,
+		false,
+		Workers,
+		func(cbs *subject.CookbooksStatus) {
+			cbs.Policies = policymock
+		},
+	)
+	assert.Nil(t, err)
+	if assert.NotNil(t, c) && assert.Equal(t, 1, len(c.Records)) {
+		assert.Equal(t, []string{"node1", "node2"}, c.Records[0].Nodes)
+		assert.Nil(t, c.Records[0].UsageLookupError, "policies must not turn the usage by nodes into errors")
+		if assert.Equal(t, 1, len(c.PolicyErrors)) {
+			assert.Equal(t, "unable to retrieve policy groups: groups error", c.PolicyErrors[0].Error())
+		}
+	}
+}
+
+func TestCookbooksPolicyRevisionErrors(t *testing.T) {
+	cookbookList := chef.CookbookListResult{
+		"foo": chef.CookbookVersions{
+			Versions: []chef.CookbookVersion{
+				chef.CookbookVersion{Version: "0.1.0"},
+			},
+		},
+	}
+	policymock := newMockPolicies()
+	policymock.desiredRevisionError = errors.New("403 Forbidden")
+	c, err := subject.NewCookbooks(
+		newMockCookbook(cookbookList, nil, nil),
+		makeMockPaginatedSearch(mockedCookbooksUsageSearchRows(), 0),
+		false,
+		false,
+		Workers,
+		func(cbs *subject.CookbooksStatus) {
+			cbs.Policies = policymock
+		},
+	)
+	assert.Nil(t, err)
+	if assert.NotNil(t, c) && assert.Equal(t, 1, len(c.Records)) {
+		assert.Nil(t, c.Records[0].UsageLookupError)
+		assert.Equal(t, []string{"node1", "node2"}, c.Records[0].Nodes)
+	}
+	if assert.Equal(t, 3, len(c.PolicyErrors), "every policy revision that failed should be reported") {
+		assert.Equal(t, "unable to get revision rev2 of policy app: 403 Forbidden", c.PolicyErrors[0].Error())
+	}
+}
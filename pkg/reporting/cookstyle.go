@@ -17,8 +17,20 @@
 package reporting
 
 import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -46,22 +58,57 @@ type CookbookFile struct {
 	Offenses []CookstyleOffense `json:"offenses"`
 }
 
+type CookstyleMetadata struct {
+	RubocopVersion string `json:"rubocop_version"`
+	RubyEngine     string `json:"ruby_engine"`
+	RubyVersion    string `json:"ruby_version"`
+	RubyPatchlevel string `json:"ruby_patchlevel"`
+	RubyPlatform   string `json:"ruby_platform"`
+}
+
 type CookstyleResult struct {
-	Metadata struct {
-		RubocopVersion string `json:"rubocop_version"`
-		RubyEngine     string `json:"ruby_engine"`
-		RubyVersion    string `json:"ruby_version"`
-		RubyPatchlevel string `json:"ruby_patchlevel"`
-		RubyPlatform   string `json:"ruby_platform"`
-	} `json:"metadata"`
-	Files []CookbookFile `json:"files"`
+	Metadata CookstyleMetadata `json:"metadata"`
+	Files    []CookbookFile    `json:"files"`
 }
 
 type CookstyleRunner struct {
 	Opts []string
+	// when set, results are memoized inside this directory, keyed by the checksums
+	// of the cookbook files, the cookstyle version and the runner options
+	CacheDir string
+	// ignore the memoized results, fresh results are memoized anyway
+	Refresh bool
+
+	versionOnce sync.Once
+	version     string
+}
+
+// options that modify the cookbook files, results of those runs are never memoized
+var cookstyleAutoCorrectOpts = map[string]bool{
+	"-a": true, "--auto-correct": true, "-A": true, "--auto-correct-all": true, "--safe-auto-correct": true,
 }
 
 func (ecr *CookstyleRunner) Run(workingDir string) (*CookstyleResult, error) {
+	key := ecr.cacheKey(workingDir)
+	if key != "" && !ecr.Refresh {
+		if result, ok := ecr.cachedResult(key); ok {
+			return result, nil
+		}
+	}
+
+	result, err := ecr.run(workingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if key != "" {
+		// failing to memoize a result must not fail the analysis
+		ecr.storeResult(key, result)
+	}
+	return result, nil
+}
+
+func (ecr *CookstyleRunner) run(workingDir string) (*CookstyleResult, error) {
 	var (
 		cookstyleRes CookstyleResult
 		cmd          = exec.Command("cookstyle", ecr.Opts...)
@@ -92,6 +139,149 @@ func (ecr *CookstyleRunner) Run(workingDir string) (*CookstyleResult, error) {
 	return &cookstyleRes, nil
 }
 
+// returns the output of 'cookstyle --version', which includes the rubocop version,
+// or an empty string if it can't be detected
+func (ecr *CookstyleRunner) Version() string {
+	ecr.versionOnce.Do(func() {
+		output, err := exec.Command("cookstyle", "--version").Output()
+		if err == nil {
+			ecr.version = strings.TrimSpace(string(output))
+		}
+	})
+	return ecr.version
+}
+
+// returns the rubocop version from the output of 'cookstyle --version', that is
+// the last field of the line that mentions RuboCop, or an empty string if missing
+//
+//	Cookstyle 5.13.7
+//	  * RuboCop 0.75.1
+func rubocopVersion(cookstyleVersion string) string {
+	for _, line := range strings.Split(cookstyleVersion, "\n") {
+		fields := strings.Fields(line)
+		for i, field := range fields {
+			if field == "RuboCop" && i+1 < len(fields) {
+				return fields[i+1]
+			}
+		}
+	}
+	return ""
+}
+
+// returns the key of the memoized result of the provided cookbook directory, or
+// an empty string when results should not be memoized
+func (ecr *CookstyleRunner) cacheKey(workingDir string) string {
+	if ecr.CacheDir == "" {
+		return ""
+	}
+	for _, opt := range ecr.Opts {
+		if cookstyleAutoCorrectOpts[opt] {
+			return ""
+		}
+	}
+
+	version := ecr.Version()
+	if version == "" {
+		return ""
+	}
+
+	checksums, err := dirChecksums(workingDir)
+	if err != nil || len(checksums) == 0 {
+		return ""
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", version, strings.Join(ecr.Opts, "\x00"))
+	for _, line := range checksums {
+		fmt.Fprintln(h, line)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (ecr *CookstyleRunner) resultPath(key string) string {
+	return filepath.Join(ecr.CacheDir, key[:2], key+".json")
+}
+
+func (ecr *CookstyleRunner) cachedResult(key string) (*CookstyleResult, bool) {
+	content, err := ioutil.ReadFile(ecr.resultPath(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var result CookstyleResult
+	if err := json.Unmarshal(content, &result); err != nil {
+		return nil, false
+	}
+
+	// never reuse results of a different, or unknown, rubocop version
+	version := rubocopVersion(ecr.Version())
+	if version == "" || result.Metadata.RubocopVersion != version {
+		return nil, false
+	}
+
+	// the modification time tells us when the result was last used
+	now := time.Now()
+	os.Chtimes(ecr.resultPath(key), now, now)
+
+	return &result, true
+}
+
+func (ecr *CookstyleRunner) storeResult(key string, result *CookstyleResult) {
+	content, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+
+	p := ecr.resultPath(key)
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return
+	}
+
+	// write and rename so that concurrent runs never read a partial result
+	tmpFile, err := ioutil.TempFile(filepath.Dir(p), ".result-")
+	if err != nil {
+		return
+	}
+	_, err = tmpFile.Write(content)
+	tmpFile.Close()
+	if err != nil || os.Rename(tmpFile.Name(), p) != nil {
+		os.Remove(tmpFile.Name())
+	}
+}
+
+// returns a sorted list of '<relative path> <md5 checksum>' of every file inside a directory
+func dirChecksums(dir string) ([]string, error) {
+	checksums := make([]string, 0)
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		h := md5.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		checksums = append(checksums, filepath.ToSlash(rel)+" "+hex.EncodeToString(h.Sum(nil)))
+		return nil
+	})
+	sort.Strings(checksums)
+	return checksums, err
+}
+
 func NewCookstyleRunner() *CookstyleRunner {
 	return &CookstyleRunner{
 		Opts: []string{"--format", "json", "--only", "ChefDeprecations,ChefCorrectness"},
@@ -18,10 +18,12 @@
 package reporting_test
 
 import (
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -110,6 +112,123 @@ func TestCookstyleRunnerWithBinstubs(t *testing.T) {
 	}
 }
 
+func TestCookstyleRunnerCache(t *testing.T) {
+	savedPath := setupBinstubsDir()
+	defer os.Setenv("PATH", savedPath)
+
+	dir, err := ioutil.TempDir("", "cookbook")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cookbookDir := filepath.Join(dir, "foo-1.0.0")
+	assert.Nil(t, os.MkdirAll(cookbookDir, os.ModePerm))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(cookbookDir, "metadata.rb"), []byte("name 'foo'"), 0644))
+
+	// the binstub records every run inside this file
+	runsLog := filepath.Join(dir, "runs.log")
+	os.Setenv("COOKSTYLE_RUNS_LOG", runsLog)
+	defer os.Unsetenv("COOKSTYLE_RUNS_LOG")
+	numRuns := func() int {
+		content, _ := ioutil.ReadFile(runsLog)
+		runs := 0
+		for _, line := range strings.Split(string(content), "\n") {
+			if line != "" && line != "--version" {
+				runs++
+			}
+		}
+		return runs
+	}
+
+	runner := subject.NewCookstyleRunner()
+	runner.CacheDir = filepath.Join(dir, "cache")
+	runner.Opts = []string{"known-exit-code-error"}
+	assert.Equal(t, "Cookstyle 5.13.7\n  * RuboCop 0.75.1", runner.Version())
+
+	// first run analyzes the cookbook, the second one is memoized
+	for i := 0; i < 2; i++ {
+		result, err := runner.Run(cookbookDir)
+		assert.Nil(t, err)
+		assert.NotNil(t, result)
+	}
+	assert.Equal(t, 1, numRuns())
+
+	// changing a file invalidates the result
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(cookbookDir, "metadata.rb"), []byte("name 'bar'"), 0644))
+	_, err = runner.Run(cookbookDir)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, numRuns())
+
+	// so does changing the options
+	runner.Opts = []string{"known-exit-code-error", "--only", "ChefDeprecations"}
+	_, err = runner.Run(cookbookDir)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, numRuns())
+
+	// refreshing the cache ignores the memoized result
+	runner.Refresh = true
+	_, err = runner.Run(cookbookDir)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, numRuns())
+
+	// failures are never memoized
+	runner.Refresh = false
+	runner.Opts = []string{"exit-code-error", "2"}
+	for i := 0; i < 2; i++ {
+		_, err = runner.Run(cookbookDir)
+		assert.NotNil(t, err)
+	}
+	assert.Equal(t, 6, numRuns())
+
+	// neither are runs that modify the cookbook
+	runner.Opts = []string{"known-exit-code-error", "-a"}
+	for i := 0; i < 2; i++ {
+		_, err = runner.Run(cookbookDir)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 8, numRuns())
+}
+
+func TestCookstyleRunnerCacheRubocopVersion(t *testing.T) {
+	savedPath := setupBinstubsDir()
+	defer os.Setenv("PATH", savedPath)
+
+	dir, err := ioutil.TempDir("", "cookbook")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cookbookDir := filepath.Join(dir, "foo-1.0.0")
+	assert.Nil(t, os.MkdirAll(cookbookDir, os.ModePerm))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(cookbookDir, "metadata.rb"), []byte("name 'foo'"), 0644))
+
+	runner := subject.NewCookstyleRunner()
+	runner.CacheDir = filepath.Join(dir, "cache")
+	runner.Opts = []string{"known-exit-code-error"}
+	_, err = runner.Run(cookbookDir)
+	assert.Nil(t, err)
+
+	memoized, err := filepath.Glob(filepath.Join(runner.CacheDir, "*", "*.json"))
+	if !assert.Nil(t, err) || !assert.Equal(t, 1, len(memoized)) {
+		return
+	}
+
+	// only results of the exact same rubocop version are reused, not even
+	// results that don't record their version or of a version prefix
+	for _, version := range []string{"", "0.75", "0.75.1"} {
+		content := fmt.Sprintf(`{"metadata":{"rubocop_version":"%s"},"files":[{"path":"memoized.rb"}]}`, version)
+		assert.Nil(t, ioutil.WriteFile(memoized[0], []byte(content), 0644))
+
+		result, err := runner.Run(cookbookDir)
+		if assert.Nil(t, err) && assert.NotNil(t, result) {
+			assert.Equal(t, "0.75.1", result.Metadata.RubocopVersion)
+			assert.Equal(t, version == "0.75.1", len(result.Files) == 1, "rubocop version %q", version)
+		}
+	}
+}
+
 // returns the binstubs/ directory path
 func binstubsDir() string {
 	pwd, err := os.Getwd()
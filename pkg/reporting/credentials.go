@@ -0,0 +1,234 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	chef "github.com/chef/go-chef"
+	"github.com/chef/go-libs/credentials"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// profile names are used as TOML table headers, we only allow bare keys
+var validProfileName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// CredentialsProfile is a single profile of a Chef credentials file
+//
+// example:
+//
+//	[default]
+//	client_name = 'admin'
+//	client_key = '/home/admin/.chef/admin.pem'
+//	chef_server_url = 'https://chef.example.com/organizations/my-org'
+type CredentialsProfile struct {
+	Name string
+	credentials.CredsDetail
+}
+
+// verifies that the profile has all the required settings and that
+// the client key is a valid RSA private key
+func (p CredentialsProfile) Validate() error {
+	if !validProfileName.MatchString(p.Name) {
+		return errors.Errorf(
+			"invalid profile name '%s', only letters, numbers, '-' and '_' are allowed", p.Name,
+		)
+	}
+
+	settings := []struct{ key, value string }{
+		{"chef_server_url", p.ChefServerUrl},
+		{"client_name", p.ClientName},
+		{"client_key", p.ClientKey},
+	}
+	for _, s := range settings {
+		if strings.TrimSpace(s.value) == "" {
+			return errors.Errorf("%s cannot be empty", s.key)
+		}
+		// values are written as TOML literal strings
+		if strings.ContainsAny(s.value, "'\r\n") {
+			return errors.Errorf("%s cannot contain single quotes or new lines", s.key)
+		}
+	}
+
+	serverURL, err := url.Parse(p.ChefServerUrl)
+	if err != nil || (serverURL.Scheme != "http" && serverURL.Scheme != "https") || serverURL.Host == "" {
+		return errors.Errorf(
+			"invalid chef_server_url '%s', use a full URL like https://chef.example.com/organizations/my-org",
+			p.ChefServerUrl,
+		)
+	}
+
+	return ValidateClientKey(p.ClientKey)
+}
+
+// renders the profile as a TOML table
+func (p CredentialsProfile) String() string {
+	return "[" + p.Name + "]\n" +
+		"client_name = '" + p.ClientName + "'\n" +
+		"client_key = '" + p.ClientKey + "'\n" +
+		"chef_server_url = '" + p.ChefServerUrl + "'\n"
+}
+
+// verifies that the provided file contains a valid RSA private key
+func ValidateClientKey(path string) error {
+	key, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't read key '%s'", path)
+	}
+
+	if _, err := chef.PrivateKeyFromString(key); err != nil {
+		return errors.Wrapf(err, "key '%s' is not a valid RSA private key", path)
+	}
+
+	return nil
+}
+
+// returns the location where a credentials file is created when
+// none exists, that is, $HOME/.chef/credentials
+func DefaultCredentialsFile() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to detect home directory")
+	}
+	return filepath.Join(home, ".chef", credentials.DefaultFileName), nil
+}
+
+// saves the profile into the provided credentials file, the file is created
+// if it doesn't exist and, since it points to private keys, it is only
+// readable by its owner
+//
+// an existing profile with the same name is only replaced when force is true,
+// any other profile, or comment, inside the file is preserved
+func SaveCredentialsProfile(credsFile string, profile CredentialsProfile, force bool) error {
+	content, err := ioutil.ReadFile(credsFile)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "unable to read credentials from '%s'", credsFile)
+	}
+
+	// never append to a file we can't parse, we would make things worse
+	if len(content) != 0 {
+		v := viper.New()
+		v.SetConfigType("toml")
+		if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+			return errors.Wrap(err, credentials.MalformedCredentialsFileErr)
+		}
+	}
+
+	lines := strings.Split(string(content), "\n")
+	if profileIndex(lines, profile.Name) != -1 {
+		if !force {
+			return errors.Errorf(
+				"profile '%s' already exists in '%s', use --force to overwrite it",
+				profile.Name, credsFile,
+			)
+		}
+		lines = removeProfile(lines, profile.Name)
+	}
+
+	newContent := strings.TrimRight(strings.Join(lines, "\n"), "\n")
+	if newContent != "" {
+		newContent += "\n\n"
+	}
+	newContent += profile.String()
+
+	return writePrivateFile(credsFile, []byte(newContent))
+}
+
+// returns the line where the table of the provided profile starts, or -1
+func profileIndex(lines []string, name string) int {
+	for i, line := range lines {
+		if header, ok := tableHeader(line); ok && header == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// removes the table of the provided profile, that is, from its header
+// until the next table header or the end of the file
+func removeProfile(lines []string, name string) []string {
+	start := profileIndex(lines, name)
+	if start == -1 {
+		return lines
+	}
+
+	end := start + 1
+	for end < len(lines) {
+		if _, ok := tableHeader(lines[end]); ok {
+			break
+		}
+		end++
+	}
+
+	return append(lines[:start:start], lines[end:]...)
+}
+
+// returns the name of a TOML table if the line is a table header
+func tableHeader(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "[[") {
+		return "", false
+	}
+
+	closing := strings.Index(trimmed, "]")
+	if closing == -1 {
+		return "", false
+	}
+
+	name := strings.TrimSpace(trimmed[1:closing])
+	return strings.Trim(name, `"'`), true
+}
+
+// writes the content to a temporary file with owner-only permissions and then
+// moves it into place, so we never leave a half-written credentials file
+func writePrivateFile(path string, content []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrapf(err, "unable to create directory '%s'", dir)
+	}
+
+	tmpFile, err := ioutil.TempFile(dir, ".credentials-")
+	if err != nil {
+		return errors.Wrap(err, "unable to create temporary credentials file")
+	}
+	defer os.Remove(tmpFile.Name()) // no-op once renamed
+
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		return errors.Wrap(err, "unable to write credentials file")
+	}
+	if err := tmpFile.Chmod(0600); err != nil {
+		tmpFile.Close()
+		return errors.Wrap(err, "unable to set permissions of credentials file")
+	}
+	if err := tmpFile.Close(); err != nil {
+		return errors.Wrap(err, "unable to write credentials file")
+	}
+
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		return errors.Wrapf(err, "unable to save credentials to '%s'", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chef/go-libs/credentials"
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func newTestProfile(t *testing.T, dir, name string) subject.CredentialsProfile {
+	keyPath := filepath.Join(dir, name+".pem")
+	if err := ioutil.WriteFile(keyPath, key(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return subject.CredentialsProfile{
+		Name: name,
+		CredsDetail: credentials.CredsDetail{
+			ChefServerUrl: "https://chef.example.com/organizations/" + name,
+			ClientName:    name,
+			ClientKey:     keyPath,
+		},
+	}
+}
+
+func TestCredentialsProfileValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	profile := newTestProfile(t, dir, "dev")
+	assert.Nil(t, profile.Validate())
+
+	invalid := profile
+	invalid.Name = "my profile"
+	if err := invalid.Validate(); assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "invalid profile name 'my profile'")
+	}
+
+	invalid = profile
+	invalid.ClientName = ""
+	if err := invalid.Validate(); assert.NotNil(t, err) {
+		assert.Equal(t, "client_name cannot be empty", err.Error())
+	}
+
+	invalid = profile
+	invalid.ClientName = "o'neil"
+	if err := invalid.Validate(); assert.NotNil(t, err) {
+		assert.Equal(t, "client_name cannot contain single quotes or new lines", err.Error())
+	}
+
+	invalid = profile
+	invalid.ChefServerUrl = "chef.example.com/organizations/dev"
+	if err := invalid.Validate(); assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "invalid chef_server_url 'chef.example.com/organizations/dev'")
+	}
+
+	invalid = profile
+	invalid.ClientKey = filepath.Join(dir, "missing.pem")
+	if err := invalid.Validate(); assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "couldn't read key")
+	}
+}
+
+func TestValidateClientKeyNotRSA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	keyPath := filepath.Join(dir, "bad.pem")
+	if err := ioutil.WriteFile(keyPath, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err = subject.ValidateClientKey(keyPath)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "is not a valid RSA private key")
+		assert.Contains(t, err.Error(), "private key block size invalid")
+	}
+}
+
+func TestSaveCredentialsProfileNewFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		credsFile = filepath.Join(dir, ".chef", "credentials")
+		profile   = newTestProfile(t, dir, "default")
+	)
+	assert.Nil(t, subject.SaveCredentialsProfile(credsFile, profile, false))
+
+	content, err := ioutil.ReadFile(credsFile)
+	if assert.Nil(t, err) {
+		assert.Equal(t, `[default]
+client_name = 'default'
+client_key = '`+profile.ClientKey+`'
+chef_server_url = 'https://chef.example.com/organizations/default'
+`, string(content))
+	}
+
+	info, err := os.Stat(credsFile)
+	if assert.Nil(t, err) {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "credentials must only be readable by its owner")
+	}
+}
+
+func TestSaveCredentialsProfileAppendAndForce(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	credsFile := filepath.Join(dir, "credentials")
+	err = ioutil.WriteFile(credsFile, []byte(`# my profiles
+[default]
+client_name = 'foo'
+client_key = '/foo.pem'
+chef_server_url = 'https://chef.example.com/organizations/foo'
+
+[prod]
+client_name = 'prod'
+client_key = '/prod.pem'
+chef_server_url = 'https://chef.example.com/organizations/prod'
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// append a new profile
+	dev := newTestProfile(t, dir, "dev")
+	assert.Nil(t, subject.SaveCredentialsProfile(credsFile, dev, false))
+
+	// never overwrite without force
+	def := newTestProfile(t, dir, "default")
+	err = subject.SaveCredentialsProfile(credsFile, def, false)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "profile 'default' already exists")
+		assert.Contains(t, err.Error(), "use --force to overwrite it")
+	}
+
+	// overwrite with force
+	assert.Nil(t, subject.SaveCredentialsProfile(credsFile, def, true))
+
+	content, err := ioutil.ReadFile(credsFile)
+	if assert.Nil(t, err) {
+		assert.Equal(t, `# my profiles
+[prod]
+client_name = 'prod'
+client_key = '/prod.pem'
+chef_server_url = 'https://chef.example.com/organizations/prod'
+
+[dev]
+client_name = 'dev'
+client_key = '`+dev.ClientKey+`'
+chef_server_url = 'https://chef.example.com/organizations/dev'
+
+[default]
+client_name = 'default'
+client_key = '`+def.ClientKey+`'
+chef_server_url = 'https://chef.example.com/organizations/default'
+`, string(content))
+	}
+}
+
+func TestSaveCredentialsProfileMalformedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	credsFile := filepath.Join(dir, "credentials")
+	if err := ioutil.WriteFile(credsFile, []byte("[default\nfoo ="), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err = subject.SaveCredentialsProfile(credsFile, newTestProfile(t, dir, "dev"), false)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "unable to parse credentials file")
+	}
+}
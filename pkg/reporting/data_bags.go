@@ -0,0 +1,244 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/cheggaaa/pb/v3"
+	"github.com/pkg/errors"
+)
+
+// encrypted data bag items with this format version, or lower, are considered
+// a security finding, newer versions authenticate the encrypted data (HMAC/GCM)
+const LegacyEncryptionVersion = 1
+
+type DataBagsStatus struct {
+	Records       []*DataBagRecord
+	TotalDataBags int
+	TotalItems    int
+}
+
+type DataBagRecord struct {
+	Name      string
+	Items     []*DataBagItemRecord
+	ListError error
+}
+
+type DataBagItemRecord struct {
+	Name      string
+	Encrypted bool
+	// the format version of the encrypted item, when the fields of the item
+	// were encrypted with different versions, the lowest one is reported
+	EncryptionVersion int
+	Cipher            string
+	GetError          error
+}
+
+func (dbr DataBagRecord) Errors() []error {
+	errs := make([]error, 0)
+	if dbr.ListError != nil {
+		errs = append(errs, dbr.ListError)
+	}
+	for _, item := range dbr.Items {
+		if item.GetError != nil {
+			errs = append(errs, item.GetError)
+		}
+	}
+	return errs
+}
+
+func (dbr *DataBagRecord) NumItems() int {
+	return len(dbr.Items)
+}
+
+func (dbr *DataBagRecord) NumEncrypted() int {
+	i := 0
+	for _, item := range dbr.Items {
+		if item.Encrypted {
+			i++
+		}
+	}
+	return i
+}
+
+func (dbr *DataBagRecord) NumLegacyEncrypted() int {
+	i := 0
+	for _, item := range dbr.Items {
+		if item.LegacyEncryption() {
+			i++
+		}
+	}
+	return i
+}
+
+// returns true if the item is encrypted with a legacy format version
+func (dbir *DataBagItemRecord) LegacyEncryption() bool {
+	return dbir.Encrypted && dbir.EncryptionVersion <= LegacyEncryptionVersion
+}
+
+// NewDataBags analyzes every item of every data bag to detect which ones are
+// encrypted and their encryption format version, no secret is required
+func NewDataBags(dbi DataBagInterface, workers int) (*DataBagsStatus, error) {
+	fmt.Printf("Finding available data bags...")
+	bags, err := dbi.List()
+	if err != nil {
+		fmt.Println(" (-)")
+		return nil, errors.Wrap(err, "unable to retrieve data bags")
+	}
+
+	status := &DataBagsStatus{
+		Records:       make([]*DataBagRecord, 0, len(*bags)),
+		TotalDataBags: len(*bags),
+	}
+	fmt.Printf(" (%d found)\n", status.TotalDataBags)
+
+	if status.TotalDataBags == 0 {
+		fmt.Println("No data bags available for analysis")
+		return status, nil
+	}
+
+	for name := range *bags {
+		record := &DataBagRecord{Name: name, Items: make([]*DataBagItemRecord, 0)}
+		status.Records = append(status.Records, record)
+
+		items, err := dbi.ListItems(name)
+		if err != nil {
+			record.ListError = errors.Wrapf(err, "unable to list items of data bag %s", name)
+			continue
+		}
+
+		for item := range *items {
+			record.Items = append(record.Items, &DataBagItemRecord{Name: item})
+		}
+		sort.Slice(record.Items, func(i, j int) bool {
+			return record.Items[i].Name < record.Items[j].Name
+		})
+		status.TotalItems += len(record.Items)
+	}
+
+	sort.Slice(status.Records, func(i, j int) bool {
+		return status.Records[i].Name < status.Records[j].Name
+	})
+
+	if status.TotalItems == 0 {
+		return status, nil
+	}
+
+	fmt.Println("Analyzing data bag items...")
+	status.analyzeItems(dbi, workers)
+
+	return status, nil
+}
+
+// internally used to submit items to the workers
+type dataBagItemJob struct {
+	bag  string
+	item *DataBagItemRecord
+}
+
+func (dbs *DataBagsStatus) analyzeItems(dbi DataBagInterface, workers int) {
+	var (
+		progress = pb.StartNew(dbs.TotalItems)
+		jobs     = make(chan dataBagItemJob)
+		wg       sync.WaitGroup
+	)
+
+	numWorkers := dbs.TotalItems
+	if numWorkers > workers {
+		numWorkers = workers
+	}
+
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				content, err := dbi.GetItem(job.bag, job.item.Name)
+				if err != nil {
+					job.item.GetError = errors.Wrapf(err, "unable to get item %s of data bag %s", job.item.Name, job.bag)
+				} else {
+					job.item.detectEncryption(content)
+				}
+				progress.Increment()
+			}
+		}()
+	}
+
+	for _, record := range dbs.Records {
+		for _, item := range record.Items {
+			jobs <- dataBagItemJob{bag: record.Name, item: item}
+		}
+	}
+	close(jobs)
+
+	wg.Wait()
+	progress.Finish()
+}
+
+// an encrypted data bag item has every field, except the id, wrapped in an
+// envelope like:
+//
+//	{
+//	  "encrypted_data": "...",
+//	  "iv": "...",
+//	  "version": 1,
+//	  "cipher": "aes-256-cbc"
+//	}
+//
+// NOTE: version 2 adds an 'hmac' and version 3 an 'auth_tag', the version 0
+// format has no envelope and therefore can't be detected without the secret
+func (dbir *DataBagItemRecord) detectEncryption(content interface{}) {
+	fields, ok := content.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for key, value := range fields {
+		if key == "id" {
+			continue
+		}
+
+		envelope, ok := value.(map[string]interface{})
+		if !ok || !isEncryptedEnvelope(envelope) {
+			continue
+		}
+
+		version := int(envelope["version"].(float64))
+		if !dbir.Encrypted || version < dbir.EncryptionVersion {
+			dbir.EncryptionVersion = version
+			dbir.Cipher = safeStringFromMap(envelope, "cipher")
+		}
+		dbir.Encrypted = true
+	}
+}
+
+func isEncryptedEnvelope(envelope map[string]interface{}) bool {
+	if _, ok := envelope["encrypted_data"].(string); !ok {
+		return false
+	}
+	if _, ok := envelope["iv"]; !ok {
+		return false
+	}
+	if _, ok := envelope["cipher"].(string); !ok {
+		return false
+	}
+	_, ok := envelope["version"].(float64)
+	return ok
+}
@@ -0,0 +1,141 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func TestDataBags(t *testing.T) {
+	dbmock := DataBagMock{desiredItems: map[string]map[string]string{
+		"users": map[string]string{
+			"alice": `{"id": "alice", "shell": "/bin/bash"}`,
+			"bob":   `{"id": "bob", "shell": "/bin/zsh"}`,
+		},
+		"secrets": map[string]string{
+			"aws": `{
+  "id": "aws",
+  "access_key": {"encrypted_data": "abc", "iv": "def", "version": 1, "cipher": "aes-256-cbc"}
+}`,
+			"db": `{
+  "id": "db",
+  "password": {"encrypted_data": "abc", "iv": "def", "hmac": "ghi", "version": 2, "cipher": "aes-256-cbc"},
+  "user": {"encrypted_data": "abc", "iv": "def", "auth_tag": "ghi", "version": 3, "cipher": "aes-256-gcm"}
+}`,
+			"api": `{
+  "id": "api",
+  "token": {"encrypted_data": "abc", "iv": "def", "auth_tag": "ghi", "version": 3, "cipher": "aes-256-gcm"}
+}`,
+			"notes": `{"id": "notes", "text": {"version": 1}}`,
+		},
+		"empty": map[string]string{},
+	}}
+
+	bags, err := subject.NewDataBags(dbmock, Workers)
+	assert.Nil(t, err)
+	if assert.NotNil(t, bags) {
+		assert.Equal(t, 3, bags.TotalDataBags)
+		assert.Equal(t, 6, bags.TotalItems)
+
+		if assert.Equal(t, 3, len(bags.Records)) {
+			empty := bags.Records[0]
+			assert.Equal(t, "empty", empty.Name)
+			assert.Equal(t, 0, empty.NumItems())
+
+			secrets := bags.Records[1]
+			assert.Equal(t, "secrets", secrets.Name)
+			assert.Equal(t, 4, secrets.NumItems())
+			assert.Equal(t, 3, secrets.NumEncrypted())
+			assert.Equal(t, 1, secrets.NumLegacyEncrypted())
+			assert.Empty(t, secrets.Errors())
+
+			api := secrets.Items[0]
+			assert.Equal(t, "api", api.Name)
+			assert.True(t, api.Encrypted)
+			assert.Equal(t, 3, api.EncryptionVersion)
+			assert.Equal(t, "aes-256-gcm", api.Cipher)
+			assert.False(t, api.LegacyEncryption())
+
+			aws := secrets.Items[1]
+			assert.Equal(t, "aws", aws.Name)
+			assert.Equal(t, 1, aws.EncryptionVersion)
+			assert.True(t, aws.LegacyEncryption())
+
+			db := secrets.Items[2]
+			assert.Equal(t, "db", db.Name)
+			assert.Equal(t, 2, db.EncryptionVersion, "the lowest version should be reported")
+			assert.Equal(t, "aes-256-cbc", db.Cipher)
+
+			notes := secrets.Items[3]
+			assert.Equal(t, "notes", notes.Name)
+			assert.False(t, notes.Encrypted, "incomplete envelopes are not encrypted items")
+
+			users := bags.Records[2]
+			assert.Equal(t, "users", users.Name)
+			assert.Equal(t, 2, users.NumItems())
+			assert.Equal(t, 0, users.NumEncrypted())
+		}
+	}
+}
+
+func TestDataBagsEmpty(t *testing.T) {
+	bags, err := subject.NewDataBags(DataBagMock{}, Workers)
+	assert.Nil(t, err)
+	if assert.NotNil(t, bags) {
+		assert.Equal(t, 0, bags.TotalDataBags)
+		assert.Empty(t, bags.Records)
+	}
+}
+
+func TestDataBagsListError(t *testing.T) {
+	bags, err := subject.NewDataBags(DataBagMock{desiredListError: errors.New("list error")}, Workers)
+	assert.Nil(t, bags)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "unable to retrieve data bags: list error", err.Error())
+	}
+}
+
+func TestDataBagsItemErrors(t *testing.T) {
+	dbmock := DataBagMock{
+		desiredItems:          map[string]map[string]string{"users": map[string]string{"alice": `{}`}},
+		desiredListItemsError: errors.New("list items error"),
+	}
+	bags, err := subject.NewDataBags(dbmock, Workers)
+	assert.Nil(t, err)
+	if assert.NotNil(t, bags) && assert.Equal(t, 1, len(bags.Records)) {
+		errs := bags.Records[0].Errors()
+		if assert.Equal(t, 1, len(errs)) {
+			assert.Equal(t, "unable to list items of data bag users: list items error", errs[0].Error())
+		}
+	}
+
+	dbmock.desiredListItemsError = nil
+	dbmock.desiredGetItemError = errors.New("get error")
+	bags, err = subject.NewDataBags(dbmock, Workers)
+	assert.Nil(t, err)
+	if assert.NotNil(t, bags) && assert.Equal(t, 1, len(bags.Records)) {
+		errs := bags.Records[0].Errors()
+		if assert.Equal(t, 1, len(errs)) {
+			assert.Equal(t, "unable to get item alice of data bag users: get error", errs[0].Error())
+		}
+	}
+}
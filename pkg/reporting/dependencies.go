@@ -0,0 +1,381 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	chef "github.com/chef/go-chef"
+	"github.com/cheggaaa/pb/v3"
+	"github.com/pkg/errors"
+)
+
+// DependencyGraph is the graph of the dependencies declared in the metadata
+// of every cookbook version uploaded to the Chef Infra Server
+type DependencyGraph struct {
+	Cookbooks      []*CookbookDependencies
+	CookbooksMutex sync.Mutex
+	TotalCookbooks int
+	// groups of cookbooks that depend on each other, directly or transitively
+	Cycles [][]string
+	// cookbooks that other cookbooks depend on and that, in none of their
+	// versions, depend on anything, the leaves of the dependency graph
+	LeafDependencies []string
+}
+
+// CookbookDependencies is a node of the dependency graph, a cookbook version
+type CookbookDependencies struct {
+	Name         string
+	Version      string
+	Dependencies []*Dependency
+	// cookbook versions that resolve one of their dependencies to this one
+	Dependents []CookbookVersion
+	GetError   error
+}
+
+// Dependency is an edge of the dependency graph, a cookbook declared with
+// 'depends' in the metadata and the uploaded versions that satisfy it
+type Dependency struct {
+	Cookbook   string
+	Constraint string
+	// uploaded versions that satisfy the constraint, latest first
+	Versions   []string
+	ParseError error
+}
+
+// returns true if any uploaded version of the cookbook satisfies the constraint
+func (d *Dependency) Satisfied() bool {
+	return len(d.Versions) != 0
+}
+
+// returns the latest uploaded version that satisfies the constraint, if any
+func (d *Dependency) Resolved() string {
+	if len(d.Versions) == 0 {
+		return ""
+	}
+	return d.Versions[0]
+}
+
+func (cd CookbookDependencies) Errors() []error {
+	errs := make([]error, 0)
+	if cd.GetError != nil {
+		errs = append(errs, cd.GetError)
+	}
+	for _, d := range cd.Dependencies {
+		if d.ParseError != nil {
+			errs = append(errs, d.ParseError)
+		}
+	}
+	return errs
+}
+
+// returns the dependencies that no uploaded version satisfies
+func (cd *CookbookDependencies) Unsatisfied() []*Dependency {
+	unsatisfied := make([]*Dependency, 0)
+	for _, d := range cd.Dependencies {
+		if !d.Satisfied() {
+			unsatisfied = append(unsatisfied, d)
+		}
+	}
+	return unsatisfied
+}
+
+// returns the number of dependencies across the graph that no uploaded version satisfies
+func (dg *DependencyGraph) NumUnsatisfied() int {
+	i := 0
+	for _, cd := range dg.Cookbooks {
+		i += len(cd.Unsatisfied())
+	}
+	return i
+}
+
+// NewDependencyGraph fetches the metadata of every cookbook version and
+// resolves the dependencies it declares against the uploaded versions
+func NewDependencyGraph(cbi CookbookInterface, workers int) (*DependencyGraph, error) {
+	fmt.Printf("Finding available cookbooks...")
+	// Version limit of "0" means fetch all
+	cookbooks, err := cbi.ListAvailableVersions("0")
+	if err != nil {
+		fmt.Println(" (-)")
+		return nil, errors.Wrap(err, "unable to retrieve cookbooks")
+	}
+
+	items := make([]cookbookItem, 0)
+	for name, versions := range cookbooks {
+		for _, ver := range versions.Versions {
+			items = append(items, cookbookItem{name, ver.Version})
+		}
+	}
+
+	graph := &DependencyGraph{
+		Cookbooks:        make([]*CookbookDependencies, 0, len(items)),
+		TotalCookbooks:   len(items),
+		Cycles:           [][]string{},
+		LeafDependencies: []string{},
+	}
+	fmt.Printf(" (%d found)\n", len(items))
+
+	if len(items) == 0 {
+		fmt.Println("No cookbooks available for analysis")
+		return graph, nil
+	}
+
+	numWorkers := len(items)
+	if numWorkers > workers {
+		numWorkers = workers
+	}
+
+	fmt.Println("Finding cookbook dependencies...")
+	var (
+		progress = pb.StartNew(len(items))
+		itemsCh  = make(chan cookbookItem)
+		wg       sync.WaitGroup
+	)
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range itemsCh {
+				graph.addCookbook(fetchDependencies(cbi, item.Name, item.Version, cookbooks))
+				progress.Increment()
+			}
+		}()
+	}
+	for _, item := range items {
+		itemsCh <- item
+	}
+	close(itemsCh)
+	wg.Wait()
+	progress.Finish()
+
+	// workers finish in any order
+	sort.Slice(graph.Cookbooks, func(i, j int) bool {
+		if graph.Cookbooks[i].Name != graph.Cookbooks[j].Name {
+			return graph.Cookbooks[i].Name < graph.Cookbooks[j].Name
+		}
+		return compareVersionStrings(graph.Cookbooks[i].Version, graph.Cookbooks[j].Version) < 0
+	})
+
+	graph.linkDependents()
+	graph.Cycles = graph.findCycles()
+	graph.LeafDependencies = graph.findLeafDependencies()
+
+	return graph, nil
+}
+
+func (dg *DependencyGraph) addCookbook(cd *CookbookDependencies) {
+	dg.CookbooksMutex.Lock()
+	defer dg.CookbooksMutex.Unlock()
+	dg.Cookbooks = append(dg.Cookbooks, cd)
+}
+
+// fetches the metadata of a cookbook version and resolves its dependencies
+func fetchDependencies(cbi CookbookInterface, name, version string,
+	cookbooks chef.CookbookListResult) *CookbookDependencies {
+	cd := &CookbookDependencies{Name: name, Version: version, Dependencies: []*Dependency{}, Dependents: []CookbookVersion{}}
+
+	cookbook, err := cbi.GetVersion(name, version)
+	if err != nil {
+		cd.GetError = errors.Wrapf(err, "unable to get metadata of cookbook %s (%s)", name, version)
+		return cd
+	}
+
+	for dep, str := range cookbook.Metadata.Depends {
+		d := &Dependency{Cookbook: dep, Constraint: str, Versions: []string{}}
+		cd.Dependencies = append(cd.Dependencies, d)
+
+		constraint, err := ParseVersionConstraint(str)
+		if err != nil {
+			d.ParseError = errors.Wrapf(err, "cookbook %s (%s) depends on %s", name, version, dep)
+			continue
+		}
+
+		for _, cbv := range cookbooks[dep].Versions {
+			if constraint.SatisfiedBy(cbv.Version) {
+				d.Versions = append(d.Versions, cbv.Version)
+			}
+		}
+		sort.Slice(d.Versions, func(i, j int) bool {
+			return compareVersionStrings(d.Versions[i], d.Versions[j]) > 0
+		})
+	}
+
+	sort.Slice(cd.Dependencies, func(i, j int) bool {
+		return cd.Dependencies[i].Cookbook < cd.Dependencies[j].Cookbook
+	})
+
+	return cd
+}
+
+// records every cookbook version as a dependent of the versions its dependencies resolve to
+func (dg *DependencyGraph) linkDependents() {
+	index := make(map[string]*CookbookDependencies, len(dg.Cookbooks))
+	for _, cd := range dg.Cookbooks {
+		index[cd.Name+"@"+cd.Version] = cd
+	}
+
+	for _, cd := range dg.Cookbooks {
+		for _, d := range cd.Dependencies {
+			if resolved, ok := index[d.Cookbook+"@"+d.Resolved()]; ok {
+				resolved.Dependents = append(resolved.Dependents, CookbookVersion{Name: cd.Name, Version: cd.Version})
+			}
+		}
+	}
+}
+
+// returns the cookbook names that every cookbook depends on, across all its versions
+func (dg *DependencyGraph) nameEdges() map[string]map[string]bool {
+	edges := map[string]map[string]bool{}
+	for _, cd := range dg.Cookbooks {
+		if _, ok := edges[cd.Name]; !ok {
+			edges[cd.Name] = map[string]bool{}
+		}
+		for _, d := range cd.Dependencies {
+			if d.Satisfied() {
+				edges[cd.Name][d.Cookbook] = true
+			}
+		}
+	}
+	return edges
+}
+
+// finds the strongly connected components of the graph of cookbook names
+// (Tarjan's algorithm), a component with more than one cookbook, or a cookbook
+// that depends on itself, is a circular dependency
+func (dg *DependencyGraph) findCycles() [][]string {
+	var (
+		edges   = dg.nameEdges()
+		index   = map[string]int{}
+		lowlink = map[string]int{}
+		onStack = map[string]bool{}
+		stack   = []string{}
+		cycles  = [][]string{}
+		next    = 0
+		visit   func(name string)
+	)
+
+	visit = func(name string) {
+		index[name] = next
+		lowlink[name] = next
+		next++
+		stack = append(stack, name)
+		onStack[name] = true
+
+		for _, dep := range sortedKeys(edges[name]) {
+			if _, visited := index[dep]; !visited {
+				visit(dep)
+				if lowlink[dep] < lowlink[name] {
+					lowlink[name] = lowlink[dep]
+				}
+			} else if onStack[dep] && index[dep] < lowlink[name] {
+				lowlink[name] = index[dep]
+			}
+		}
+
+		if lowlink[name] != index[name] {
+			return
+		}
+
+		component := []string{}
+		for {
+			member := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[member] = false
+			component = append(component, member)
+			if member == name {
+				break
+			}
+		}
+
+		if len(component) > 1 || edges[name][name] {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, name := range sortedKeys(toSet(edges)) {
+		if _, visited := index[name]; !visited {
+			visit(name)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i][0] < cycles[j][0]
+	})
+
+	return cycles
+}
+
+// finds the cookbooks that other cookbooks depend on but that, in none of
+// their versions, depend on anything
+func (dg *DependencyGraph) findLeafDependencies() []string {
+	var (
+		edges      = dg.nameEdges()
+		declares   = map[string]bool{}
+		dependedOn = map[string]bool{}
+		leaves     = map[string]bool{}
+	)
+
+	for _, cd := range dg.Cookbooks {
+		if len(cd.Dependencies) != 0 {
+			declares[cd.Name] = true
+		}
+	}
+
+	for name, deps := range edges {
+		for dep := range deps {
+			if dep != name {
+				dependedOn[dep] = true
+			}
+		}
+	}
+
+	for name := range dependedOn {
+		if !declares[name] {
+			leaves[name] = true
+		}
+	}
+
+	return sortedKeys(leaves)
+}
+
+func toSet(m map[string]map[string]bool) map[string]bool {
+	set := make(map[string]bool, len(m))
+	for k := range m {
+		set[k] = true
+	}
+	return set
+}
+
+// compares two cookbook versions, versions that can't be parsed are compared as strings
+func compareVersionStrings(a, b string) int {
+	va, errA := ParseVersion(a)
+	vb, errB := ParseVersion(b)
+	if errA != nil || errB != nil {
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return va.Compare(vb)
+}
@@ -0,0 +1,145 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"errors"
+	"testing"
+
+	chef "github.com/chef/go-chef"
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func dependenciesMock() *CookbookMetadataMock {
+	versions := func(vs ...string) chef.CookbookVersions {
+		cbv := chef.CookbookVersions{}
+		for _, v := range vs {
+			cbv.Versions = append(cbv.Versions, chef.CookbookVersion{Version: v})
+		}
+		return cbv
+	}
+
+	return &CookbookMetadataMock{
+		CookbookMock: CookbookMock{desiredCookbookList: chef.CookbookListResult{
+			"a":               versions("1.0.0"),
+			"apache2":         versions("5.0.1", "4.0.0"),
+			"b":               versions("1.0.0"),
+			"broken":          versions("0.1.0"),
+			"build-essential": versions("2.0.0", "10.0.0"),
+			"php":             versions("1.0.0"),
+			"self":            versions("1.0.0"),
+		}},
+		desiredDepends: map[string]map[string]string{
+			"a@1.0.0":                map[string]string{"b": ">= 0.0.0"},
+			"apache2@4.0.0":          map[string]string{},
+			"apache2@5.0.1":          map[string]string{"build-essential": ">= 0.0.0"},
+			"b@1.0.0":                map[string]string{"a": "~> 1.0"},
+			"build-essential@2.0.0":  map[string]string{},
+			"build-essential@10.0.0": map[string]string{},
+			"php@1.0.0": map[string]string{
+				"apache2": "~> 5.0",
+				"mysql":   "~> 1.0",
+				"xml":     "= one",
+			},
+			"self@1.0.0": map[string]string{"self": ">= 0.0.0"},
+			// no metadata for broken
+		},
+	}
+}
+
+func TestDependencyGraph(t *testing.T) {
+	graph, err := subject.NewDependencyGraph(dependenciesMock(), Workers)
+	assert.Nil(t, err)
+	if !assert.NotNil(t, graph) {
+		return
+	}
+
+	assert.Equal(t, 9, graph.TotalCookbooks)
+	assert.Equal(t, 2, graph.NumUnsatisfied())
+	assert.Equal(t, [][]string{{"a", "b"}, {"self"}}, graph.Cycles)
+	assert.Equal(t, []string{"build-essential"}, graph.LeafDependencies)
+
+	if !assert.Equal(t, 9, len(graph.Cookbooks)) {
+		return
+	}
+
+	// versions are sorted semantically
+	assert.Equal(t, "apache2", graph.Cookbooks[1].Name)
+	assert.Equal(t, "4.0.0", graph.Cookbooks[1].Version)
+	assert.Equal(t, "2.0.0", graph.Cookbooks[5].Version)
+	assert.Equal(t, "10.0.0", graph.Cookbooks[6].Version)
+
+	apache2 := graph.Cookbooks[2]
+	if assert.Equal(t, 1, len(apache2.Dependencies)) {
+		dep := apache2.Dependencies[0]
+		assert.Equal(t, "build-essential", dep.Cookbook)
+		assert.True(t, dep.Satisfied())
+		assert.Equal(t, []string{"10.0.0", "2.0.0"}, dep.Versions)
+		assert.Equal(t, "10.0.0", dep.Resolved())
+	}
+	assert.Equal(t, []subject.CookbookVersion{{Name: "php", Version: "1.0.0"}}, apache2.Dependents)
+
+	assert.Empty(t, graph.Cookbooks[5].Dependents)
+	assert.Equal(t,
+		[]subject.CookbookVersion{{Name: "apache2", Version: "5.0.1"}},
+		graph.Cookbooks[6].Dependents)
+
+	broken := graph.Cookbooks[4]
+	assert.Equal(t, "broken", broken.Name)
+	if assert.NotNil(t, broken.GetError) {
+		assert.Contains(t, broken.GetError.Error(), "unable to get metadata of cookbook broken (0.1.0)")
+	}
+
+	php := graph.Cookbooks[7]
+	if assert.Equal(t, 3, len(php.Dependencies)) {
+		assert.Equal(t, "5.0.1", php.Dependencies[0].Resolved())
+		assert.Equal(t, "mysql", php.Dependencies[1].Cookbook)
+		assert.False(t, php.Dependencies[1].Satisfied())
+		assert.Equal(t, "", php.Dependencies[1].Resolved())
+		assert.False(t, php.Dependencies[2].Satisfied())
+		if assert.NotNil(t, php.Dependencies[2].ParseError) {
+			assert.Equal(t,
+				"cookbook php (1.0.0) depends on xml: invalid version constraint '= one'",
+				php.Dependencies[2].ParseError.Error())
+		}
+	}
+	assert.Equal(t, 2, len(php.Unsatisfied()))
+	assert.Equal(t, 1, len(php.Errors()))
+}
+
+func TestDependencyGraph_Empty(t *testing.T) {
+	graph, err := subject.NewDependencyGraph(newMockCookbook(chef.CookbookListResult{}, nil, nil), Workers)
+	assert.Nil(t, err)
+	if assert.NotNil(t, graph) {
+		assert.Equal(t, 0, graph.TotalCookbooks)
+		assert.Empty(t, graph.Cookbooks)
+		assert.Empty(t, graph.Cycles)
+		assert.Empty(t, graph.LeafDependencies)
+	}
+}
+
+func TestDependencyGraph_ListAvailableVersionsError(t *testing.T) {
+	graph, err := subject.NewDependencyGraph(
+		newMockCookbook(chef.CookbookListResult{}, errors.New("server down"), nil), Workers,
+	)
+	assert.Nil(t, graph)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "unable to retrieve cookbooks: server down", err.Error())
+	}
+}
@@ -0,0 +1,261 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// the team of the cookbooks that don't match any team of the effort model
+const UnassignedTeam = "unassigned"
+
+// EffortModel converts the cookstyle offenses of a cookbook version into an
+// estimate of the time required to upgrade it
+//
+// example of an effort model file:
+//
+//	{
+//	  "minutes_per_correctable": 5,
+//	  "minutes_per_manual": 30,
+//	  "minutes_per_cop": {"ChefDeprecations/ResourceUsesOnlyResourceName": 60},
+//	  "node_multipliers": [
+//	    {"min_nodes": 10, "multiplier": 1.5},
+//	    {"min_nodes": 100, "multiplier": 2}
+//	  ],
+//	  "teams": {"web": ["apache2", "nginx*"], "data": ["mysql*"]}
+//	}
+type EffortModel struct {
+	// minutes to fix an offense that cookstyle can auto-correct
+	MinutesPerCorrectable float64 `json:"minutes_per_correctable"`
+	// minutes to fix an offense that has to be corrected manually
+	MinutesPerManual float64 `json:"minutes_per_manual"`
+	// minutes to fix an offense of a specific cop, overrides the above
+	MinutesPerCop map[string]float64 `json:"minutes_per_cop,omitempty"`
+	// multipliers applied to cookbook versions used by many nodes, the one
+	// with the highest minimum number of nodes that is reached applies
+	NodeMultipliers []NodeMultiplier `json:"node_multipliers,omitempty"`
+	// team name -> cookbook names or glob patterns owned by the team
+	Teams map[string][]string `json:"teams,omitempty"`
+}
+
+type NodeMultiplier struct {
+	MinNodes   int     `json:"min_nodes"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// DefaultEffortModel is used when no effort model file is provided, every
+// setting missing in an effort model file defaults to these values
+var DefaultEffortModel = EffortModel{
+	MinutesPerCorrectable: 5,
+	MinutesPerManual:      30,
+}
+
+// LoadEffortModel reads an effort model from the provided JSON file
+func LoadEffortModel(file string) (EffortModel, error) {
+	model := DefaultEffortModel
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return model, errors.Wrapf(err, "unable to read effort model '%s'", file)
+	}
+	if err := json.Unmarshal(content, &model); err != nil {
+		return model, errors.Wrapf(err, "malformed effort model '%s'", file)
+	}
+	if err := model.validate(); err != nil {
+		return model, errors.Wrapf(err, "malformed effort model '%s'", file)
+	}
+
+	return model, nil
+}
+
+func (m EffortModel) validate() error {
+	if m.MinutesPerCorrectable < 0 || m.MinutesPerManual < 0 {
+		return errors.New("minutes can't be negative")
+	}
+	for cop, minutes := range m.MinutesPerCop {
+		if minutes < 0 {
+			return errors.Errorf("minutes of cop %s can't be negative", cop)
+		}
+	}
+	for _, nm := range m.NodeMultipliers {
+		if nm.MinNodes < 0 || nm.Multiplier <= 0 {
+			return errors.Errorf("invalid node multiplier %v for %d nodes", nm.Multiplier, nm.MinNodes)
+		}
+	}
+	for team, patterns := range m.Teams {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return errors.Errorf("invalid cookbook pattern '%s' of team %s", pattern, team)
+			}
+		}
+	}
+	return nil
+}
+
+// returns the minutes required to fix the provided offense
+func (m EffortModel) OffenseMinutes(offense CookstyleOffense) float64 {
+	if minutes, ok := m.MinutesPerCop[offense.CopName]; ok {
+		return minutes
+	}
+	if offense.Correctable {
+		return m.MinutesPerCorrectable
+	}
+	return m.MinutesPerManual
+}
+
+// returns the multiplier that applies to a cookbook version used by the provided number of nodes
+func (m EffortModel) Multiplier(nodes int) float64 {
+	var (
+		multiplier = 1.0
+		reached    = -1
+	)
+	for _, nm := range m.NodeMultipliers {
+		if nodes >= nm.MinNodes && nm.MinNodes > reached {
+			multiplier = nm.Multiplier
+			reached = nm.MinNodes
+		}
+	}
+	return multiplier
+}
+
+// returns the team that owns the provided cookbook, teams are evaluated in
+// alphabetical order and the first one with a matching pattern wins
+func (m EffortModel) TeamOf(cookbook string) string {
+	teams := make([]string, 0, len(m.Teams))
+	for team := range m.Teams {
+		teams = append(teams, team)
+	}
+	sort.Strings(teams)
+
+	for _, team := range teams {
+		for _, pattern := range m.Teams[team] {
+			if matched, _ := path.Match(pattern, cookbook); matched {
+				return team
+			}
+		}
+	}
+	return UnassignedTeam
+}
+
+// EffortEstimate is the ranked backlog of cookbook versions to upgrade
+type EffortEstimate struct {
+	// ranked by hours, the most expensive cookbook version first
+	Cookbooks []*CookbookEffort
+	// ranked by hours, only when the effort model defines teams
+	Teams []*TeamEffort
+	// hours to upgrade every cookbook version of the organization
+	TotalHours float64
+	// cookbook versions that couldn't be analyzed and therefore estimated
+	Unestimated []*CookbookRecord
+}
+
+type CookbookEffort struct {
+	Name        string
+	Version     string
+	Team        string
+	NumOffenses int
+	NumNodes    int
+	// minutes to fix every offense, before applying the multiplier
+	Minutes    float64
+	Multiplier float64
+}
+
+// returns the estimated hours to upgrade the cookbook version
+func (ce *CookbookEffort) Hours() float64 {
+	return ce.Minutes * ce.Multiplier / 60
+}
+
+type TeamEffort struct {
+	Name         string
+	Hours        float64
+	NumCookbooks int
+}
+
+// NewEffortEstimate estimates the hours to upgrade every cookbook version of
+// the provided report with the provided effort model, the report must have
+// been generated running cookstyle
+func NewEffortEstimate(state *CookbooksStatus, model EffortModel) *EffortEstimate {
+	estimate := &EffortEstimate{
+		Cookbooks:   make([]*CookbookEffort, 0),
+		Teams:       make([]*TeamEffort, 0),
+		Unestimated: make([]*CookbookRecord, 0),
+	}
+	if state == nil {
+		return estimate
+	}
+
+	teams := map[string]*TeamEffort{}
+	for _, record := range state.Records {
+		if record.DownloadError != nil || record.CookstyleError != nil {
+			estimate.Unestimated = append(estimate.Unestimated, record)
+			continue
+		}
+
+		effort := &CookbookEffort{
+			Name:        record.Name,
+			Version:     record.Version,
+			Team:        model.TeamOf(record.Name),
+			NumOffenses: record.NumOffenses(),
+			NumNodes:    record.NumNodesAffected(),
+			Multiplier:  model.Multiplier(record.NumNodesAffected()),
+		}
+		for _, file := range record.Files {
+			for _, offense := range file.Offenses {
+				effort.Minutes += model.OffenseMinutes(offense)
+			}
+		}
+		estimate.Cookbooks = append(estimate.Cookbooks, effort)
+		estimate.TotalHours += effort.Hours()
+
+		if len(model.Teams) == 0 {
+			continue
+		}
+		team, ok := teams[effort.Team]
+		if !ok {
+			team = &TeamEffort{Name: effort.Team}
+			teams[effort.Team] = team
+			estimate.Teams = append(estimate.Teams, team)
+		}
+		team.Hours += effort.Hours()
+		team.NumCookbooks++
+	}
+
+	sort.SliceStable(estimate.Cookbooks, func(i, j int) bool {
+		a, b := estimate.Cookbooks[i], estimate.Cookbooks[j]
+		if a.Hours() != b.Hours() {
+			return a.Hours() > b.Hours()
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return compareVersionStrings(a.Version, b.Version) > 0
+	})
+	sort.SliceStable(estimate.Teams, func(i, j int) bool {
+		a, b := estimate.Teams[i], estimate.Teams[j]
+		if a.Hours != b.Hours {
+			return a.Hours > b.Hours
+		}
+		return a.Name < b.Name
+	})
+
+	return estimate
+}
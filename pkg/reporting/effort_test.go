@@ -0,0 +1,175 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func cookbookRecordWithOffenses(name, version string, nodes int, offenses ...subject.CookstyleOffense) *subject.CookbookRecord {
+	record := &subject.CookbookRecord{Name: name, Version: version,
+		Files: []subject.CookbookFile{subject.CookbookFile{Path: "recipes/default.rb", Offenses: offenses}},
+	}
+	for i := 0; i < nodes; i++ {
+		record.Nodes = append(record.Nodes, "node")
+	}
+	return record
+}
+
+func TestEffortModel(t *testing.T) {
+	model := subject.EffortModel{
+		MinutesPerCorrectable: 5,
+		MinutesPerManual:      30,
+		MinutesPerCop:         map[string]float64{"Chef/Slow": 120},
+		NodeMultipliers: []subject.NodeMultiplier{
+			{MinNodes: 100, Multiplier: 2},
+			{MinNodes: 10, Multiplier: 1.5},
+		},
+		Teams: map[string][]string{"web": {"apache2", "nginx*"}, "all": {"nginx-*"}},
+	}
+
+	assert.Equal(t, float64(5), model.OffenseMinutes(subject.CookstyleOffense{CopName: "Chef/A", Correctable: true}))
+	assert.Equal(t, float64(30), model.OffenseMinutes(subject.CookstyleOffense{CopName: "Chef/A"}))
+	assert.Equal(t, float64(120), model.OffenseMinutes(subject.CookstyleOffense{CopName: "Chef/Slow", Correctable: true}),
+		"the minutes of a cop should override the defaults")
+
+	assert.Equal(t, float64(1), model.Multiplier(9))
+	assert.Equal(t, 1.5, model.Multiplier(10))
+	assert.Equal(t, float64(2), model.Multiplier(250), "the highest multiplier reached should apply")
+
+	assert.Equal(t, "web", model.TeamOf("apache2"))
+	assert.Equal(t, "all", model.TeamOf("nginx-proxy"), "teams should be evaluated in alphabetical order")
+	assert.Equal(t, subject.UnassignedTeam, model.TeamOf("mysql"))
+}
+
+func TestNewEffortEstimate(t *testing.T) {
+	var (
+		correctable = subject.CookstyleOffense{CopName: "Chef/A", Correctable: true}
+		manual      = subject.CookstyleOffense{CopName: "Chef/B"}
+		model       = subject.EffortModel{
+			MinutesPerCorrectable: 6,
+			MinutesPerManual:      60,
+			NodeMultipliers:       []subject.NodeMultiplier{{MinNodes: 2, Multiplier: 2}},
+			Teams:                 map[string][]string{"web": {"apache2"}},
+		}
+		state = &subject.CookbooksStatus{
+			Records: []*subject.CookbookRecord{
+				cookbookRecordWithOffenses("apache2", "1.0.0", 1, correctable, correctable),
+				cookbookRecordWithOffenses("apache2", "2.0.0", 3, manual),
+				cookbookRecordWithOffenses("mysql", "1.0.0", 0, manual, correctable),
+				cookbookRecordWithOffenses("clean", "1.0.0", 5),
+				&subject.CookbookRecord{Name: "broken", Version: "0.1.0", DownloadError: errors.New("not found")},
+			},
+		}
+	)
+
+	estimate := subject.NewEffortEstimate(state, model)
+	if assert.Equal(t, 4, len(estimate.Cookbooks)) {
+		assert.Equal(t, "apache2", estimate.Cookbooks[0].Name)
+		assert.Equal(t, "2.0.0", estimate.Cookbooks[0].Version)
+		assert.Equal(t, "web", estimate.Cookbooks[0].Team)
+		assert.Equal(t, float64(2), estimate.Cookbooks[0].Multiplier)
+		assert.Equal(t, float64(2), estimate.Cookbooks[0].Hours())
+
+		assert.Equal(t, "mysql", estimate.Cookbooks[1].Name)
+		assert.Equal(t, subject.UnassignedTeam, estimate.Cookbooks[1].Team)
+		assert.InDelta(t, 1.1, estimate.Cookbooks[1].Hours(), 0.001)
+
+		assert.Equal(t, "apache2", estimate.Cookbooks[2].Name)
+		assert.Equal(t, "1.0.0", estimate.Cookbooks[2].Version)
+		assert.InDelta(t, 0.2, estimate.Cookbooks[2].Hours(), 0.001)
+
+		assert.Equal(t, "clean", estimate.Cookbooks[3].Name)
+		assert.Equal(t, float64(0), estimate.Cookbooks[3].Hours())
+	}
+
+	assert.InDelta(t, 3.3, estimate.TotalHours, 0.001)
+	if assert.Equal(t, 2, len(estimate.Teams)) {
+		assert.Equal(t, "web", estimate.Teams[0].Name)
+		assert.InDelta(t, 2.2, estimate.Teams[0].Hours, 0.001)
+		assert.Equal(t, 2, estimate.Teams[0].NumCookbooks)
+		assert.Equal(t, subject.UnassignedTeam, estimate.Teams[1].Name)
+		assert.Equal(t, 2, estimate.Teams[1].NumCookbooks)
+	}
+	if assert.Equal(t, 1, len(estimate.Unestimated)) {
+		assert.Equal(t, "broken", estimate.Unestimated[0].Name)
+	}
+
+	estimate = subject.NewEffortEstimate(state, subject.DefaultEffortModel)
+	assert.Empty(t, estimate.Teams, "teams are only rolled up when the model defines them")
+}
+
+func TestNewEffortEstimateNil(t *testing.T) {
+	estimate := subject.NewEffortEstimate(nil, subject.DefaultEffortModel)
+	assert.Empty(t, estimate.Cookbooks)
+	assert.Equal(t, float64(0), estimate.TotalHours)
+}
+
+func TestLoadEffortModel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "effort-model")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "effort.json")
+	err = ioutil.WriteFile(path, []byte(`{
+  "minutes_per_manual": 45,
+  "minutes_per_cop": {"Chef/Slow": 120},
+  "node_multipliers": [{"min_nodes": 10, "multiplier": 1.5}],
+  "teams": {"web": ["nginx*"]}
+}`), 0644)
+	if assert.Nil(t, err) {
+		model, err := subject.LoadEffortModel(path)
+		assert.Nil(t, err)
+		assert.Equal(t, subject.EffortModel{
+			MinutesPerCorrectable: subject.DefaultEffortModel.MinutesPerCorrectable,
+			MinutesPerManual:      45,
+			MinutesPerCop:         map[string]float64{"Chef/Slow": 120},
+			NodeMultipliers:       []subject.NodeMultiplier{{MinNodes: 10, Multiplier: 1.5}},
+			Teams:                 map[string][]string{"web": {"nginx*"}},
+		}, model)
+	}
+
+	_, err = subject.LoadEffortModel(filepath.Join(dir, "missing.json"))
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "unable to read effort model")
+	}
+
+	for content, message := range map[string]string{
+		`{"minutes_per_manual": "a lot"}`:           "malformed effort model",
+		`{"minutes_per_correctable": -1}`:           "minutes can't be negative",
+		`{"node_multipliers": [{"min_nodes": 10}]}`: "invalid node multiplier 0 for 10 nodes",
+		`{"teams": {"web": ["[nginx"]}}`:            "invalid cookbook pattern '[nginx' of team web",
+		`{"minutes_per_cop": {"Chef/Slow": -5}}`:    "minutes of cop Chef/Slow can't be negative",
+	} {
+		if assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644)) {
+			_, err = subject.LoadEffortModel(path)
+			if assert.NotNil(t, err, content) {
+				assert.Contains(t, err.Error(), message)
+			}
+		}
+	}
+}
@@ -0,0 +1,310 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// files of an Effortless package
+const (
+	EffortlessPolicyfile = "Policyfile.rb"
+	EffortlessPlan       = "habitat/plan.sh"
+	EffortlessKitchen    = "kitchen.yml"
+)
+
+// the kitchen platform used when the platform of the nodes is unknown
+const defaultKitchenPlatform = "ubuntu-18.04"
+
+// EffortlessPackage is an Effortless Infra package generated from the expanded
+// run list of a node or a role, its cookbooks are pinned to the versions in use
+type EffortlessPackage struct {
+	// name of the policy and the Habitat package
+	Name string
+	// what the package was generated from, like "node 'web01'" or "role 'base'"
+	Source        string
+	Origin        string
+	ChefServerURL string
+	// the expanded run list, only recipes
+	RunList   []RunListItem
+	Cookbooks []CookbookVersion
+	// kitchen platform, like 'ubuntu-18.04'
+	Platform string
+}
+
+var invalidPackageNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// policy and Habitat package names only allow letters, numbers, dashes and underscores
+func effortlessPackageName(name string) string {
+	return strings.Trim(invalidPackageNameChars.ReplaceAllString(name, "-"), "-")
+}
+
+// generates an Effortless package from the run list of a node, cookbooks are
+// pinned to the versions the node applied in its last Chef Infra Client run
+func NewEffortlessFromNode(searcher SearchInterface, roles RoleRunListsInterface, cbi CookbookInterface,
+	name string) (*EffortlessPackage, error) {
+
+	var (
+		query = map[string]interface{}{
+			"name":             []string{"name"},
+			"chef_environment": []string{"chef_environment"},
+			"run_list":         []string{"run_list"},
+			"platform":         []string{"platform"},
+			"platform_version": []string{"platform_version"},
+			"policy_name":      []string{"policy_name"},
+			"cookbooks":        []string{"cookbooks"},
+		}
+		search = NewPartialSearch(searcher, "node", fmt.Sprintf("name:%s", searchSpecialCharsReplacer.Replace(name)), query)
+		node   map[string]interface{}
+	)
+	for search.Next() {
+		for _, element := range search.Page() {
+			v, ok := element.(map[string]interface{})["data"].(map[string]interface{})
+			if ok && safeStringFromMap(v, "name") == name {
+				node = v
+			}
+		}
+	}
+	if err := search.Err(); err != nil {
+		return nil, errors.Wrapf(err, "unable to get node %s", name)
+	}
+	if node == nil {
+		return nil, errors.Errorf("node '%s' not found", name)
+	}
+
+	if policy := safeStringFromMap(node, "policy_name"); policy != "" {
+		return nil, errors.Errorf("node '%s' already uses the policy '%s'", name, policy)
+	}
+
+	runList := make([]string, 0)
+	if items, ok := node["run_list"].([]interface{}); ok {
+		for _, item := range items {
+			if s, ok := item.(string); ok {
+				runList = append(runList, s)
+			}
+		}
+	}
+
+	pinned := map[string]string{}
+	if cookbooks, ok := node["cookbooks"].(map[string]interface{}); ok {
+		for cookbook, details := range cookbooks {
+			if detailsMap, ok := details.(map[string]interface{}); ok {
+				pinned[cookbook] = safeStringFromMap(detailsMap, "version")
+			}
+		}
+	}
+
+	pkg := &EffortlessPackage{
+		Name:     effortlessPackageName(name),
+		Source:   fmt.Sprintf("node '%s'", name),
+		Platform: defaultKitchenPlatform,
+	}
+	if platform := safeStringFromMap(node, "platform"); platform != "" {
+		pkg.Platform = strings.TrimSuffix(platform+"-"+safeStringFromMap(node, "platform_version"), "-")
+	}
+
+	return pkg, pkg.resolve(roles, cbi, safeStringFromMap(node, "chef_environment"), runList, pinned)
+}
+
+// generates an Effortless package from the run list of a role, cookbooks are
+// pinned to the latest version available on the Chef Infra Server
+func NewEffortlessFromRole(roles RoleRunListsInterface, cbi CookbookInterface, name string) (*EffortlessPackage, error) {
+	role, err := roles.GetRunLists(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get role %s", name)
+	}
+
+	pkg := &EffortlessPackage{
+		Name:     effortlessPackageName(name),
+		Source:   fmt.Sprintf("role '%s'", name),
+		Platform: defaultKitchenPlatform,
+	}
+
+	return pkg, pkg.resolve(roles, cbi, "", role.RunList, map[string]string{})
+}
+
+// expands the run list in the provided environment and pins every cookbook,
+// cookbooks without a pinned version are pinned to the latest version available
+func (ep *EffortlessPackage) resolve(roles RoleRunListsInterface, cbi CookbookInterface,
+	environment string, runList []string, pinned map[string]string) error {
+
+	expansion := ExpandRunList(roles, environment, runList)
+	if len(expansion.Errors) != 0 {
+		return errors.Wrap(expansion.Errors[0], "unable to expand run list")
+	}
+	if len(expansion.MissingRoles) != 0 {
+		return errors.Errorf("role(s) not found on the Chef Infra Server: %s", strings.Join(expansion.MissingRoles, ", "))
+	}
+	if len(expansion.Recipes) == 0 {
+		return errors.Errorf("the run list of %s is empty", ep.Source)
+	}
+
+	recipes := make([]RunListItem, 0, len(expansion.Recipes))
+	for _, recipe := range expansion.Recipes {
+		recipes = append(recipes, recipe.RunListItem)
+	}
+	ep.RunList = recipes
+
+	latest, err := cbi.ListAvailableVersions("1")
+	if err != nil {
+		return errors.Wrap(err, "unable to retrieve cookbooks")
+	}
+
+	// recipes pinned in the run list take precedence
+	for _, recipe := range recipes {
+		if recipe.Version != "" {
+			pinned[recipe.Cookbook()] = recipe.Version
+		}
+	}
+
+	missing := make([]string, 0)
+	for _, recipe := range recipes {
+		cookbook := recipe.Cookbook()
+		if pinned[cookbook] != "" {
+			continue
+		}
+		versions, ok := latest[cookbook]
+		if !ok || len(versions.Versions) == 0 {
+			missing = append(missing, cookbook)
+			continue
+		}
+		pinned[cookbook] = versions.Versions[0].Version
+	}
+	if len(missing) != 0 {
+		return errors.Errorf("cookbook(s) not found on the Chef Infra Server: %s", strings.Join(missing, ", "))
+	}
+
+	ep.Cookbooks = make([]CookbookVersion, 0, len(pinned))
+	for cookbook, version := range pinned {
+		if version == "" {
+			continue
+		}
+		ep.Cookbooks = append(ep.Cookbooks, CookbookVersion{Name: cookbook, Version: version})
+	}
+	sort.Slice(ep.Cookbooks, func(i, j int) bool {
+		return ep.Cookbooks[i].Name < ep.Cookbooks[j].Name
+	})
+
+	return nil
+}
+
+func (ep *EffortlessPackage) Policyfile() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("# Policyfile generated by chef-analyze from the %s\n", ep.Source))
+	b.WriteString("#\n# https://docs.chef.io/policyfile.html\n")
+	b.WriteString(fmt.Sprintf("name '%s'\n\n", ep.Name))
+	if ep.ChefServerURL != "" {
+		b.WriteString(fmt.Sprintf("default_source :chef_server, '%s'\n\n", ep.ChefServerURL))
+	} else {
+		b.WriteString("default_source :supermarket\n\n")
+	}
+
+	recipes := make([]string, 0, len(ep.RunList))
+	for _, recipe := range ep.RunList {
+		recipes = append(recipes, fmt.Sprintf("'%s'", recipe.Recipe()))
+	}
+	b.WriteString(fmt.Sprintf("run_list %s\n\n", strings.Join(recipes, ", ")))
+
+	b.WriteString("# cookbook versions in use\n")
+	for _, cookbook := range ep.Cookbooks {
+		b.WriteString(fmt.Sprintf("cookbook '%s', '= %s'\n", cookbook.Name, cookbook.Version))
+	}
+	return b.String()
+}
+
+func (ep *EffortlessPackage) PlanSh() string {
+	return fmt.Sprintf(`# Effortless Infra package generated by chef-analyze from the %s
+#
+# https://github.com/chef/effortless
+pkg_name=%s
+pkg_origin=%s
+pkg_version="0.1.0"
+pkg_maintainer="The Chef Maintainers <humans@chef.io>"
+pkg_description="The Chef Infra policy of the %s"
+pkg_license=("Apache-2.0")
+pkg_scaffolding="chef/scaffolding-chef-infra"
+pkg_svc_user=("root")
+
+scaffold_policy_name="Policyfile"
+scaffold_policyfile_path="$PLAN_CONTEXT/.."
+`, ep.Source, ep.Name, ep.Origin, ep.Source)
+}
+
+func (ep *EffortlessPackage) KitchenYml() string {
+	return fmt.Sprintf(`---
+# Test Kitchen configuration generated by chef-analyze from the %s
+#
+# https://docs.chef.io/config_yml_kitchen.html
+driver:
+  name: vagrant
+
+provisioner:
+  name: chef_zero
+  policyfile_path: %s
+
+verifier:
+  name: inspec
+
+platforms:
+  - name: %s
+
+suites:
+  - name: default
+`, ep.Source, EffortlessPolicyfile, ep.Platform)
+}
+
+// writes the files of the package inside the provided directory and returns
+// their paths, a directory that is not empty is only overwritten when forced
+func (ep *EffortlessPackage) Write(dir string, force bool) ([]string, error) {
+	if entries, err := ioutil.ReadDir(dir); err == nil && len(entries) != 0 && !force {
+		return nil, errors.Errorf("directory '%s' is not empty, use --force to overwrite it", dir)
+	}
+
+	var (
+		files = []struct {
+			path    string
+			content string
+			mode    os.FileMode
+		}{
+			{EffortlessPolicyfile, ep.Policyfile(), 0644},
+			{EffortlessPlan, ep.PlanSh(), 0755},
+			{EffortlessKitchen, ep.KitchenYml(), 0644},
+		}
+		written = make([]string, 0, len(files))
+	)
+
+	for _, file := range files {
+		p := filepath.Join(dir, filepath.FromSlash(file.path))
+		if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+			return written, errors.Wrapf(err, "unable to create directory '%s'", filepath.Dir(p))
+		}
+		if err := ioutil.WriteFile(p, []byte(file.content), file.mode); err != nil {
+			return written, errors.Wrapf(err, "unable to write '%s'", p)
+		}
+		written = append(written, p)
+	}
+
+	return written, nil
+}
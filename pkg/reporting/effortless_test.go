@@ -0,0 +1,209 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	chef "github.com/chef/go-chef"
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func effortlessMocks() (RoleRunListsMock, *CookbookMock) {
+	roles := RoleRunListsMock{desiredRunLists: map[string]subject.RoleRunLists{
+		"base": subject.RoleRunLists{Name: "base",
+			RunList: []string{"recipe[ntp]"},
+			EnvRunLists: map[string][]string{
+				"production": []string{"recipe[ntp]", "recipe[audit]"},
+			},
+		},
+		"webserver": subject.RoleRunLists{Name: "webserver", RunList: []string{"role[base]", "recipe[apache2::mod_ssl]"}},
+	}}
+	cookbooks := newMockCookbook(chef.CookbookListResult{
+		"ntp":     chef.CookbookVersions{Versions: []chef.CookbookVersion{chef.CookbookVersion{Version: "3.7.0"}}},
+		"apache2": chef.CookbookVersions{Versions: []chef.CookbookVersion{chef.CookbookVersion{Version: "8.0.0"}}},
+	}, nil, nil)
+	return roles, cookbooks
+}
+
+func TestNewEffortlessFromNode(t *testing.T) {
+	roles, cookbooks := effortlessMocks()
+	searcher := makeMockSearch(`[
+  {
+    "data": {
+      "name": "web01.example.com",
+      "run_list": ["role[webserver]", "recipe[app]"],
+      "platform": "centos",
+      "platform_version": "7.6",
+      "cookbooks": {
+        "ntp": { "version": "3.6.2" },
+        "apache2": { "version": "5.0.1" },
+        "app": { "version": "1.2.3" },
+        "build-essential": { "version": "8.2.1" }
+      }
+    }
+  }
+]`, nil)
+
+	pkg, err := subject.NewEffortlessFromNode(searcher, roles, cookbooks, "web01.example.com")
+	if !assert.Nil(t, err) {
+		return
+	}
+	pkg.Origin = "my_origin"
+	pkg.ChefServerURL = "https://chef.example.com/organizations/acme"
+
+	assert.Equal(t, "web01-example-com", pkg.Name)
+	assert.Equal(t, "centos-7.6", pkg.Platform)
+	assert.Equal(t, `# Policyfile generated by chef-analyze from the node 'web01.example.com'
+#
+# https://docs.chef.io/policyfile.html
+name 'web01-example-com'
+
+default_source :chef_server, 'https://chef.example.com/organizations/acme'
+
+run_list 'ntp::default', 'apache2::mod_ssl', 'app::default'
+
+# cookbook versions in use
+cookbook 'apache2', '= 5.0.1'
+cookbook 'app', '= 1.2.3'
+cookbook 'build-essential', '= 8.2.1'
+cookbook 'ntp', '= 3.6.2'
+`, pkg.Policyfile())
+	assert.Contains(t, pkg.PlanSh(), "pkg_name=web01-example-com\npkg_origin=my_origin\n")
+	assert.Contains(t, pkg.PlanSh(), `pkg_scaffolding="chef/scaffolding-chef-infra"`)
+	assert.Contains(t, pkg.KitchenYml(), "  - name: centos-7.6\n")
+}
+
+func TestNewEffortlessFromNodeErrors(t *testing.T) {
+	roles, cookbooks := effortlessMocks()
+
+	_, err := subject.NewEffortlessFromNode(makeMockSearch("[]", nil), roles, cookbooks, "web01")
+	assert.EqualError(t, err, "node 'web01' not found")
+
+	_, err = subject.NewEffortlessFromNode(
+		makeMockSearch(`[{"data": {"name": "web01", "policy_name": "app", "run_list": []}}]`, nil),
+		roles, cookbooks, "web01",
+	)
+	assert.EqualError(t, err, "node 'web01' already uses the policy 'app'")
+
+	_, err = subject.NewEffortlessFromNode(
+		makeMockSearch(`[{"data": {"name": "web01", "run_list": ["recipe[missing]", "role[base]"]}}]`, nil),
+		roles, cookbooks, "web01",
+	)
+	assert.EqualError(t, err, "cookbook(s) not found on the Chef Infra Server: missing")
+
+	_, err = subject.NewEffortlessFromNode(
+		makeMockSearch(`[{"data": {"name": "web01", "run_list": ["recipe[ntp]", "role[ghost]"]}}]`, nil),
+		roles, cookbooks, "web01",
+	)
+	assert.EqualError(t, err, "role(s) not found on the Chef Infra Server: ghost")
+
+	_, err = subject.NewEffortlessFromNode(
+		makeMockSearch(`[{"data": {"name": "web01", "run_list": []}}]`, nil),
+		roles, cookbooks, "web01",
+	)
+	assert.EqualError(t, err, "the run list of node 'web01' is empty")
+}
+
+func TestNewEffortlessFromNodeEnvironment(t *testing.T) {
+	roles, cookbooks := effortlessMocks()
+	cookbooks.desiredCookbookList["audit"] = chef.CookbookVersions{
+		Versions: []chef.CookbookVersion{chef.CookbookVersion{Version: "7.0.0"}},
+	}
+	searcher := makeMockSearch(`[{"data": {"name": "web01", "chef_environment": "production", "run_list": ["role[webserver]"]}}]`, nil)
+
+	pkg, err := subject.NewEffortlessFromNode(searcher, roles, cookbooks, "web01")
+	if assert.Nil(t, err) {
+		assert.Equal(t, []subject.RunListItem{
+			subject.RunListItem{Type: "recipe", Name: "ntp"},
+			subject.RunListItem{Type: "recipe", Name: "audit"},
+			subject.RunListItem{Type: "recipe", Name: "apache2::mod_ssl"},
+		}, pkg.RunList, "the production run list of the role base should be used")
+	}
+}
+
+func TestNewEffortlessFromNodeEscapesName(t *testing.T) {
+	roles, cookbooks := effortlessMocks()
+	searcher := makeMockPaginatedSearch(
+		`[{"data": {"name": "web-01:prod", "run_list": ["recipe[app]"], "platform": "centos", "platform_version": "7.6",
+			"cookbooks": {"app": {"version": "1.2.3"}}}}]`, 0,
+	)
+
+	pkg, err := subject.NewEffortlessFromNode(searcher, roles, cookbooks, "web-01:prod")
+	if assert.Nil(t, err) {
+		assert.Equal(t, `name:web\-01\:prod`, searcher.requestedStatement)
+		assert.Equal(t, "web-01-prod", pkg.Name)
+	}
+}
+
+func TestNewEffortlessFromRole(t *testing.T) {
+	roles, cookbooks := effortlessMocks()
+
+	pkg, err := subject.NewEffortlessFromRole(roles, cookbooks, "webserver")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "webserver", pkg.Name)
+	assert.Equal(t, "ubuntu-18.04", pkg.Platform)
+	assert.Equal(t, []subject.CookbookVersion{
+		subject.CookbookVersion{Name: "apache2", Version: "8.0.0"},
+		subject.CookbookVersion{Name: "ntp", Version: "3.7.0"},
+	}, pkg.Cookbooks)
+
+	_, err = subject.NewEffortlessFromRole(roles, cookbooks, "missing")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "unable to get role missing: ")
+	}
+}
+
+func TestEffortlessPackageWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "effortless")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	roles, cookbooks := effortlessMocks()
+	pkg, err := subject.NewEffortlessFromRole(roles, cookbooks, "base")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	files, err := pkg.Write(dir, false)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{
+			filepath.Join(dir, "Policyfile.rb"),
+			filepath.Join(dir, "habitat", "plan.sh"),
+			filepath.Join(dir, "kitchen.yml"),
+		}, files)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "Policyfile.rb"))
+	assert.Nil(t, err)
+	assert.Equal(t, pkg.Policyfile(), string(content))
+
+	_, err = pkg.Write(dir, false)
+	assert.EqualError(t, err, "directory '"+dir+"' is not empty, use --force to overwrite it")
+
+	_, err = pkg.Write(dir, true)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,290 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"fmt"
+	"sort"
+
+	chef "github.com/chef/go-chef"
+	"github.com/pkg/errors"
+)
+
+type EnvironmentsStatus struct {
+	Records           []*EnvironmentRecord
+	TotalEnvironments int
+	// total number of nodes analyzed to find the environments usage
+	TotalNodes   int
+	RunCookstyle bool
+}
+
+type EnvironmentRecord struct {
+	Name        string
+	Description string
+	Constraints []*ConstraintRecord
+	// the cookbook versions the Chef Infra Server would pick for the
+	// constrained cookbooks and their dependencies
+	Solution   *Solution
+	NumNodes   int
+	GetError   error
+	SolveError error
+}
+
+func (er EnvironmentRecord) Errors() []error {
+	errs := make([]error, 0)
+	if er.GetError != nil {
+		errs = append(errs, er.GetError)
+	}
+	if er.SolveError != nil {
+		errs = append(errs, er.SolveError)
+	}
+	for _, c := range er.Constraints {
+		if c.ParseError != nil {
+			errs = append(errs, c.ParseError)
+		}
+		if c.Cookstyle != nil {
+			errs = append(errs, c.Cookstyle.Errors()...)
+		}
+	}
+	return errs
+}
+
+// returns the number of cookbook constraints that no uploaded version satisfies
+func (er *EnvironmentRecord) NumUnsatisfied() int {
+	i := 0
+	for _, c := range er.Constraints {
+		if !c.Satisfied() {
+			i++
+		}
+	}
+	return i
+}
+
+// returns true if the constraints of the environment, together with the
+// dependencies of the cookbooks they pin, can be resolved
+func (er *EnvironmentRecord) Solvable() bool {
+	return er.Solution != nil && er.Solution.Solved()
+}
+
+// ConstraintRecord is a cookbook version constraint of an environment
+// (cookbook_versions) and the version of the cookbook it resolves to
+type ConstraintRecord struct {
+	Cookbook   string
+	Constraint string
+	// the latest uploaded version that satisfies the constraint, if any
+	PinnedVersion string
+	// the analysis of the pinned version, only when cookstyle was run
+	Cookstyle  *CookbookRecord
+	ParseError error
+}
+
+// returns true if any uploaded version of the cookbook satisfies the constraint
+func (cr *ConstraintRecord) Satisfied() bool {
+	return cr.PinnedVersion != ""
+}
+
+// NewEnvironments analyzes the cookbook constraints of every environment and
+// solves them together with the dependencies of the pinned cookbooks, when
+// runCookstyle is true, the pinned cookbook versions are verified with cookstyle
+// and the provided overrides are applied to that cookbooks analysis
+func NewEnvironments(envs EnvironmentInterface, cbi CookbookInterface, searcher SearchInterface,
+	runCookstyle bool, workers int, overrides ...CookbooksOverrideFunc) (*EnvironmentsStatus, error) {
+
+	fmt.Printf("Finding available environments...")
+	envList, err := envs.List()
+	if err != nil {
+		fmt.Println(" (-)")
+		return nil, errors.Wrap(err, "unable to retrieve environments")
+	}
+
+	status := &EnvironmentsStatus{
+		Records:           make([]*EnvironmentRecord, 0, len(*envList)),
+		TotalEnvironments: len(*envList),
+		RunCookstyle:      runCookstyle,
+	}
+	fmt.Printf(" (%d found)\n", status.TotalEnvironments)
+
+	if status.TotalEnvironments == 0 {
+		fmt.Println("No environments available for analysis")
+		return status, nil
+	}
+
+	// Version limit of "0" means fetch all
+	cookbooks, err := cbi.ListAvailableVersions("0")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve cookbooks")
+	}
+
+	fmt.Printf("Finding environments usage...")
+	usage, totalNodes, err := environmentsUsage(searcher)
+	if err != nil {
+		fmt.Println(" (-)")
+		return nil, err
+	}
+	status.TotalNodes = totalNodes
+	fmt.Printf(" (%d nodes found)\n", totalNodes)
+
+	fmt.Println("Analyzing environments...")
+	solver := NewSolver(cbi, cookbooks)
+	for name := range *envList {
+		record := &EnvironmentRecord{Name: name, NumNodes: usage[name]}
+		status.Records = append(status.Records, record)
+
+		env, err := envs.Get(name)
+		if err != nil {
+			record.GetError = errors.Wrapf(err, "unable to get environment %s", name)
+			continue
+		}
+
+		record.Description = env.Description
+		record.Constraints = resolveConstraints(env.CookbookVersions, cookbooks)
+
+		pinned := make([]string, 0, len(record.Constraints))
+		for _, c := range record.Constraints {
+			pinned = append(pinned, c.Cookbook)
+		}
+		record.Solution, err = solver.Solve(pinned, env.CookbookVersions, "environment "+name)
+		if err != nil {
+			record.SolveError = errors.Wrapf(err, "unable to solve the constraints of environment %s", name)
+		}
+	}
+
+	sort.Slice(status.Records, func(i, j int) bool {
+		return status.Records[i].Name < status.Records[j].Name
+	})
+
+	if runCookstyle {
+		err = status.verifyPinnedVersions(cbi, searcher, workers, overrides)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return status, nil
+}
+
+// resolves every cookbook constraint to the latest uploaded version that satisfies it
+func resolveConstraints(cookbookVersions map[string]string, cookbooks chef.CookbookListResult) []*ConstraintRecord {
+	constraints := make([]*ConstraintRecord, 0, len(cookbookVersions))
+
+	for cookbook, str := range cookbookVersions {
+		record := &ConstraintRecord{Cookbook: cookbook, Constraint: str}
+		constraints = append(constraints, record)
+
+		constraint, err := ParseVersionConstraint(str)
+		if err != nil {
+			record.ParseError = errors.Wrapf(err, "cookbook %s", cookbook)
+			continue
+		}
+
+		var pinned *Version
+		for _, cbv := range cookbooks[cookbook].Versions {
+			v, err := ParseVersion(cbv.Version)
+			if err != nil || !constraint.Satisfies(v) {
+				continue
+			}
+			if pinned == nil || v.Compare(*pinned) > 0 {
+				pinned = &v
+				record.PinnedVersion = cbv.Version
+			}
+		}
+	}
+
+	sort.Slice(constraints, func(i, j int) bool {
+		return constraints[i].Cookbook < constraints[j].Cookbook
+	})
+
+	return constraints
+}
+
+// runs cookstyle against every pinned cookbook version, reusing the cookbooks analysis
+func (es *EnvironmentsStatus) verifyPinnedVersions(cbi CookbookInterface, searcher SearchInterface, workers int,
+	overrides []CookbooksOverrideFunc) error {
+	filters := make([]CookbookFilter, 0)
+	seen := map[string]bool{}
+	for _, env := range es.Records {
+		for _, c := range env.Constraints {
+			if !c.Satisfied() || seen[c.Cookbook+"@"+c.PinnedVersion] {
+				continue
+			}
+			seen[c.Cookbook+"@"+c.PinnedVersion] = true
+
+			pin, _ := ParseVersionConstraint(c.PinnedVersion)
+			filters = append(filters, CookbookFilter{Pattern: c.Cookbook, Constraints: []VersionConstraint{pin}})
+		}
+	}
+
+	if len(filters) == 0 {
+		return nil
+	}
+
+	fmt.Println("Verifying pinned cookbook versions...")
+	overrides = append(overrides, func(cbs *CookbooksStatus) {
+		cbs.Filters = filters
+		// pinned versions must be verified even if no node is using them
+		cbs.AnalyzeAll = true
+	})
+	cookbooksState, err := NewCookbooks(cbi, searcher, true, false, workers, overrides...)
+	if err != nil {
+		return err
+	}
+
+	verified := map[string]*CookbookRecord{}
+	for _, record := range cookbooksState.Records {
+		verified[record.Name+"@"+record.Version] = record
+	}
+
+	for _, env := range es.Records {
+		for _, c := range env.Constraints {
+			if c.Satisfied() {
+				c.Cookstyle = verified[c.Cookbook+"@"+c.PinnedVersion]
+			}
+		}
+	}
+
+	return nil
+}
+
+// counts the number of nodes in every environment with a single sweep
+// of all the nodes, it also returns the total number of nodes
+func environmentsUsage(searcher SearchInterface) (map[string]int, int, error) {
+	var (
+		usage      = map[string]int{}
+		totalNodes = 0
+		query      = map[string]interface{}{
+			"chef_environment": []string{"chef_environment"},
+		}
+		search = NewPartialSearch(searcher, "node", "*:*", query)
+	)
+
+	for search.Next() {
+		for _, element := range search.Page() {
+			v := element.(map[string]interface{})["data"].(map[string]interface{})
+			if v == nil {
+				continue
+			}
+
+			totalNodes++
+			usage[safeStringFromMap(v, "chef_environment")]++
+		}
+	}
+	if err := search.Err(); err != nil {
+		return nil, 0, errors.Wrap(err, "unable to get environment usage information")
+	}
+
+	return usage, totalNodes, nil
+}
@@ -0,0 +1,266 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"errors"
+	"testing"
+
+	chef "github.com/chef/go-chef"
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func TestEnvironments(t *testing.T) {
+	var (
+		envmock = EnvironmentMock{desiredEnvironments: map[string]*chef.Environment{
+			"_default": &chef.Environment{Name: "_default", Description: "The default environment"},
+			"production": &chef.Environment{Name: "production",
+				CookbookVersions: map[string]string{
+					"foo":     "~> 0.1.0",
+					"bar":     "= 0.1.0",
+					"missing": ">= 1.0.0",
+					"invalid": "~> one",
+				},
+			},
+			"staging": &chef.Environment{Name: "staging",
+				CookbookVersions: map[string]string{"foo": "< 0.3.0"},
+			},
+		}}
+		cbmock     = newMockCookbook(mockedEnvironmentsCookbookList(), nil, nil)
+		searchmock = makeMockPaginatedSearch(mockedEnvironmentsUsageSearchRows(), 0)
+	)
+
+	envs, err := subject.NewEnvironments(envmock, cbmock, searchmock, false, Workers)
+	assert.Nil(t, err)
+	if assert.NotNil(t, envs) {
+		assert.Equal(t, 3, envs.TotalEnvironments)
+		assert.Equal(t, 4, envs.TotalNodes)
+		assert.False(t, envs.RunCookstyle)
+
+		if assert.Equal(t, 3, len(envs.Records)) {
+			def := envs.Records[0]
+			assert.Equal(t, "_default", def.Name)
+			assert.Equal(t, "The default environment", def.Description)
+			assert.Empty(t, def.Constraints)
+			assert.Equal(t, 1, def.NumNodes)
+
+			prod := envs.Records[1]
+			assert.Equal(t, "production", prod.Name)
+			assert.Equal(t, 3, prod.NumNodes)
+			assert.Equal(t, 2, prod.NumUnsatisfied())
+			if assert.Equal(t, 4, len(prod.Constraints)) {
+				assert.Equal(t, "bar", prod.Constraints[0].Cookbook)
+				assert.Equal(t, "= 0.1.0", prod.Constraints[0].Constraint)
+				assert.Equal(t, "0.1.0", prod.Constraints[0].PinnedVersion)
+				assert.True(t, prod.Constraints[0].Satisfied())
+				assert.Nil(t, prod.Constraints[0].Cookstyle)
+
+				assert.Equal(t, "foo", prod.Constraints[1].Cookbook)
+				assert.Equal(t, "0.1.0", prod.Constraints[1].PinnedVersion)
+
+				assert.Equal(t, "invalid", prod.Constraints[2].Cookbook)
+				assert.False(t, prod.Constraints[2].Satisfied())
+				if assert.NotNil(t, prod.Constraints[2].ParseError) {
+					assert.Equal(t, "cookbook invalid: invalid version constraint '~> one'",
+						prod.Constraints[2].ParseError.Error())
+				}
+
+				assert.Equal(t, "missing", prod.Constraints[3].Cookbook)
+				assert.False(t, prod.Constraints[3].Satisfied())
+				assert.Nil(t, prod.Constraints[3].ParseError)
+			}
+			assert.Equal(t, 1, len(prod.Errors()))
+			assert.False(t, prod.Solvable(), "pinned cookbooks that don't exist can't be solved")
+
+			assert.True(t, def.Solvable())
+			assert.Empty(t, def.Solution.Cookbooks)
+
+			staging := envs.Records[2]
+			assert.Equal(t, "staging", staging.Name)
+			assert.Equal(t, 0, staging.NumNodes)
+			if assert.Equal(t, 1, len(staging.Constraints)) {
+				assert.Equal(t, "0.2.0", staging.Constraints[0].PinnedVersion,
+					"the latest version that satisfies the constraint should be pinned")
+			}
+			assert.True(t, staging.Solvable())
+			assert.Equal(t, "0.2.0", staging.Solution.VersionOf("foo"))
+		}
+	}
+}
+
+func TestEnvironmentsVerifyPinnedVersions(t *testing.T) {
+	var (
+		envmock = EnvironmentMock{desiredEnvironments: map[string]*chef.Environment{
+			"production": &chef.Environment{Name: "production",
+				CookbookVersions: map[string]string{"foo": "= 0.1.0", "missing": "= 1.0.0"},
+			},
+			"staging": &chef.Environment{Name: "staging",
+				CookbookVersions: map[string]string{"foo": "~> 0.1"},
+			},
+		}}
+		// download errors will skip the cookstyle run
+		cbmock     = newMockCookbook(mockedEnvironmentsCookbookList(), nil, errors.New("download error"))
+		searchmock = makeMockPaginatedSearch(mockedCookbooksUsageSearchRows(), 0)
+	)
+
+	envs, err := subject.NewEnvironments(envmock, cbmock, searchmock, true, Workers)
+	assert.Nil(t, err)
+	if assert.NotNil(t, envs) && assert.Equal(t, 2, len(envs.Records)) {
+		assert.True(t, envs.RunCookstyle)
+
+		prod := envs.Records[0]
+		if assert.Equal(t, 2, len(prod.Constraints)) {
+			foo := prod.Constraints[0]
+			if assert.NotNil(t, foo.Cookstyle, "pinned versions should be verified") {
+				assert.Equal(t, "foo", foo.Cookstyle.Name)
+				assert.Equal(t, "0.1.0", foo.Cookstyle.Version)
+				assert.Equal(t, []string{"node1", "node2"}, foo.Cookstyle.Nodes)
+				assert.NotNil(t, foo.Cookstyle.DownloadError)
+			}
+			assert.Nil(t, prod.Constraints[1].Cookstyle, "unsatisfied constraints can't be verified")
+		}
+
+		staging := envs.Records[1]
+		if assert.Equal(t, 1, len(staging.Constraints)) {
+			foo := staging.Constraints[0]
+			if assert.NotNil(t, foo.Cookstyle) {
+				assert.Equal(t, "0.3.0", foo.Cookstyle.Version)
+			}
+		}
+	}
+}
+
+func TestEnvironmentsEmpty(t *testing.T) {
+	envs, err := subject.NewEnvironments(EnvironmentMock{}, CookbookMock{}, makeMockPaginatedSearch("", 0), false, Workers)
+	assert.Nil(t, err)
+	if assert.NotNil(t, envs) {
+		assert.Equal(t, 0, envs.TotalEnvironments)
+		assert.Empty(t, envs.Records)
+	}
+}
+
+func TestEnvironmentsErrors(t *testing.T) {
+	envs, err := subject.NewEnvironments(EnvironmentMock{desiredListError: errors.New("list error")},
+		CookbookMock{}, makeMockPaginatedSearch("", 0), false, Workers)
+	assert.Nil(t, envs)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "unable to retrieve environments: list error", err.Error())
+	}
+
+	envmock := EnvironmentMock{desiredEnvironments: map[string]*chef.Environment{
+		"_default": &chef.Environment{Name: "_default"},
+	}}
+	envs, err = subject.NewEnvironments(envmock,
+		newMockCookbook(nil, errors.New("cookbooks error"), nil), makeMockPaginatedSearch("", 0), false, Workers)
+	assert.Nil(t, envs)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "unable to retrieve cookbooks: cookbooks error", err.Error())
+	}
+
+	envs, err = subject.NewEnvironments(envmock, CookbookMock{}, makeMockSearch("", errors.New("search error")), false, Workers)
+	assert.Nil(t, envs)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "unable to get environment usage information: search error", err.Error())
+	}
+
+	envmock.desiredGetError = errors.New("get error")
+	envs, err = subject.NewEnvironments(envmock, CookbookMock{}, makeMockPaginatedSearch("", 0), false, Workers)
+	assert.Nil(t, err)
+	if assert.NotNil(t, envs) && assert.Equal(t, 1, len(envs.Records)) {
+		errs := envs.Records[0].Errors()
+		if assert.Equal(t, 1, len(errs)) {
+			assert.Equal(t, "unable to get environment _default: get error", errs[0].Error())
+		}
+	}
+}
+
+func TestEnvironmentsSolveError(t *testing.T) {
+	var (
+		envmock = EnvironmentMock{desiredEnvironments: map[string]*chef.Environment{
+			"staging": &chef.Environment{Name: "staging",
+				CookbookVersions: map[string]string{"foo": "< 0.3.0"},
+			},
+		}}
+		cbmock = newMockCookbook(mockedEnvironmentsCookbookList(), nil, nil)
+	)
+	cbmock.desiredGetVersionError = errors.New("metadata error")
+
+	envs, err := subject.NewEnvironments(envmock, cbmock, makeMockPaginatedSearch("", 0), false, Workers)
+	assert.Nil(t, err)
+	if assert.NotNil(t, envs) && assert.Equal(t, 1, len(envs.Records)) {
+		assert.False(t, envs.Records[0].Solvable())
+		errs := envs.Records[0].Errors()
+		if assert.Equal(t, 1, len(errs)) {
+			assert.Equal(t,
+				"unable to solve the constraints of environment staging: "+
+					"unable to get metadata of cookbook foo (0.2.0): metadata error",
+				errs[0].Error())
+		}
+	}
+}
+
+func mockedEnvironmentsUsageSearchRows() string {
+	return `[
+  { "data": { "chef_environment": "production" } },
+  { "data": { "chef_environment": "production" } },
+  { "data": { "chef_environment": "_default" } },
+  { "data": { "chef_environment": "production" } }
+]`
+}
+
+func mockedEnvironmentsCookbookList() chef.CookbookListResult {
+	return chef.CookbookListResult{
+		"foo": chef.CookbookVersions{
+			Versions: []chef.CookbookVersion{
+				chef.CookbookVersion{Version: "0.1.0"},
+				chef.CookbookVersion{Version: "0.3.0"},
+				chef.CookbookVersion{Version: "0.2.0"},
+			},
+		},
+		"bar": chef.CookbookVersions{
+			Versions: []chef.CookbookVersion{
+				chef.CookbookVersion{Version: "0.1.0"},
+			},
+		},
+	}
+}
+
+func TestEnvironmentsVerifyUnusedPinnedVersions(t *testing.T) {
+	var (
+		envmock = EnvironmentMock{desiredEnvironments: map[string]*chef.Environment{
+			"production": &chef.Environment{Name: "production",
+				CookbookVersions: map[string]string{"bar": "= 0.1.0"},
+			},
+		}}
+		cbmock = newMockCookbook(mockedEnvironmentsCookbookList(), nil, errors.New("download error"))
+		// no node is using any cookbook
+		searchmock = makeMockPaginatedSearch(mockedEnvironmentsUsageSearchRows(), 0)
+	)
+
+	envs, err := subject.NewEnvironments(envmock, cbmock, searchmock, true, Workers)
+	assert.Nil(t, err)
+	if assert.NotNil(t, envs) && assert.Equal(t, 1, len(envs.Records)) {
+		constraints := envs.Records[0].Constraints
+		if assert.Equal(t, 1, len(constraints)) && assert.NotNil(t, constraints[0].Cookstyle,
+			"pinned versions should be verified even if no node is using them") {
+			assert.Equal(t, "0.1.0", constraints[0].Cookstyle.Version)
+			assert.Empty(t, constraints[0].Cookstyle.Nodes)
+		}
+	}
+}
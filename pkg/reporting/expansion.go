@@ -0,0 +1,358 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	chef "github.com/chef/go-chef"
+	"github.com/pkg/errors"
+)
+
+// types of the steps of a provenance chain
+const (
+	ProvenanceRole    = "role"
+	ProvenanceRecipe  = "recipe"
+	ProvenanceDepends = "depends"
+)
+
+// NodeExpansion is the expanded run list of a node together with the chain
+// of roles, recipes and dependencies that pulled in every cookbook it applies
+type NodeExpansion struct {
+	Node        *NodeReportItem
+	Environment string
+	RunList     []string
+	Recipes     []ExpandedRecipe
+	// every cookbook applied by the node, sorted by name
+	Cookbooks    []CookbookProvenance
+	MissingRoles []string
+	// every cycle is the list of roles that form it, the first role is repeated at the end
+	RoleCycles [][]string
+	// invalid run list items and failures to get roles or cookbook metadata
+	Errors []error
+}
+
+// ExpandedRecipe is a recipe of the expanded run list and the chain that pulled it in
+type ExpandedRecipe struct {
+	RunListItem
+	Chain []ProvenanceStep
+}
+
+// CookbookProvenance is a cookbook applied by a node and the chain that pulled
+// it in, cookbooks that are not part of the expanded run list have no chain
+type CookbookProvenance struct {
+	Name    string
+	Version string
+	Chain   []ProvenanceStep
+}
+
+func (cp CookbookProvenance) InRunList() bool {
+	return len(cp.Chain) != 0
+}
+
+// ProvenanceStep is a single link of a provenance chain
+type ProvenanceStep struct {
+	Type string
+	Name string
+	// the environment of an environment specific run list of a role,
+	// or the version constraint of a dependency
+	Detail string
+}
+
+func (ps ProvenanceStep) String() string {
+	switch ps.Type {
+	case ProvenanceRole:
+		if ps.Detail != "" {
+			return fmt.Sprintf("role[%s] (%s run list)", ps.Name, ps.Detail)
+		}
+		return fmt.Sprintf("role[%s]", ps.Name)
+	case ProvenanceDepends:
+		return fmt.Sprintf("depends '%s', '%s'", ps.Name, ps.Detail)
+	default:
+		return fmt.Sprintf("recipe[%s]", ps.Name)
+	}
+}
+
+// RunListExpansion is a run list expanded the way the Chef Infra Client does it,
+// together with the roles that couldn't be expanded
+type RunListExpansion struct {
+	Environment string
+	Recipes     []ExpandedRecipe
+	// sorted by name
+	MissingRoles []string
+	// every cycle is the list of roles that form it, the first role is repeated at the end
+	RoleCycles [][]string
+	// invalid run list items and failures to get roles
+	Errors []error
+}
+
+// ExpandRunList expands a run list into the ordered list of recipes it applies,
+// roles are expanded in place, depth-first, using the run list of the provided
+// environment, if they have one, and roles or recipes that appear more than once
+// are only applied the first time, like the Chef Infra Client does
+//
+// missing roles, role cycles and invalid items don't stop the expansion, they
+// are recorded in the returned expansion
+func ExpandRunList(roles RoleRunListsInterface, environment string, runList []string) *RunListExpansion {
+	if environment == "" {
+		environment = "_default"
+	}
+
+	expander := &runListExpander{
+		expansion: &RunListExpansion{
+			Environment:  environment,
+			Recipes:      make([]ExpandedRecipe, 0),
+			MissingRoles: make([]string, 0),
+			RoleCycles:   make([][]string, 0),
+			Errors:       make([]error, 0),
+		},
+		roles:       roles,
+		seenRoles:   map[string]bool{},
+		seenRecipes: map[string]bool{},
+		missing:     map[string]bool{},
+	}
+	expander.expandRunList(runList, []ProvenanceStep{}, []string{})
+	expander.expansion.MissingRoles = sortedKeys(expander.missing)
+
+	return expander.expansion
+}
+
+// ExpandNodeRunList expands the run list of a node the way the Chef Infra Client
+// does, see ExpandRunList, and the dependencies of every cookbook are resolved
+// from the metadata of the cookbook version that the node applied
+func ExpandNodeRunList(nodes NodeInterface, roles RoleRunListsInterface, cbi CookbookInterface,
+	name string) (*NodeExpansion, error) {
+
+	node, err := nodes.Get(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get node %s", name)
+	}
+
+	expansion := &NodeExpansion{
+		Node:    nodeReportItemFromNode(node),
+		RunList: node.RunList,
+	}
+	if expansion.RunList == nil {
+		expansion.RunList = []string{}
+	}
+
+	runListExpansion := ExpandRunList(roles, node.Environment, expansion.RunList)
+	expansion.Environment = runListExpansion.Environment
+	expansion.Recipes = runListExpansion.Recipes
+	expansion.MissingRoles = runListExpansion.MissingRoles
+	expansion.RoleCycles = runListExpansion.RoleCycles
+	expansion.Errors = runListExpansion.Errors
+
+	chains := expansion.resolveDependencies(cbi)
+	for _, cbv := range expansion.Node.CookbookVersions {
+		expansion.Cookbooks = append(expansion.Cookbooks,
+			CookbookProvenance{Name: cbv.Name, Version: cbv.Version, Chain: chains[cbv.Name]},
+		)
+	}
+	sort.Slice(expansion.Cookbooks, func(i, j int) bool {
+		return expansion.Cookbooks[i].Name < expansion.Cookbooks[j].Name
+	})
+
+	return expansion, nil
+}
+
+type runListExpander struct {
+	expansion   *RunListExpansion
+	roles       RoleRunListsInterface
+	seenRoles   map[string]bool
+	seenRecipes map[string]bool
+	missing     map[string]bool
+}
+
+func (e *runListExpander) expandRunList(runList []string, chain []ProvenanceStep, stack []string) {
+	for _, entry := range runList {
+		item, err := ParseRunListItem(entry)
+		if err != nil {
+			e.expansion.Errors = append(e.expansion.Errors, err)
+			continue
+		}
+
+		if !item.IsRole() {
+			if e.seenRecipes[item.Recipe()] {
+				continue
+			}
+			e.seenRecipes[item.Recipe()] = true
+			e.expansion.Recipes = append(e.expansion.Recipes, ExpandedRecipe{
+				RunListItem: item,
+				Chain:       appendStep(chain, ProvenanceStep{Type: ProvenanceRecipe, Name: item.Recipe()}),
+			})
+			continue
+		}
+
+		// a role that includes itself, directly or through other roles
+		if idx := indexOf(stack, item.Name); idx != -1 {
+			cycle := append(append([]string{}, stack[idx:]...), item.Name)
+			e.expansion.RoleCycles = append(e.expansion.RoleCycles, cycle)
+			continue
+		}
+
+		// like the Chef Infra Client, roles are only expanded the first time
+		if e.seenRoles[item.Name] {
+			continue
+		}
+		e.seenRoles[item.Name] = true
+
+		runLists, err := e.roles.GetRunLists(item.Name)
+		if err != nil {
+			if isNotFoundErr(err) {
+				e.missing[item.Name] = true
+			} else {
+				e.expansion.Errors = append(e.expansion.Errors, errors.Wrapf(err, "unable to get role %s", item.Name))
+			}
+			continue
+		}
+
+		roleRunList, envSpecific := runLists.RunListFor(e.expansion.Environment)
+		step := ProvenanceStep{Type: ProvenanceRole, Name: item.Name}
+		if envSpecific {
+			step.Detail = e.expansion.Environment
+		}
+		e.expandRunList(roleRunList, appendStep(chain, step), append(stack, item.Name))
+	}
+}
+
+// walks the dependencies of the cookbooks of the expanded run list, breadth-first,
+// so that every cookbook gets the shortest chain that pulled it in
+func (ne *NodeExpansion) resolveDependencies(cbi CookbookInterface) map[string][]ProvenanceStep {
+	var (
+		chains  = map[string][]ProvenanceStep{}
+		queue   = make([]string, 0)
+		applied = map[string]string{}
+	)
+
+	for _, cbv := range ne.Node.CookbookVersions {
+		applied[cbv.Name] = cbv.Version
+	}
+
+	for _, recipe := range ne.Recipes {
+		if _, ok := chains[recipe.Cookbook()]; ok {
+			continue
+		}
+		chains[recipe.Cookbook()] = recipe.Chain
+		queue = append(queue, recipe.Cookbook())
+	}
+
+	for len(queue) != 0 {
+		cookbook := queue[0]
+		queue = queue[1:]
+
+		// cookbooks that the node didn't apply are resolved from their latest version
+		version := applied[cookbook]
+		if version == "" {
+			version = "_latest"
+		}
+
+		metadata, err := cbi.GetVersion(cookbook, version)
+		if err != nil {
+			ne.Errors = append(ne.Errors,
+				errors.Wrapf(err, "unable to get metadata of cookbook %s (%s)", cookbook, version),
+			)
+			continue
+		}
+
+		for _, dep := range sortedStringKeys(metadata.Metadata.Depends) {
+			if _, ok := chains[dep]; ok {
+				continue
+			}
+			chains[dep] = appendStep(chains[cookbook],
+				ProvenanceStep{Type: ProvenanceDepends, Name: dep, Detail: metadata.Metadata.Depends[dep]},
+			)
+			queue = append(queue, dep)
+		}
+	}
+
+	return chains
+}
+
+// builds a node report item from the attributes of a node
+func nodeReportItemFromNode(node chef.Node) *NodeReportItem {
+	item := &NodeReportItem{
+		Name:             node.Name,
+		OS:               safeStringFromMap(node.AutomaticAttributes, "platform"),
+		OSVersion:        safeStringFromMap(node.AutomaticAttributes, "platform_version"),
+		OSFamily:         safeStringFromMap(node.AutomaticAttributes, "platform_family"),
+		PolicyName:       node.PolicyName,
+		PolicyGroup:      node.PolicyGroup,
+		CookbookVersions: make([]CookbookVersion, 0),
+	}
+
+	if packages, ok := node.AutomaticAttributes["chef_packages"].(map[string]interface{}); ok {
+		if chefPackage, ok := packages["chef"].(map[string]interface{}); ok {
+			item.ChefVersion = safeStringFromMap(chefPackage, "version")
+		}
+	}
+
+	if cookbooks, ok := node.AutomaticAttributes["cookbooks"].(map[string]interface{}); ok {
+		for name, details := range cookbooks {
+			if detailsMap, ok := details.(map[string]interface{}); ok {
+				item.CookbookVersions = append(item.CookbookVersions,
+					CookbookVersion{Name: name, Version: safeStringFromMap(detailsMap, "version")},
+				)
+			}
+		}
+	}
+
+	return item
+}
+
+// returns a copy of the chain with the step appended, chains share their
+// prefixes and must never be modified in place
+func appendStep(chain []ProvenanceStep, step ProvenanceStep) []ProvenanceStep {
+	newChain := make([]ProvenanceStep, len(chain), len(chain)+1)
+	copy(newChain, chain)
+	return append(newChain, step)
+}
+
+func indexOf(list []string, s string) int {
+	for i, item := range list {
+		if item == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isNotFoundErr(err error) bool {
+	chefErr, ok := errors.Cause(err).(*chef.ErrorResponse)
+	return ok && chefErr.Response.StatusCode == http.StatusNotFound
+}
+
+// returns the chain as a single line, like 'role[base] -> recipe[ntp::default]'
+func (cp CookbookProvenance) ChainString() string {
+	steps := make([]string, 0, len(cp.Chain))
+	for _, step := range cp.Chain {
+		steps = append(steps, step.String())
+	}
+	return strings.Join(steps, " -> ")
+}
@@ -0,0 +1,128 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"errors"
+	"testing"
+
+	chef "github.com/chef/go-chef"
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func expansionMocks() (NodeMock, RoleRunListsMock, *CookbookMetadataMock) {
+	nodes := NodeMock{desiredNodes: map[string]chef.Node{
+		"web01": chef.Node{
+			Name:        "web01",
+			Environment: "production",
+			RunList:     []string{"role[webserver]", "recipe[app]", "role[ghost]"},
+			AutomaticAttributes: map[string]interface{}{
+				"platform": "ubuntu",
+				"cookbooks": map[string]interface{}{
+					"apache2":         map[string]interface{}{"version": "5.0.1"},
+					"app":             map[string]interface{}{"version": "1.2.3"},
+					"audit":           map[string]interface{}{"version": "7.0.0"},
+					"build-essential": map[string]interface{}{"version": "8.2.1"},
+					"ntp":             map[string]interface{}{"version": "3.6.2"},
+					"leftover":        map[string]interface{}{"version": "0.1.0"},
+				},
+			},
+		},
+	}}
+	roles := RoleRunListsMock{desiredRunLists: map[string]subject.RoleRunLists{
+		"webserver": subject.RoleRunLists{Name: "webserver",
+			RunList: []string{"role[base]", "recipe[apache2::mod_ssl]"}},
+		"base": subject.RoleRunLists{Name: "base",
+			RunList: []string{"recipe[ntp]"},
+			EnvRunLists: map[string][]string{
+				"production": []string{"recipe[ntp]", "role[security]"},
+			},
+		},
+		"security": subject.RoleRunLists{Name: "security",
+			RunList: []string{"recipe[audit]", "role[base]"}},
+	}}
+	cookbooks := &CookbookMetadataMock{desiredDepends: map[string]map[string]string{
+		"apache2@5.0.1": map[string]string{"build-essential": ">= 0.0.0"},
+		"app@1.2.3":     map[string]string{"apache2": "~> 5.0", "build-essential": ">= 2.0"},
+		"audit@7.0.0":   map[string]string{},
+		"ntp@3.6.2":     map[string]string{},
+		// no metadata for build-essential
+	}}
+	return nodes, roles, cookbooks
+}
+
+func TestExpandNodeRunList(t *testing.T) {
+	nodes, roles, cookbooks := expansionMocks()
+
+	expansion, err := subject.ExpandNodeRunList(nodes, roles, cookbooks, "web01")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, "production", expansion.Environment)
+	assert.Equal(t, "ubuntu", expansion.Node.OS)
+
+	recipes := make([]string, 0)
+	for _, recipe := range expansion.Recipes {
+		recipes = append(recipes, recipe.Recipe())
+	}
+	assert.Equal(t, []string{"ntp::default", "audit::default", "apache2::mod_ssl", "app::default"}, recipes)
+
+	assert.Equal(t, []string{"ghost"}, expansion.MissingRoles)
+	assert.Equal(t, [][]string{[]string{"base", "security", "base"}}, expansion.RoleCycles)
+
+	chains := map[string]string{}
+	for _, cookbook := range expansion.Cookbooks {
+		chains[cookbook.Name] = cookbook.ChainString()
+	}
+	assert.Equal(t, map[string]string{
+		"apache2":         "role[webserver] -> recipe[apache2::mod_ssl]",
+		"app":             "recipe[app::default]",
+		"audit":           "role[webserver] -> role[base] (production run list) -> role[security] -> recipe[audit::default]",
+		"build-essential": "role[webserver] -> recipe[apache2::mod_ssl] -> depends 'build-essential', '>= 0.0.0'",
+		"ntp":             "role[webserver] -> role[base] (production run list) -> recipe[ntp::default]",
+		"leftover":        "",
+	}, chains)
+	assert.Equal(t, "apache2", expansion.Cookbooks[0].Name, "cookbooks should be sorted by name")
+	assert.False(t, expansion.Cookbooks[4].InRunList())
+
+	// the metadata of the applied versions is used
+	assert.Contains(t, cookbooks.requested, "apache2@5.0.1")
+	if assert.Equal(t, 1, len(expansion.Errors)) {
+		assert.Contains(t, expansion.Errors[0].Error(), "unable to get metadata of cookbook build-essential (8.2.1)")
+	}
+}
+
+func TestExpandNodeRunListErrors(t *testing.T) {
+	nodes, roles, cookbooks := expansionMocks()
+
+	_, err := subject.ExpandNodeRunList(nodes, roles, cookbooks, "missing")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "unable to get node missing")
+	}
+
+	// failures other than a missing role are reported as errors
+	roles.desiredGetError = errors.New("500 Internal Server Error")
+	expansion, err := subject.ExpandNodeRunList(nodes, roles, cookbooks, "web01")
+	if assert.Nil(t, err) {
+		assert.Empty(t, expansion.MissingRoles)
+		assert.Equal(t, 1, len(expansion.Recipes))
+		assert.EqualError(t, expansion.Errors[0], "unable to get role webserver: 500 Internal Server Error")
+	}
+}
@@ -0,0 +1,137 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"path"
+	"strings"
+
+	chef "github.com/chef/go-chef"
+	"github.com/pkg/errors"
+)
+
+// CookbookFilter selects cookbook versions by name, or glob pattern,
+// and an optional list of version constraints
+//
+// examples:
+//
+//	apache2 '~> 5.0'
+//	'mysql*'
+//	nginx '>= 1.0' '< 2.0'
+type CookbookFilter struct {
+	Pattern     string
+	Constraints []VersionConstraint
+}
+
+// parses a list of arguments into cookbook filters, every version constraint
+// applies to the cookbook name, or glob pattern, that precedes it
+func ParseCookbookFilters(args []string) ([]CookbookFilter, error) {
+	filters := make([]CookbookFilter, 0, len(args))
+
+	for _, arg := range args {
+		if looksLikeVersionConstraint(arg) {
+			if len(filters) == 0 {
+				return nil, errors.Errorf(
+					"version constraint '%s' must be preceded by a cookbook name", arg,
+				)
+			}
+
+			constraint, err := ParseVersionConstraint(arg)
+			if err != nil {
+				return nil, err
+			}
+
+			last := &filters[len(filters)-1]
+			last.Constraints = append(last.Constraints, constraint)
+			continue
+		}
+
+		pattern := strings.TrimSpace(arg)
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return nil, errors.Errorf("invalid cookbook name or pattern '%s'", arg)
+		}
+		filters = append(filters, CookbookFilter{Pattern: pattern})
+	}
+
+	return filters, nil
+}
+
+// returns true if the cookbook version matches the filter
+func (f CookbookFilter) Matches(name, version string) bool {
+	if matched, _ := path.Match(f.Pattern, name); !matched {
+		return false
+	}
+
+	for _, constraint := range f.Constraints {
+		if !constraint.SatisfiedBy(version) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f CookbookFilter) String() string {
+	if len(f.Constraints) == 0 {
+		return f.Pattern
+	}
+
+	constraints := make([]string, 0, len(f.Constraints))
+	for _, c := range f.Constraints {
+		constraints = append(constraints, c.String())
+	}
+	return f.Pattern + " (" + strings.Join(constraints, ", ") + ")"
+}
+
+// returns true if the cookbook version matches any of the filters,
+// when there are no filters, every cookbook version matches
+func MatchCookbookFilters(filters []CookbookFilter, name, version string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+
+	for _, f := range filters {
+		if f.Matches(name, version) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// returns only the cookbook versions that match the provided filters
+func FilterCookbooks(cookbooks chef.CookbookListResult, filters []CookbookFilter) chef.CookbookListResult {
+	if len(filters) == 0 {
+		return cookbooks
+	}
+
+	filtered := chef.CookbookListResult{}
+	for name, cookbookVersions := range cookbooks {
+		versions := make([]chef.CookbookVersion, 0)
+		for _, ver := range cookbookVersions.Versions {
+			if MatchCookbookFilters(filters, name, ver.Version) {
+				versions = append(versions, ver)
+			}
+		}
+
+		if len(versions) != 0 {
+			filtered[name] = chef.CookbookVersions{Url: cookbookVersions.Url, Versions: versions}
+		}
+	}
+
+	return filtered
+}
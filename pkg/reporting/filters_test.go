@@ -0,0 +1,103 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"testing"
+
+	chef "github.com/chef/go-chef"
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func TestParseCookbookFilters(t *testing.T) {
+	filters, err := subject.ParseCookbookFilters([]string{"apache2", "~> 5.0", "mysql*", "nginx", ">= 1.0", "< 2.0"})
+	if assert.Nil(t, err) && assert.Equal(t, 3, len(filters)) {
+		assert.Equal(t, "apache2 (~> 5.0)", filters[0].String())
+		assert.Equal(t, "mysql*", filters[1].String())
+		assert.Equal(t, "nginx (>= 1.0, < 2.0)", filters[2].String())
+	}
+
+	filters, err = subject.ParseCookbookFilters([]string{"7-zip", "3.0", "1password"})
+	if assert.Nil(t, err) && assert.Equal(t, 2, len(filters)) {
+		assert.Equal(t, "7-zip (= 3.0)", filters[0].String())
+		assert.Equal(t, "1password", filters[1].String())
+	}
+
+	filters, err = subject.ParseCookbookFilters([]string{})
+	assert.Nil(t, err)
+	assert.Empty(t, filters)
+}
+
+func TestParseCookbookFiltersErrors(t *testing.T) {
+	_, err := subject.ParseCookbookFilters([]string{"~> 5.0", "apache2"})
+	assert.EqualError(t, err, "version constraint '~> 5.0' must be preceded by a cookbook name")
+
+	_, err = subject.ParseCookbookFilters([]string{"apache2", ">= foo"})
+	assert.EqualError(t, err, "invalid version constraint '>= foo'")
+
+	_, err = subject.ParseCookbookFilters([]string{"mysql["})
+	assert.EqualError(t, err, "invalid cookbook name or pattern 'mysql['")
+}
+
+func TestCookbookFilterMatches(t *testing.T) {
+	filters, err := subject.ParseCookbookFilters([]string{"apache2", "~> 5.0", "mysql*"})
+	if assert.Nil(t, err) {
+		assert.True(t, subject.MatchCookbookFilters(filters, "apache2", "5.1.0"))
+		assert.False(t, subject.MatchCookbookFilters(filters, "apache2", "6.0.0"))
+		assert.True(t, subject.MatchCookbookFilters(filters, "mysql", "1.0.0"))
+		assert.True(t, subject.MatchCookbookFilters(filters, "mysql-server", "8.0.0"))
+		assert.False(t, subject.MatchCookbookFilters(filters, "my-mysql", "8.0.0"))
+		assert.False(t, subject.MatchCookbookFilters(filters, "nginx", "1.0.0"))
+	}
+
+	assert.True(t, subject.MatchCookbookFilters(nil, "anything", "1.0.0"),
+		"every cookbook should match when there are no filters")
+}
+
+func TestFilterCookbooks(t *testing.T) {
+	cookbookList := chef.CookbookListResult{
+		"apache2": chef.CookbookVersions{
+			Versions: []chef.CookbookVersion{
+				chef.CookbookVersion{Version: "4.0.0"},
+				chef.CookbookVersion{Version: "5.0.0"},
+				chef.CookbookVersion{Version: "5.2.1"},
+			},
+		},
+		"nginx": chef.CookbookVersions{
+			Versions: []chef.CookbookVersion{
+				chef.CookbookVersion{Version: "1.0.0"},
+			},
+		},
+	}
+
+	assert.Equal(t, cookbookList, subject.FilterCookbooks(cookbookList, nil))
+
+	filters, _ := subject.ParseCookbookFilters([]string{"apache2", "~> 5.0"})
+	assert.Equal(t,
+		chef.CookbookListResult{
+			"apache2": chef.CookbookVersions{
+				Versions: []chef.CookbookVersion{
+					chef.CookbookVersion{Version: "5.0.0"},
+					chef.CookbookVersion{Version: "5.2.1"},
+				},
+			},
+		},
+		subject.FilterCookbooks(cookbookList, filters),
+	)
+}
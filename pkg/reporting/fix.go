@@ -0,0 +1,389 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cheggaaa/pb/v3"
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+type FixStatus struct {
+	Fixes          []*CookbookFix
+	FixesMutex     sync.Mutex
+	TotalCookbooks int
+	Filters        []CookbookFilter
+	Cookbooks      CookbookInterface
+	Cookstyle      *CookstyleRunner
+	Cache          *CookbookCache
+	progress       *pb.ProgressBar
+}
+
+// CookbookFix is the result of auto-correcting a copy of a cookbook version,
+// the cookbook inside the cache is never modified
+type CookbookFix struct {
+	Name    string
+	Version string
+	// unified diff between the original and the corrected cookbook, the paths are
+	// relative to the cookbook directory so it can be applied with 'patch -p1'
+	Patch string
+	// offenses auto-corrected by cookstyle
+	Corrected []CookbookFile
+	// offenses that cookstyle couldn't correct, they need manual work
+	Remaining      []CookbookFile
+	DownloadError  error
+	CookstyleError error
+	PatchError     error
+}
+
+func (cf *CookbookFix) NumCorrected() int {
+	return numOffenses(cf.Corrected)
+}
+
+func (cf *CookbookFix) NumRemaining() int {
+	return numOffenses(cf.Remaining)
+}
+
+// returns the paths of the files modified by the patch
+func (cf *CookbookFix) ChangedFiles() []string {
+	var (
+		files    = make([]string, 0)
+		fromFile string
+	)
+	for _, line := range strings.Split(cf.Patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			fromFile = strings.TrimPrefix(line, "--- a/")
+		case strings.HasPrefix(line, "+++ "):
+			// deleted files are diffed against /dev/null
+			if line == "+++ /dev/null" {
+				files = append(files, fromFile)
+			} else {
+				files = append(files, strings.TrimPrefix(line, "+++ b/"))
+			}
+		}
+	}
+	return files
+}
+
+func (cf *CookbookFix) Errors() []error {
+	errs := make([]error, 0)
+	if cf.DownloadError != nil {
+		errs = append(errs, cf.DownloadError)
+	}
+	if cf.CookstyleError != nil {
+		errs = append(errs, cf.CookstyleError)
+	}
+	if cf.PatchError != nil {
+		errs = append(errs, cf.PatchError)
+	}
+	return errs
+}
+
+func numOffenses(files []CookbookFile) int {
+	i := 0
+	for _, f := range files {
+		i += len(f.Offenses)
+	}
+	return i
+}
+
+// override functions to override any particular setting of the fix status
+// before the cookbooks are corrected
+type FixOverrideFunc func(*FixStatus)
+
+// returns a cookstyle runner that auto-corrects the same departments
+// that the default runner analyzes
+func NewAutoCorrectRunner() *CookstyleRunner {
+	runner := NewCookstyleRunner()
+	runner.Opts = append(runner.Opts, "--auto-correct")
+	return runner
+}
+
+// auto-corrects a copy of every cookbook version that matches the provided filters
+func NewFixes(cbi CookbookInterface, filters []CookbookFilter, workers int,
+	overrides ...FixOverrideFunc) (*FixStatus, error) {
+
+	fixState := &FixStatus{
+		Filters:   filters,
+		Cookbooks: cbi,
+		Cookstyle: NewAutoCorrectRunner(),
+		Cache:     NewCookbookCache(DefaultCacheDir, cbi),
+	}
+	for _, f := range overrides {
+		f(fixState)
+	}
+
+	fmt.Printf("Finding available cookbooks...")
+	results, err := cbi.ListAvailableVersions("0")
+	if err != nil {
+		fmt.Println(" (-)")
+		return nil, errors.Wrap(err, "unable to retrieve cookbooks")
+	}
+
+	results = FilterCookbooks(results, fixState.Filters)
+
+	items := make([]cookbookItem, 0)
+	for name, versions := range results {
+		for _, ver := range versions.Versions {
+			items = append(items, cookbookItem{name, ver.Version})
+		}
+	}
+	fixState.TotalCookbooks = len(items)
+	fixState.Fixes = make([]*CookbookFix, 0, len(items))
+	fmt.Printf(" (%d found)\n", len(items))
+
+	if len(items) == 0 {
+		fmt.Println("No cookbooks available to fix")
+		return fixState, nil
+	}
+
+	numWorkers := len(items)
+	if numWorkers > workers {
+		numWorkers = workers
+	}
+
+	fmt.Println("Fixing cookbooks...")
+	fixState.progress = pb.StartNew(len(items))
+
+	var (
+		itemsCh = make(chan cookbookItem)
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range itemsCh {
+				fixState.addFix(fixState.fixCookbook(item.Name, item.Version))
+				fixState.progress.Increment()
+			}
+		}()
+	}
+	for _, item := range items {
+		itemsCh <- item
+	}
+	close(itemsCh)
+	wg.Wait()
+	fixState.progress.Finish()
+
+	// workers finish in any order
+	sort.Slice(fixState.Fixes, func(i, j int) bool {
+		if fixState.Fixes[i].Name != fixState.Fixes[j].Name {
+			return fixState.Fixes[i].Name < fixState.Fixes[j].Name
+		}
+		return fixState.Fixes[i].Version < fixState.Fixes[j].Version
+	})
+
+	return fixState, nil
+}
+
+func (fs *FixStatus) addFix(fix *CookbookFix) {
+	fs.FixesMutex.Lock()
+	defer fs.FixesMutex.Unlock()
+	fs.Fixes = append(fs.Fixes, fix)
+}
+
+func (fs *FixStatus) fixCookbook(name, version string) *CookbookFix {
+	fix := &CookbookFix{Name: name, Version: version}
+
+	originalDir, err := fs.Cache.Fetch(name, version)
+	if err != nil {
+		fix.DownloadError = errors.Wrapf(err, "unable to download cookbook %s", name)
+		return fix
+	}
+
+	tmpDir, err := ioutil.TempDir("", "chef-analyze-fix")
+	if err != nil {
+		fix.CookstyleError = errors.Wrap(err, "unable to create temporary directory")
+		return fix
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// NOTE: the cached files are hard links to the files shared by every cookbook
+	// version, cookstyle must work on real copies or it would modify all of them
+	fixedDir := filepath.Join(tmpDir, filepath.Base(originalDir))
+	if err := copyDir(originalDir, fixedDir); err != nil {
+		fix.CookstyleError = errors.Wrapf(err, "unable to copy cookbook %s", name)
+		return fix
+	}
+
+	result, err := fs.Cookstyle.Run(fixedDir)
+	if err != nil {
+		fix.CookstyleError = err
+		return fix
+	}
+
+	for _, file := range result.Files {
+		corrected := CookbookFile{Path: file.Path}
+		remaining := CookbookFile{Path: file.Path}
+		for _, offense := range file.Offenses {
+			if offense.Corrected {
+				corrected.Offenses = append(corrected.Offenses, offense)
+			} else {
+				remaining.Offenses = append(remaining.Offenses, offense)
+			}
+		}
+		if len(corrected.Offenses) != 0 {
+			fix.Corrected = append(fix.Corrected, corrected)
+		}
+		if len(remaining.Offenses) != 0 {
+			fix.Remaining = append(fix.Remaining, remaining)
+		}
+	}
+
+	fix.Patch, err = DiffDirs(originalDir, fixedDir)
+	if err != nil {
+		fix.PatchError = errors.Wrapf(err, "unable to generate patch for cookbook %s", name)
+	}
+
+	return fix
+}
+
+// returns a unified diff of every file that differs between two directories,
+// the paths are relative to the directories and prefixed with 'a/' and 'b/'
+func DiffDirs(a, b string) (string, error) {
+	aFiles, err := relativeFiles(a)
+	if err != nil {
+		return "", err
+	}
+	bFiles, err := relativeFiles(b)
+	if err != nil {
+		return "", err
+	}
+
+	paths := make([]string, 0, len(aFiles))
+	for rel := range aFiles {
+		paths = append(paths, rel)
+	}
+	for rel := range bFiles {
+		if !aFiles[rel] {
+			paths = append(paths, rel)
+		}
+	}
+	sort.Strings(paths)
+
+	var patch bytes.Buffer
+	for _, rel := range paths {
+		diff := difflib.UnifiedDiff{
+			FromFile: "a/" + rel,
+			ToFile:   "b/" + rel,
+			Context:  3,
+		}
+
+		if aFiles[rel] {
+			content, err := ioutil.ReadFile(filepath.Join(a, filepath.FromSlash(rel)))
+			if err != nil {
+				return "", err
+			}
+			diff.A = splitLines(string(content))
+		} else {
+			diff.FromFile = "/dev/null"
+		}
+
+		if bFiles[rel] {
+			content, err := ioutil.ReadFile(filepath.Join(b, filepath.FromSlash(rel)))
+			if err != nil {
+				return "", err
+			}
+			diff.B = splitLines(string(content))
+		} else {
+			diff.ToFile = "/dev/null"
+		}
+
+		if err := difflib.WriteUnifiedDiff(&patch, diff); err != nil {
+			return "", err
+		}
+	}
+
+	return patch.String(), nil
+}
+
+// splits the content of a file into lines that keep their line ending, like
+// patch(1) expects, a missing line ending at the end of the file is marked
+func splitLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	last := len(lines) - 1
+	if lines[last] == "" {
+		return lines[:last]
+	}
+	lines[last] += "\n\\ No newline at end of file\n"
+	return lines
+}
+
+// returns the set of files inside a directory, relative to it and with forward slashes
+func relativeFiles(dir string) (map[string]bool, error) {
+	files := map[string]bool{}
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = true
+		return nil
+	})
+	return files, err
+}
+
+// copies the content of every file inside src into dst
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, os.ModePerm)
+		}
+
+		in, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
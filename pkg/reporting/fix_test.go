@@ -0,0 +1,160 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	chef "github.com/chef/go-chef"
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func TestNewAutoCorrectRunner(t *testing.T) {
+	assert.Equal(t,
+		[]string{"--format", "json", "--only", "ChefDeprecations,ChefCorrectness", "--auto-correct"},
+		subject.NewAutoCorrectRunner().Opts,
+	)
+}
+
+func TestFixes(t *testing.T) {
+	savedPath := setupBinstubsDir()
+	defer os.Setenv("PATH", savedPath)
+
+	cache, mock, cleanup := newCacheFixture(t)
+	defer cleanup()
+	mock.desiredCookbookList = chef.CookbookListResult{
+		"foo": chef.CookbookVersions{
+			Versions: []chef.CookbookVersion{
+				chef.CookbookVersion{Version: "1.0.0"},
+				chef.CookbookVersion{Version: "1.1.0"},
+				chef.CookbookVersion{Version: "2.0.0"}, // can't be downloaded
+			},
+		},
+		"bar": chef.CookbookVersions{
+			Versions: []chef.CookbookVersion{
+				chef.CookbookVersion{Version: "1.0.0"},
+			},
+		},
+	}
+	filters, err := subject.ParseCookbookFilters([]string{"foo"})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	fs, err := subject.NewFixes(mock, filters, Workers,
+		func(fs *subject.FixStatus) {
+			fs.Cache = cache
+			// manipulating the output of the binstub `cookstyle` command
+			fs.Cookstyle.Opts = []string{"auto-correct"}
+		},
+	)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 3, fs.TotalCookbooks)
+	if !assert.Equal(t, 3, len(fs.Fixes)) {
+		return
+	}
+
+	fix := fs.Fixes[0]
+	assert.Equal(t, "1.0.0", fix.Version)
+	assert.Empty(t, fix.Errors())
+	assert.Equal(t, 1, fix.NumCorrected())
+	assert.Equal(t, 1, fix.NumRemaining())
+	assert.Equal(t, "ChefDeprecations/Corrected", fix.Corrected[0].Offenses[0].CopName)
+	assert.Equal(t, "ChefCorrectness/Remaining", fix.Remaining[0].Offenses[0].CopName)
+	assert.Equal(t, []string{"recipes/default.rb"}, fix.ChangedFiles())
+	assert.Equal(t, `--- a/recipes/default.rb
++++ b/recipes/default.rb
+@@ -1 +1 @@
+-package 'foo'
++package "foo-corrected"
+`, fix.Patch)
+
+	// the cached cookbook is never modified
+	content, err := ioutil.ReadFile(filepath.Join(cache.CookbookPath("foo", "1.0.0"), "recipes", "default.rb"))
+	assert.Nil(t, err)
+	assert.Equal(t, "package 'foo'\n", string(content))
+
+	assert.Equal(t, "1.1.0", fs.Fixes[1].Version)
+	assert.NotEmpty(t, fs.Fixes[1].Patch)
+
+	fix = fs.Fixes[2]
+	assert.Equal(t, "2.0.0", fix.Version)
+	if assert.NotNil(t, fix.DownloadError) {
+		assert.Contains(t, fix.DownloadError.Error(), "unable to download cookbook foo")
+	}
+	assert.Empty(t, fix.Patch)
+}
+
+func TestFixes_ListAvailableVersionsError(t *testing.T) {
+	fs, err := subject.NewFixes(
+		newMockCookbook(chef.CookbookListResult{}, errors.New("list error"), nil),
+		nil,
+		Workers,
+	)
+	assert.Nil(t, fs)
+	assert.EqualError(t, err, "unable to retrieve cookbooks: list error")
+}
+
+func TestDiffDirs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diff")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		a = filepath.Join(dir, "a")
+		b = filepath.Join(dir, "b")
+	)
+	writeFiles(t, a, map[string]string{"same.rb": "same\n", "removed.rb": "gone\n"})
+	writeFiles(t, b, map[string]string{"same.rb": "same\n", "added.rb": "new\n"})
+
+	patch, err := subject.DiffDirs(a, b)
+	assert.Nil(t, err)
+	assert.Equal(t, `--- /dev/null
++++ b/added.rb
+@@ -0,0 +1 @@
++new
+--- a/removed.rb
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+`, patch)
+
+	fix := subject.CookbookFix{Patch: patch}
+	assert.Equal(t, []string{"added.rb", "removed.rb"}, fix.ChangedFiles())
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for p, content := range files {
+		target := filepath.Join(dir, p)
+		if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(target, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
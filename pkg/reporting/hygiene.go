@@ -0,0 +1,108 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"sort"
+	"time"
+)
+
+// attributes that identify a machine, nodes sharing any of them are usually
+// leftovers of rebuilt hosts that were registered again under a new name
+const (
+	DuplicateFQDN      = "fqdn"
+	DuplicateHostname  = "hostname"
+	DuplicateIPAddress = "ipaddress"
+)
+
+// NodesHygiene lists the nodes that haven't checked in for a while and the
+// groups of nodes that look like the same machine
+type NodesHygiene struct {
+	StaleAfter time.Duration
+	StaleNodes []*NodeReportItem
+	Duplicates []*DuplicateNodes
+}
+
+// DuplicateNodes is a group of nodes that share the same value of an attribute
+type DuplicateNodes struct {
+	Attribute string
+	Value     string
+	Nodes     []string
+}
+
+// NewNodesHygiene marks the nodes that haven't checked in within the stale period
+// and groups the nodes that share the same fqdn, hostname or IP address, a zero
+// stale period disables the detection of stale nodes
+func NewNodesHygiene(records []*NodeReportItem, staleAfter time.Duration, now time.Time) *NodesHygiene {
+	hygiene := &NodesHygiene{
+		StaleAfter: staleAfter,
+		StaleNodes: make([]*NodeReportItem, 0),
+		Duplicates: make([]*DuplicateNodes, 0),
+	}
+
+	// attribute -> value -> nodes
+	groups := map[string]map[string][]string{
+		DuplicateFQDN:      map[string][]string{},
+		DuplicateHostname:  map[string][]string{},
+		DuplicateIPAddress: map[string][]string{},
+	}
+
+	for _, record := range records {
+		record.Stale = isStale(record.LastCheckIn(), staleAfter, now)
+		if record.Stale {
+			hygiene.StaleNodes = append(hygiene.StaleNodes, record)
+		}
+
+		for attribute, value := range map[string]string{
+			DuplicateFQDN:      record.FQDN,
+			DuplicateHostname:  record.Hostname,
+			DuplicateIPAddress: record.IPAddress,
+		} {
+			if value != "" {
+				groups[attribute][value] = append(groups[attribute][value], record.Name)
+			}
+		}
+	}
+
+	for _, attribute := range []string{DuplicateFQDN, DuplicateHostname, DuplicateIPAddress} {
+		duplicates := make([]*DuplicateNodes, 0)
+		for value, nodes := range groups[attribute] {
+			if len(nodes) < 2 {
+				continue
+			}
+			sort.Strings(nodes)
+			duplicates = append(duplicates, &DuplicateNodes{Attribute: attribute, Value: value, Nodes: nodes})
+		}
+		sort.Slice(duplicates, func(i, j int) bool {
+			return duplicates[i].Value < duplicates[j].Value
+		})
+		hygiene.Duplicates = append(hygiene.Duplicates, duplicates...)
+	}
+
+	// the oldest check-in first, those are the first candidates to clean up,
+	// nodes that never checked in go before any of them
+	sort.SliceStable(hygiene.StaleNodes, func(i, j int) bool {
+		return hygiene.StaleNodes[i].OhaiTime < hygiene.StaleNodes[j].OhaiTime
+	})
+
+	return hygiene
+}
+
+// returns true if there is nothing to clean up
+func (nh *NodesHygiene) IsClean() bool {
+	return len(nh.StaleNodes) == 0 && len(nh.Duplicates) == 0
+}
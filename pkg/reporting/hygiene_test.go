@@ -0,0 +1,74 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func TestNodesHygiene(t *testing.T) {
+	var (
+		now     = time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC)
+		daysAgo = func(days int) float64 {
+			return float64(now.Add(-time.Duration(days) * 24 * time.Hour).Unix())
+		}
+		records = []*subject.NodeReportItem{
+			&subject.NodeReportItem{Name: "web01", FQDN: "web01.example.com", Hostname: "web01",
+				IPAddress: "10.0.0.1", OhaiTime: daysAgo(90)},
+			&subject.NodeReportItem{Name: "web01-rebuilt", FQDN: "web01.example.com", Hostname: "web01",
+				IPAddress: "10.0.0.2", OhaiTime: daysAgo(1)},
+			&subject.NodeReportItem{Name: "db01", FQDN: "db01.example.com", Hostname: "db01",
+				IPAddress: "10.0.0.2", OhaiTime: daysAgo(45)},
+			&subject.NodeReportItem{Name: "new", IPAddress: "10.0.0.3"},
+		}
+	)
+
+	hygiene := subject.NewNodesHygiene(records, 30*24*time.Hour, now)
+	assert.False(t, hygiene.IsClean())
+
+	if assert.Equal(t, 3, len(hygiene.StaleNodes)) {
+		assert.Equal(t, "new", hygiene.StaleNodes[0].Name, "nodes that never checked in should be first")
+		assert.Equal(t, "web01", hygiene.StaleNodes[1].Name, "the oldest check-in should be next")
+		assert.Equal(t, "db01", hygiene.StaleNodes[2].Name)
+	}
+	assert.True(t, records[0].Stale)
+	assert.False(t, records[1].Stale)
+	assert.True(t, records[3].Stale, "nodes that never checked in are stale")
+
+	assert.Equal(t, []*subject.DuplicateNodes{
+		{Attribute: subject.DuplicateFQDN, Value: "web01.example.com", Nodes: []string{"web01", "web01-rebuilt"}},
+		{Attribute: subject.DuplicateHostname, Value: "web01", Nodes: []string{"web01", "web01-rebuilt"}},
+		{Attribute: subject.DuplicateIPAddress, Value: "10.0.0.2", Nodes: []string{"db01", "web01-rebuilt"}},
+	}, hygiene.Duplicates)
+}
+
+func TestNodesHygieneWithoutStalePeriod(t *testing.T) {
+	records := []*subject.NodeReportItem{
+		&subject.NodeReportItem{Name: "old", OhaiTime: 1},
+		&subject.NodeReportItem{Name: "new"},
+	}
+
+	hygiene := subject.NewNodesHygiene(records, 0, time.Now())
+	assert.True(t, hygiene.IsClean())
+	assert.False(t, records[0].Stale)
+	assert.False(t, records[1].Stale)
+}
@@ -0,0 +1,264 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RunJournal checkpoints every cookbook record that finished its analysis
+// successfully so that an interrupted run can be resumed later on, only the
+// cookbook versions that are not in the journal are analyzed again
+//
+// the journal is a file with one JSON entry per line, the first entry holds
+// the options of the run and the rest are the finished cookbook records
+type RunJournal struct {
+	ID        string
+	path      string
+	file      *os.File
+	mutex     sync.Mutex
+	options   *RunOptions
+	completed map[string]*CookbookRecord
+}
+
+// RunOptions are the options that change the results of a run, a run can only
+// be resumed with the same options it was started with
+type RunOptions struct {
+	VerifyUpgrade bool     `json:"verify_upgrade"`
+	OnlyUnused    bool     `json:"only_unused"`
+	AnalyzeAll    bool     `json:"analyze_all"`
+	Filters       []string `json:"filters"`
+	StaleAfter    string   `json:"stale_after,omitempty"`
+}
+
+type journalEntry struct {
+	Options *RunOptions    `json:"options,omitempty"`
+	Record  *journalRecord `json:"record,omitempty"`
+}
+
+type journalRecord struct {
+	Name              string            `json:"name"`
+	Version           string            `json:"version"`
+	Files             []CookbookFile    `json:"files"`
+	Nodes             []string          `json:"nodes"`
+	Policies          []string          `json:"policies"`
+	CookstyleMetadata CookstyleMetadata `json:"cookstyle_metadata"`
+}
+
+var runIDRegex = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// creates a new run journal inside the provided directory
+func NewRunJournal(dir string) (*RunJournal, error) {
+	suffix := make([]byte, 2)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, errors.Wrap(err, "unable to generate run id")
+	}
+
+	id := time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+	journal := &RunJournal{
+		ID:        id,
+		path:      runJournalPath(dir, id),
+		completed: map[string]*CookbookRecord{},
+	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, errors.Wrapf(err, "unable to create runs directory '%s'", dir)
+	}
+
+	var err error
+	journal.file, err = os.OpenFile(journal.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to create run journal '%s'", journal.path)
+	}
+
+	return journal, nil
+}
+
+// opens the journal of an existing run to resume it
+func OpenRunJournal(dir, id string) (*RunJournal, error) {
+	if !runIDRegex.MatchString(id) {
+		return nil, errors.Errorf("invalid run id '%s'", id)
+	}
+
+	journal := &RunJournal{
+		ID:        id,
+		path:      runJournalPath(dir, id),
+		completed: map[string]*CookbookRecord{},
+	}
+
+	file, err := os.Open(journal.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Errorf("run '%s' not found in '%s'", id, dir)
+		}
+		return nil, errors.Wrapf(err, "unable to open run journal '%s'", journal.path)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var entry journalEntry
+		// NOTE: an interrupted run might have left a partial entry at the end
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+
+		if entry.Options != nil {
+			journal.options = entry.Options
+		}
+		if entry.Record != nil {
+			journal.completed[entry.Record.Name+"@"+entry.Record.Version] = entry.Record.cookbookRecord()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "unable to read run journal '%s'", journal.path)
+	}
+
+	journal.file, err = os.OpenFile(journal.path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open run journal '%s'", journal.path)
+	}
+
+	return journal, nil
+}
+
+func runJournalPath(dir, id string) string {
+	return filepath.Join(dir, id+".jsonl")
+}
+
+// records the options of a new run, or verifies that a resumed run
+// uses the same options it was started with
+func (j *RunJournal) Start(options RunOptions) error {
+	if options.Filters == nil {
+		options.Filters = []string{}
+	}
+
+	if j.options != nil {
+		if !reflect.DeepEqual(*j.options, options) {
+			return errors.Errorf(
+				"run '%s' was started with different options, use the same flags and cookbook filters to resume it",
+				j.ID,
+			)
+		}
+		return nil
+	}
+
+	j.options = &options
+	return j.write(journalEntry{Options: j.options})
+}
+
+// returns the record of a cookbook version that was already analyzed
+func (j *RunJournal) Completed(name, version string) (*CookbookRecord, bool) {
+	if j == nil {
+		return nil, false
+	}
+	record, ok := j.completed[name+"@"+version]
+	return record, ok
+}
+
+// returns the number of cookbook versions that were already analyzed
+func (j *RunJournal) NumCompleted() int {
+	if j == nil {
+		return 0
+	}
+	return len(j.completed)
+}
+
+// checkpoints a finished cookbook record, records with errors are not
+// checkpointed so that they are analyzed again when the run is resumed
+func (j *RunJournal) Checkpoint(record *CookbookRecord) error {
+	if j == nil || len(record.Errors()) != 0 {
+		return nil
+	}
+
+	return j.write(journalEntry{Record: &journalRecord{
+		Name:              record.Name,
+		Version:           record.Version,
+		Files:             record.Files,
+		Nodes:             record.Nodes,
+		Policies:          record.Policies,
+		CookstyleMetadata: record.CookstyleMetadata,
+	}})
+}
+
+func (j *RunJournal) write(entry journalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "unable to encode run journal entry")
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if j.file == nil {
+		return errors.Errorf("run journal '%s' is closed", j.path)
+	}
+	// a single write per entry, if the run is interrupted, at most
+	// the last entry is lost
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return errors.Wrapf(err, "unable to write run journal '%s'", j.path)
+	}
+	return nil
+}
+
+// closes the journal file, closing a journal more than once is a no-op
+func (j *RunJournal) Close() error {
+	if j == nil {
+		return nil
+	}
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	if err != nil {
+		return errors.Wrapf(err, "unable to close run journal '%s'", j.path)
+	}
+	return nil
+}
+
+// removes the closed journal once the run finished, it can't be resumed anymore
+func (j *RunJournal) Remove() error {
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "unable to remove run journal '%s'", j.path)
+	}
+	return nil
+}
+
+func (jr *journalRecord) cookbookRecord() *CookbookRecord {
+	return &CookbookRecord{
+		Name:              jr.Name,
+		Version:           jr.Version,
+		Files:             jr.Files,
+		Nodes:             jr.Nodes,
+		Policies:          jr.Policies,
+		CookstyleMetadata: jr.CookstyleMetadata,
+	}
+}
@@ -0,0 +1,180 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	chef "github.com/chef/go-chef"
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func TestRunJournalCheckpointAndResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runs")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	options := subject.RunOptions{VerifyUpgrade: true, Filters: []string{"foo"}}
+
+	journal, err := subject.NewRunJournal(dir)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Regexp(t, `^\d{8}-\d{6}-[0-9a-f]{4}$`, journal.ID)
+	assert.Nil(t, journal.Start(options))
+	assert.Nil(t, journal.Checkpoint(&subject.CookbookRecord{
+		Name: "foo", Version: "1.0.0", Nodes: []string{"node-1"},
+		CookstyleMetadata: subject.CookstyleMetadata{RubocopVersion: "0.75.1"},
+		Files: []subject.CookbookFile{
+			subject.CookbookFile{Path: "recipes/default.rb", Offenses: []subject.CookstyleOffense{
+				subject.CookstyleOffense{CopName: "ChefDeprecations/Blah", Correctable: true},
+			}},
+		},
+	}))
+	// records with errors are analyzed again
+	assert.Nil(t, journal.Checkpoint(&subject.CookbookRecord{
+		Name: "foo", Version: "2.0.0", DownloadError: errors.New("network blip"),
+	}))
+	assert.Nil(t, journal.Close())
+
+	// emulate an interrupted write
+	journalFile := filepath.Join(dir, journal.ID+".jsonl")
+	f, err := os.OpenFile(journalFile, os.O_APPEND|os.O_WRONLY, 0644)
+	if assert.Nil(t, err) {
+		f.WriteString(`{"record":{"name":"foo","vers`)
+		f.Close()
+	}
+
+	resumed, err := subject.OpenRunJournal(dir, journal.ID)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer resumed.Close()
+
+	assert.Equal(t, 1, resumed.NumCompleted())
+	record, ok := resumed.Completed("foo", "1.0.0")
+	if assert.True(t, ok) {
+		assert.Equal(t, []string{"node-1"}, record.Nodes)
+		assert.Equal(t, "0.75.1", record.CookstyleMetadata.RubocopVersion)
+		assert.Equal(t, 1, record.NumCorrectable())
+	}
+	_, ok = resumed.Completed("foo", "2.0.0")
+	assert.False(t, ok)
+
+	assert.Nil(t, resumed.Start(options))
+	assert.EqualError(t,
+		resumed.Start(subject.RunOptions{VerifyUpgrade: true}),
+		"run '"+journal.ID+"' was started with different options, use the same flags and cookbook filters to resume it",
+	)
+
+	assert.Nil(t, resumed.Close())
+	assert.Nil(t, resumed.Close(), "closing twice should be a no-op")
+	assert.Nil(t, resumed.Remove())
+	_, err = subject.OpenRunJournal(dir, journal.ID)
+	assert.EqualError(t, err, "run '"+journal.ID+"' not found in '"+dir+"'")
+}
+
+func TestOpenRunJournalInvalidID(t *testing.T) {
+	_, err := subject.OpenRunJournal("runs", "../../etc/passwd")
+	assert.EqualError(t, err, "invalid run id '../../etc/passwd'")
+}
+
+func TestCookbooksResumeFromJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runs")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	cookbookList := chef.CookbookListResult{
+		"foo": chef.CookbookVersions{
+			Versions: []chef.CookbookVersion{
+				chef.CookbookVersion{Version: "0.1.0"},
+				chef.CookbookVersion{Version: "0.2.0"},
+			},
+		},
+	}
+
+	// a previous attempt analyzed one of the cookbook versions
+	journal, err := subject.NewRunJournal(dir)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Nil(t, journal.Start(subject.RunOptions{}))
+	assert.Nil(t, journal.Checkpoint(&subject.CookbookRecord{
+		Name: "foo", Version: "0.1.0", Nodes: []string{"from-journal"},
+	}))
+	assert.Nil(t, journal.Close())
+
+	resumed, err := subject.OpenRunJournal(dir, journal.ID)
+	if !assert.Nil(t, err) {
+		return
+	}
+	c, err := subject.NewCookbooks(
+		newMockCookbook(cookbookList, nil, nil),
+		makeMockSearch(mockedCookbooksUsageSearchRows(), nil),
+		false,
+		false,
+		Workers,
+		func(cbs *subject.CookbooksStatus) {
+			cbs.Journal = resumed
+		},
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, resumed.Close())
+	if assert.NotNil(t, c) && assert.Equal(t, 2, len(c.Records)) {
+		for _, rec := range c.Records {
+			if rec.Version == "0.1.0" {
+				assert.Equal(t, []string{"from-journal"}, rec.Nodes, "record should come from the journal")
+			} else {
+				assert.NotContains(t, rec.Nodes, "from-journal")
+			}
+		}
+	}
+
+	// the newly analyzed version was checkpointed as well
+	resumed, err = subject.OpenRunJournal(dir, journal.ID)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 2, resumed.NumCompleted())
+		resumed.Close()
+	}
+
+	// different options can't resume the run
+	resumed, err = subject.OpenRunJournal(dir, journal.ID)
+	if assert.Nil(t, err) {
+		defer resumed.Close()
+		_, err = subject.NewCookbooks(
+			newMockCookbook(cookbookList, nil, nil),
+			makeMockSearch(mockedCookbooksUsageSearchRows(), nil),
+			false,
+			true,
+			Workers,
+			func(cbs *subject.CookbooksStatus) {
+				cbs.Journal = resumed
+			},
+		)
+		assert.NotNil(t, err)
+	}
+}
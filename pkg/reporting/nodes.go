@@ -18,6 +18,8 @@ package reporting
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -32,11 +34,29 @@ func (cbv *CookbookVersion) String() string {
 }
 
 type NodeReportItem struct {
-	Name             string
-	ChefVersion      string
-	OS               string
-	OSVersion        string
+	Name        string
+	ChefVersion string
+	OhaiVersion string
+	RubyVersion string
+	OS          string
+	OSVersion   string
+	// platform family, like 'rhel' for rocky, almalinux and centos nodes
+	OSFamily         string
+	PolicyName       string
+	PolicyGroup      string
+	FQDN             string
+	Hostname         string
+	IPAddress        string
 	CookbookVersions []CookbookVersion
+	// seconds since the epoch of the last Chef Infra Client run, zero if the node never checked in
+	OhaiTime float64
+	// true when the node hasn't checked in within the stale period, see NewNodesHygiene()
+	Stale bool
+}
+
+// returns the time of the last Chef Infra Client run, or the zero time when unknown
+func (nri *NodeReportItem) LastCheckIn() time.Time {
+	return ohaiTimeToTime(nri.OhaiTime)
 }
 
 func (nri *NodeReportItem) OSVersionPretty() string {
@@ -59,52 +79,139 @@ func (nri *NodeReportItem) CookbooksList() []string {
 	return cookbooks
 }
 
-func Nodes(searcher SearchInterface) ([]*NodeReportItem, error) {
+// NodesFilter narrows down the nodes to analyze, all the provided fields are
+// combined into a single search statement (AND)
+type NodesFilter struct {
+	Environment string
+	Role        string
+	PolicyGroup string
+	Platform    string
+	// raw Solr search query
+	Query string
+}
+
+// special characters of the search query syntax that we escape from the filter
+// values, we don't escape '*' and '?' so that users can provide wildcards
+var searchSpecialCharsReplacer = strings.NewReplacer(
+	`\`, `\\`, `+`, `\+`, `-`, `\-`, `&`, `\&`, `|`, `\|`, `!`, `\!`,
+	`(`, `\(`, `)`, `\)`, `{`, `\{`, `}`, `\}`, `[`, `\[`, `]`, `\]`,
+	`^`, `\^`, `"`, `\"`, `~`, `\~`, `:`, `\:`, `/`, `\/`, ` `, `\ `,
+)
+
+// returns true if no filter has been provided
+func (nf NodesFilter) IsEmpty() bool {
+	return nf == NodesFilter{}
+}
+
+// returns the search statement to find the nodes that match the filter
+func (nf NodesFilter) SearchStatement() string {
+	terms := make([]string, 0)
+
+	for _, field := range []struct{ key, value string }{
+		{"chef_environment", nf.Environment},
+		{"role", nf.Role},
+		{"policy_group", nf.PolicyGroup},
+		{"platform", nf.Platform},
+	} {
+		if field.value != "" {
+			terms = append(terms, fmt.Sprintf("%s:%s", field.key, searchSpecialCharsReplacer.Replace(field.value)))
+		}
+	}
+
+	if nf.Query != "" {
+		terms = append(terms, fmt.Sprintf("(%s)", nf.Query))
+	}
+
+	if len(terms) == 0 {
+		return "*:*"
+	}
+
+	return strings.Join(terms, " AND ")
+}
+
+// NodesResult are the nodes that matched a search
+type NodesResult struct {
+	Records []*NodeReportItem
+	// number of nodes that the Chef Infra Server reported but didn't return,
+	// the requestor doesn't have permissions to view them
+	HiddenNodes int
+}
+
+// returns the warning displayed when a search of nodes didn't return every node
+func HiddenNodesWarning(hidden int) string {
+	return fmt.Sprintf(
+		"%d node(s) reported by the Chef Infra Server were not returned, they are left out of the report, "+
+			"verify that the client has permissions to read all nodes", hidden,
+	)
+}
+
+func Nodes(searcher SearchInterface, filter NodesFilter) (*NodesResult, error) {
 	var (
 		query = map[string]interface{}{
 			"name":         []string{"name"},
 			"chef_version": []string{"chef_packages", "chef", "version"},
+			"ohai_version": []string{"chef_packages", "ohai", "version"},
+			"ruby_version": []string{"languages", "ruby", "version"},
 			"os":           []string{"platform"},
 			"os_version":   []string{"platform_version"},
+			"os_family":    []string{"platform_family"},
+			"policy_name":  []string{"policy_name"},
+			"policy_group": []string{"policy_group"},
 			"cookbooks":    []string{"cookbooks"},
+			"ohai_time":    []string{"ohai_time"},
+			"fqdn":         []string{"fqdn"},
+			"hostname":     []string{"hostname"},
+			"ipaddress":    []string{"ipaddress"},
 		}
 	)
 
-	pres, err := searcher.PartialExec("node", "*:*", query)
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to get node(s) information")
-	}
-
-	// We use len here and not pres.Rows, because when caller does not have permissions to
-	// 	view all nodes in the result set, the actual returned number will be lower than
-	// 	the value of Rows.
-
-	results := make([]*NodeReportItem, 0, len(pres.Rows))
-	for _, element := range pres.Rows {
-
-		// cookbook version arrives as [ NAME : { version: VERSION } - we extract that here.
-		v := element.(map[string]interface{})["data"].(map[string]interface{})
-
-		if v != nil {
-			item := &NodeReportItem{
-				Name:        safeStringFromMap(v, "name"),
-				OS:          safeStringFromMap(v, "os"),
-				OSVersion:   safeStringFromMap(v, "os_version"),
-				ChefVersion: safeStringFromMap(v, "chef_version"),
-			}
+	var (
+		search  = NewPartialSearch(searcher, "node", filter.SearchStatement(), query)
+		results = make([]*NodeReportItem, 0)
+	)
+	for search.Next() {
+		// We iterate over the rows and not Total, because when caller does not have permissions
+		// 	to view all nodes in the result set, the actual returned number will be lower than
+		// 	the value of Total.
+		for _, element := range search.Page() {
+
+			// cookbook version arrives as [ NAME : { version: VERSION } - we extract that here.
+			v := element.(map[string]interface{})["data"].(map[string]interface{})
+
+			if v != nil {
+				item := &NodeReportItem{
+					Name:        safeStringFromMap(v, "name"),
+					OS:          safeStringFromMap(v, "os"),
+					OSVersion:   safeStringFromMap(v, "os_version"),
+					OSFamily:    safeStringFromMap(v, "os_family"),
+					ChefVersion: safeStringFromMap(v, "chef_version"),
+					OhaiVersion: safeStringFromMap(v, "ohai_version"),
+					RubyVersion: safeStringFromMap(v, "ruby_version"),
+					PolicyName:  safeStringFromMap(v, "policy_name"),
+					PolicyGroup: safeStringFromMap(v, "policy_group"),
+					FQDN:        safeStringFromMap(v, "fqdn"),
+					Hostname:    safeStringFromMap(v, "hostname"),
+					IPAddress:   safeStringFromMap(v, "ipaddress"),
+					OhaiTime:    safeFloatFromMap(v, "ohai_time"),
+				}
 
-			if v["cookbooks"] != nil {
-				cookbooks := v["cookbooks"].(map[string]interface{})
-				item.CookbookVersions = make([]CookbookVersion, 0, len(cookbooks))
-				for k, v := range cookbooks {
-					cbv := CookbookVersion{Name: k, Version: safeStringFromMap(v.(map[string]interface{}), "version")}
-					item.CookbookVersions = append(item.CookbookVersions, cbv)
+				if v["cookbooks"] != nil {
+					cookbooks := v["cookbooks"].(map[string]interface{})
+					item.CookbookVersions = make([]CookbookVersion, 0, len(cookbooks))
+					for k, v := range cookbooks {
+						cbv := CookbookVersion{Name: k, Version: safeStringFromMap(v.(map[string]interface{}), "version")}
+						item.CookbookVersions = append(item.CookbookVersions, cbv)
+					}
 				}
+				results = append(results, item)
 			}
-			results = append(results, item)
 		}
 	}
-	return results, nil
+	if err := search.Err(); err != nil {
+		return nil, errors.Wrap(err, "unable to get node(s) information")
+	}
+
+	return &NodesResult{Records: results, HiddenNodes: search.Missing()}, nil
 }
 
 // This returns the value referenced by `key` in `values`. If value is nil,
@@ -116,3 +223,30 @@ func safeStringFromMap(values map[string]interface{}, key string) string {
 	}
 	return values[key].(string)
 }
+
+// same as safeStringFromMap() but for numbers, it returns zero if the value is not a number
+func safeFloatFromMap(values map[string]interface{}, key string) float64 {
+	if f, ok := values[key].(float64); ok {
+		return f
+	}
+	return 0
+}
+
+// converts the ohai_time attribute of a node, seconds since the epoch, into a time
+func ohaiTimeToTime(ohaiTime float64) time.Time {
+	if ohaiTime <= 0 {
+		return time.Time{}
+	}
+	sec := int64(ohaiTime)
+	return time.Unix(sec, int64((ohaiTime-float64(sec))*float64(time.Second)))
+}
+
+// returns true if the last check-in is older than the stale period, nodes that
+// never checked in are stale too, a zero stale period disables the detection
+// of stale nodes
+func isStale(lastCheckIn time.Time, staleAfter time.Duration, now time.Time) bool {
+	if staleAfter <= 0 {
+		return false
+	}
+	return lastCheckIn.IsZero() || now.Sub(lastCheckIn) > staleAfter
+}
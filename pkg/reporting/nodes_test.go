@@ -19,6 +19,7 @@ package reporting_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -29,9 +30,11 @@ func TestNodes(t *testing.T) {
 	// It's a little less verbose and a little more readable to format
 	// this as JSON then convert it where we need it than to create it as a golang map.
 	mocksearch := makeMockSearch(mockedNodesSearchRows(), nil)
-	results, err := subject.Nodes(mocksearch)
+	nodes, err := subject.Nodes(mocksearch, subject.NodesFilter{})
 	// valid results don't mock an error.
 	assert.Nil(t, err)
+	assert.Equal(t, 0, nodes.HiddenNodes)
+	results := nodes.Records
 
 	assert.Equalf(t, 3, len(results),
 		"3 input records should give 3 output records, got %d", len(results))
@@ -39,18 +42,21 @@ func TestNodes(t *testing.T) {
 	// TODO - should we test one field or record at a time, or is it safe practice to compare the full results?
 	//        there are a lot of things packed into this test if we compare full results.
 	expected := []*subject.NodeReportItem{
-		&subject.NodeReportItem{Name: "node1", ChefVersion: "12.22", OS: "windows", OSVersion: "10.1",
+		&subject.NodeReportItem{Name: "node1", ChefVersion: "12.22", OhaiVersion: "8.26.1", RubyVersion: "2.4.4",
+			OS: "windows", OSVersion: "10.1", OSFamily: "windows",
+			FQDN: "web01.example.com", Hostname: "web01", IPAddress: "10.0.0.1", OhaiTime: 1576492200.5,
 			CookbookVersions: []subject.CookbookVersion{
 				subject.CookbookVersion{Name: "mycookbook", Version: "1.0"}},
 		},
 		&subject.NodeReportItem{Name: "node2", ChefVersion: "13.11", OS: "", OSVersion: "",
+			FQDN: "web01.example.com", Hostname: "web01", IPAddress: "10.0.0.2", OhaiTime: 4102444800,
 			CookbookVersions: []subject.CookbookVersion{
 				subject.CookbookVersion{Name: "mycookbook", Version: "1.0"},
 				subject.CookbookVersion{Name: "test", Version: "9.9"},
 			},
 		},
-		&subject.NodeReportItem{Name: "node3", ChefVersion: "15.00", OS: "ubuntu", OSVersion: "16.04",
-			CookbookVersions: nil},
+		&subject.NodeReportItem{Name: "node3", ChefVersion: "15.00", OS: "ubuntu", OSVersion: "16.04", OSFamily: "debian",
+			PolicyName: "app", PolicyGroup: "prod", CookbookVersions: nil},
 	}
 
 	if assert.Equal(t, len(expected), len(results)) {
@@ -68,16 +74,84 @@ func TestNodes(t *testing.T) {
 	}
 }
 
+func TestNodeReportItemLastCheckIn(t *testing.T) {
+	node := subject.NodeReportItem{Name: "node1"}
+	assert.True(t, node.LastCheckIn().IsZero(), "nodes that never checked in have no last check-in")
+
+	node.OhaiTime = 1576492200.5
+	assert.Equal(t,
+		time.Date(2019, 12, 16, 10, 30, 0, 500000000, time.UTC),
+		node.LastCheckIn().UTC())
+}
+
+func TestNodesMultiplePages(t *testing.T) {
+	mocksearch := makeMockPaginatedSearch(mockedNodesSearchRows(), 0)
+	// the default page size is bigger than our mocked rows, so we need more rows
+	for len(mocksearch.desiredRows) <= subject.DefaultSearchPageSize {
+		mocksearch.desiredRows = append(mocksearch.desiredRows, mocksearch.desiredRows[0])
+	}
+
+	results, err := subject.Nodes(mocksearch, subject.NodesFilter{})
+	assert.Nil(t, err)
+	assert.Equal(t, len(mocksearch.desiredRows), len(results.Records))
+	assert.Equal(t, []int{0, subject.DefaultSearchPageSize}, mocksearch.requestedStarts)
+}
+
+func TestNodesHiddenRows(t *testing.T) {
+	// the server reports 5 nodes but the requestor can only view 3 of them
+	mocksearch := makeMockPaginatedSearch(mockedNodesSearchRows(), 2)
+
+	results, err := subject.Nodes(mocksearch, subject.NodesFilter{})
+	if assert.Nil(t, err) {
+		assert.Equal(t, 3, len(results.Records))
+		assert.Equal(t, 2, results.HiddenNodes)
+	}
+	assert.Contains(t, subject.HiddenNodesWarning(2), "2 node(s) reported by the Chef Infra Server were not returned")
+}
+
 func TestErrorResult(t *testing.T) {
 	expectedError := fmt.Errorf("error here")
 	mocksearch := makeMockSearch("", expectedError)
-	report, err := subject.Nodes(mocksearch)
+	report, err := subject.Nodes(mocksearch, subject.NodesFilter{})
 	if assert.NotNil(t, err) {
 		assert.Equal(t, "unable to get node(s) information: error here", err.Error())
 		assert.Nil(t, report)
 	}
 }
 
+func TestNodesWithFilter(t *testing.T) {
+	mocksearch := makeMockPaginatedSearch(mockedNodesSearchRows(), 0)
+	filter := subject.NodesFilter{Environment: "qa", Platform: "ubuntu"}
+
+	_, err := subject.Nodes(mocksearch, filter)
+	assert.Nil(t, err)
+	assert.Equal(t, "chef_environment:qa AND platform:ubuntu", mocksearch.requestedStatement)
+}
+
+func TestNodesFilterSearchStatement(t *testing.T) {
+	cases := []struct {
+		filter   subject.NodesFilter
+		expected string
+	}{
+		{subject.NodesFilter{}, "*:*"},
+		{subject.NodesFilter{Environment: "production"}, "chef_environment:production"},
+		{subject.NodesFilter{Role: "web*"}, "role:web*"},
+		{subject.NodesFilter{PolicyGroup: "prod-east"}, `policy_group:prod\-east`},
+		{subject.NodesFilter{Platform: "redhat"}, "platform:redhat"},
+		{subject.NodesFilter{Query: "name:web* OR name:db*"}, "(name:web* OR name:db*)"},
+		{
+			subject.NodesFilter{Environment: "qa", Role: "base", PolicyGroup: "dev", Platform: "centos", Query: "tags:foo"},
+			"chef_environment:qa AND role:base AND policy_group:dev AND platform:centos AND (tags:foo)",
+		},
+		{subject.NodesFilter{Environment: "a b:c"}, `chef_environment:a\ b\:c`},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, c.filter.SearchStatement())
+		assert.Equal(t, c.filter == subject.NodesFilter{}, c.filter.IsEmpty())
+	}
+}
+
 func TestCookbookVersionString(t *testing.T) {
 	cbv := subject.CookbookVersion{Name: "name", Version: "version"}
 	assert.Equal(t, "name(version)", cbv.String())
@@ -154,8 +228,17 @@ func equalsCookbookVersionsArray(t *testing.T, expected, actual []subject.Cookbo
 func equalsNodeReportItem(t *testing.T, expected *subject.NodeReportItem, actual *subject.NodeReportItem) {
 	assert.Equal(t, expected.Name, actual.Name)
 	assert.Equal(t, expected.ChefVersion, actual.ChefVersion)
+	assert.Equal(t, expected.OhaiVersion, actual.OhaiVersion)
+	assert.Equal(t, expected.RubyVersion, actual.RubyVersion)
 	assert.Equal(t, expected.OS, actual.OS)
 	assert.Equal(t, expected.OSVersion, actual.OSVersion)
+	assert.Equal(t, expected.OSFamily, actual.OSFamily)
+	assert.Equal(t, expected.PolicyName, actual.PolicyName)
+	assert.Equal(t, expected.PolicyGroup, actual.PolicyGroup)
+	assert.Equal(t, expected.FQDN, actual.FQDN)
+	assert.Equal(t, expected.Hostname, actual.Hostname)
+	assert.Equal(t, expected.IPAddress, actual.IPAddress)
+	assert.Equal(t, expected.OhaiTime, actual.OhaiTime)
 	equalsCookbookVersionsArray(t, expected.CookbookVersions, actual.CookbookVersions)
 }
 
@@ -169,8 +252,15 @@ func mockedNodesSearchRows() string {
     "data" : {
       "name" : "node1",
       "chef_version": "12.22",
+      "ohai_version": "8.26.1",
+      "ruby_version": "2.4.4",
       "os" : "windows",
       "os_version": "10.1",
+      "os_family": "windows",
+      "fqdn": "web01.example.com",
+      "hostname": "web01",
+      "ipaddress": "10.0.0.1",
+      "ohai_time": 1576492200.5,
       "cookbooks" : {
         "mycookbook" : {
           "version" : "1.0"
@@ -184,6 +274,10 @@ func mockedNodesSearchRows() string {
       "chef_version": "13.11",
       "os" : null,
       "os_version": null,
+      "fqdn": "web01.example.com",
+      "hostname": "web01",
+      "ipaddress": "10.0.0.2",
+      "ohai_time": 4102444800,
       "cookbooks" : {
         "mycookbook" : { "version" : "1.0" },
         "test" : { "version" : "9.9" }
@@ -196,6 +290,9 @@ func mockedNodesSearchRows() string {
       "chef_version": "15.00",
       "os" : "ubuntu",
       "os_version": "16.04",
+      "os_family": "debian",
+      "policy_name": "app",
+      "policy_group": "prod",
       "cookbooks" : null
     }
   }
@@ -0,0 +1,291 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// OSRelease is an operating system release and its end-of-life date
+type OSRelease struct {
+	// platform as reported by Ohai (ubuntu, centos, redhat, windows, ...) or a
+	// platform family (rhel, debian, suse, ...), the releases of a family apply
+	// to the nodes whose platform has no releases of its own
+	Platform string `json:"platform"`
+	// platform version prefix, '7' matches every 7.x release and '6.3' the Windows
+	// release with that kernel version, the longest matching prefix wins
+	Version string `json:"version"`
+	// optional name displayed in the reports, like 'Windows Server 2012 R2'
+	Name string `json:"name,omitempty"`
+	// date the release stops being supported (YYYY-MM-DD)
+	EOL string `json:"eol"`
+}
+
+// OSReleases is the end-of-life table of the most common operating systems,
+// it can be extended or overridden with a local file, see LoadOSReleases()
+//
+// NOTE: update this table when a vendor publishes or extends an EOL date
+var OSReleases = []OSRelease{
+	{Platform: "ubuntu", Version: "14.04", EOL: "2019-04-30"},
+	{Platform: "ubuntu", Version: "16.04", EOL: "2021-04-30"},
+	{Platform: "ubuntu", Version: "18.04", EOL: "2023-05-31"},
+	{Platform: "ubuntu", Version: "20.04", EOL: "2025-05-31"},
+	{Platform: "ubuntu", Version: "22.04", EOL: "2027-06-01"},
+	{Platform: "ubuntu", Version: "24.04", EOL: "2029-05-31"},
+	{Platform: "debian", Version: "8", EOL: "2018-06-17"},
+	{Platform: "debian", Version: "9", EOL: "2020-07-06"},
+	{Platform: "debian", Version: "10", EOL: "2022-09-10"},
+	{Platform: "debian", Version: "11", EOL: "2024-08-14"},
+	{Platform: "debian", Version: "12", EOL: "2026-06-10"},
+	{Platform: "centos", Version: "6", EOL: "2020-11-30"},
+	{Platform: "centos", Version: "7", EOL: "2024-06-30"},
+	{Platform: "centos", Version: "8", EOL: "2021-12-31"},
+	{Platform: "redhat", Version: "6", EOL: "2020-11-30"},
+	{Platform: "redhat", Version: "7", EOL: "2024-06-30"},
+	{Platform: "redhat", Version: "8", EOL: "2029-05-31"},
+	{Platform: "redhat", Version: "9", EOL: "2032-05-31"},
+	{Platform: "oracle", Version: "6", EOL: "2021-03-31"},
+	{Platform: "oracle", Version: "7", EOL: "2024-12-31"},
+	{Platform: "oracle", Version: "8", EOL: "2029-07-31"},
+	// rocky, almalinux, centos stream and other rebuilds of Red Hat Enterprise Linux
+	{Platform: "rhel", Version: "7", EOL: "2024-06-30"},
+	{Platform: "rhel", Version: "8", EOL: "2029-05-31"},
+	{Platform: "rhel", Version: "9", EOL: "2032-05-31"},
+	{Platform: "amazon", Version: "2018.03", Name: "Amazon Linux AMI 2018.03", EOL: "2023-12-31"},
+	{Platform: "amazon", Version: "2", Name: "Amazon Linux 2", EOL: "2026-06-30"},
+	{Platform: "suse", Version: "12", EOL: "2024-10-31"},
+	{Platform: "suse", Version: "15", EOL: "2031-07-31"},
+	{Platform: "windows", Version: "6.1", Name: "Windows Server 2008 R2", EOL: "2020-01-14"},
+	{Platform: "windows", Version: "6.2", Name: "Windows Server 2012", EOL: "2023-10-10"},
+	{Platform: "windows", Version: "6.3", Name: "Windows Server 2012 R2", EOL: "2023-10-10"},
+	{Platform: "windows", Version: "10.0.14393", Name: "Windows Server 2016", EOL: "2027-01-12"},
+	{Platform: "windows", Version: "10.0.17763", Name: "Windows Server 2019", EOL: "2029-01-09"},
+	{Platform: "windows", Version: "10.0.20348", Name: "Windows Server 2022", EOL: "2031-10-14"},
+}
+
+// LoadOSReleases reads a JSON list of operating system releases from the provided
+// file and merges it with the embedded table, entries of the file override the
+// embedded ones with the same platform and version
+//
+// example:
+//
+//	[
+//	  {"platform": "ubuntu", "version": "16.04", "eol": "2026-04-30"},
+//	  {"platform": "rocky", "version": "8", "eol": "2029-05-31"}
+//	]
+func LoadOSReleases(path string) ([]OSRelease, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read OS end-of-life data '%s'", path)
+	}
+
+	var overrides []OSRelease
+	if err := json.Unmarshal(content, &overrides); err != nil {
+		return nil, errors.Wrapf(err, "malformed OS end-of-life data '%s'", path)
+	}
+
+	for _, release := range overrides {
+		if release.Platform == "" || release.Version == "" {
+			return nil, errors.Errorf(
+				"malformed OS end-of-life data '%s': every release requires a platform and a version", path,
+			)
+		}
+		if _, err := time.Parse("2006-01-02", release.EOL); err != nil {
+			return nil, errors.Errorf(
+				"malformed OS end-of-life data '%s': invalid EOL date '%s' of %s %s (expected YYYY-MM-DD)",
+				path, release.EOL, release.Platform, release.Version,
+			)
+		}
+	}
+
+	return MergeOSReleases(OSReleases, overrides), nil
+}
+
+// returns the provided releases with the overrides applied on top of them
+func MergeOSReleases(releases, overrides []OSRelease) []OSRelease {
+	merged := make([]OSRelease, 0, len(releases)+len(overrides))
+	for _, release := range releases {
+		overridden := false
+		for _, o := range overrides {
+			if o.Platform == release.Platform && o.Version == release.Version {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged = append(merged, release)
+		}
+	}
+	return append(merged, overrides...)
+}
+
+// OSSupportStatus groups the nodes by operating system release and its support status
+type OSSupportStatus struct {
+	Records    []*OSRecord
+	TotalNodes int
+	NearEOL    time.Duration
+}
+
+type OSRecord struct {
+	Platform string
+	// the release version of the EOL table that matched, or the platform
+	// version reported by the nodes if there was no match
+	Version string
+	Name    string
+	// support status of the release (supported, near-eol, eol or unknown)
+	Status string
+	// end-of-life date of the release, empty if unknown
+	EOL   string
+	Nodes []string
+}
+
+func (osr *OSRecord) NumNodes() int {
+	return len(osr.Nodes)
+}
+
+// returns the name of the release, like 'ubuntu 16.04' or 'Windows Server 2012 R2'
+func (osr *OSRecord) String() string {
+	if osr.Name != "" {
+		return osr.Name
+	}
+	return strings.TrimSpace(osr.Platform + " " + osr.Version)
+}
+
+// returns the number of nodes per support status
+func (oss *OSSupportStatus) NodesByStatus() map[string]int {
+	count := map[string]int{}
+	for _, record := range oss.Records {
+		count[record.Status] += record.NumNodes()
+	}
+	return count
+}
+
+// NewOSSupport groups the nodes by operating system release and looks up the
+// support status of every release in the provided releases at the provided time,
+// releases reaching their end-of-life within the nearEOL period are near-eol
+func NewOSSupport(records []*NodeReportItem, releases []OSRelease, nearEOL time.Duration, now time.Time) *OSSupportStatus {
+	var (
+		status = &OSSupportStatus{Records: make([]*OSRecord, 0), TotalNodes: len(records), NearEOL: nearEOL}
+		groups = map[string]*OSRecord{}
+	)
+
+	for _, node := range records {
+		record := &OSRecord{Platform: node.OS, Version: node.OSVersion, Status: SupportStatusUnknown}
+		platform := node.OS
+		if !hasOSReleases(platform, releases) {
+			platform = node.OSFamily
+		}
+		if release := matchOSRelease(platform, node.OSVersion, releases); release != nil {
+			record.Version = release.Version
+			record.Name = release.Name
+			record.EOL = release.EOL
+			record.Status = supportStatus(release.EOL, nearEOL, now)
+		}
+
+		key := record.Platform + " " + record.Version
+		if group, ok := groups[key]; ok {
+			record = group
+		} else {
+			record.Nodes = []string{}
+			groups[key] = record
+			status.Records = append(status.Records, record)
+		}
+		record.Nodes = append(record.Nodes, node.Name)
+	}
+
+	for _, record := range status.Records {
+		sort.Strings(record.Nodes)
+	}
+
+	// the releases that need attention first, then by platform and version
+	sort.Slice(status.Records, func(i, j int) bool {
+		a, b := status.Records[i], status.Records[j]
+		if osStatusOrder(a.Status) != osStatusOrder(b.Status) {
+			return osStatusOrder(a.Status) < osStatusOrder(b.Status)
+		}
+		if a.Platform != b.Platform {
+			return a.Platform < b.Platform
+		}
+		return compareVersionStrings(a.Version, b.Version) < 0
+	})
+
+	return status
+}
+
+func osStatusOrder(status string) int {
+	switch status {
+	case SupportStatusEOL:
+		return 0
+	case SupportStatusNearEOL:
+		return 1
+	case SupportStatusSupported:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// returns true if any release belongs to the provided platform, the releases of
+// the platform family only apply to the platforms without any of their own
+func hasOSReleases(platform string, releases []OSRelease) bool {
+	for _, release := range releases {
+		if release.Platform == platform {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the release with the longest version prefix matching the provided
+// platform version, nil if there is no match
+func matchOSRelease(platform, version string, releases []OSRelease) *OSRelease {
+	var (
+		match  *OSRelease
+		fields = strings.Split(version, ".")
+	)
+
+	for i := range releases {
+		release := &releases[i]
+		if platform == "" || release.Platform != platform {
+			continue
+		}
+
+		prefix := strings.Split(release.Version, ".")
+		if len(prefix) > len(fields) {
+			continue
+		}
+
+		matches := true
+		for n := range prefix {
+			if prefix[n] != fields[n] {
+				matches = false
+				break
+			}
+		}
+		if matches && (match == nil || len(prefix) > len(strings.Split(match.Version, "."))) {
+			match = release
+		}
+	}
+
+	return match
+}
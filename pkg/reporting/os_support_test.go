@@ -0,0 +1,186 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+var osReleasesFixture = []subject.OSRelease{
+	{Platform: "ubuntu", Version: "16.04", EOL: "2021-04-30"},
+	{Platform: "ubuntu", Version: "18.04", EOL: "2023-05-31"},
+	{Platform: "debian", Version: "12", EOL: "2028-06-30"},
+	{Platform: "centos", Version: "7", EOL: "2020-09-30"},
+	{Platform: "rhel", Version: "7", EOL: "2024-06-30"},
+	{Platform: "rhel", Version: "8", EOL: "2029-05-31"},
+	{Platform: "windows", Version: "6.3", Name: "Windows Server 2012 R2", EOL: "2023-10-10"},
+	{Platform: "windows", Version: "6.3.9600", Name: "Windows Server 2012 R2 (build 9600)", EOL: "2024-10-10"},
+}
+
+func TestNewOSSupport(t *testing.T) {
+	var (
+		now     = time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+		records = []*subject.NodeReportItem{
+			&subject.NodeReportItem{Name: "web02", OS: "ubuntu", OSVersion: "18.04"},
+			&subject.NodeReportItem{Name: "web01", OS: "ubuntu", OSVersion: "18.04"},
+			&subject.NodeReportItem{Name: "db01", OS: "centos", OSVersion: "7.8.2003"},
+			&subject.NodeReportItem{Name: "legacy", OS: "ubuntu", OSVersion: "16.04"},
+			&subject.NodeReportItem{Name: "win01", OS: "windows", OSVersion: "6.3.9600"},
+			&subject.NodeReportItem{Name: "mac01", OS: "mac_os_x", OSVersion: "10.15.4"},
+			&subject.NodeReportItem{Name: "new"},
+		}
+	)
+
+	status := subject.NewOSSupport(records, osReleasesFixture, 365*24*time.Hour, now)
+	assert.Equal(t, 7, status.TotalNodes)
+
+	if assert.Equal(t, 6, len(status.Records)) {
+		assert.Equal(t, "centos 7", status.Records[0].String(), "near-eol releases should be first")
+		assert.Equal(t, subject.SupportStatusNearEOL, status.Records[0].Status)
+		assert.Equal(t, "2020-09-30", status.Records[0].EOL)
+		assert.Equal(t, []string{"db01"}, status.Records[0].Nodes)
+
+		assert.Equal(t, "ubuntu 16.04", status.Records[1].String())
+		assert.Equal(t, subject.SupportStatusNearEOL, status.Records[1].Status)
+
+		assert.Equal(t, "ubuntu 18.04", status.Records[2].String())
+		assert.Equal(t, subject.SupportStatusSupported, status.Records[2].Status)
+		assert.Equal(t, []string{"web01", "web02"}, status.Records[2].Nodes)
+
+		assert.Equal(t, "Windows Server 2012 R2 (build 9600)", status.Records[3].String(),
+			"the longest version prefix should win")
+		assert.Equal(t, subject.SupportStatusSupported, status.Records[3].Status)
+
+		assert.Equal(t, "", status.Records[4].String())
+		assert.Equal(t, subject.SupportStatusUnknown, status.Records[4].Status)
+		assert.Equal(t, "mac_os_x 10.15.4", status.Records[5].String())
+		assert.Equal(t, subject.SupportStatusUnknown, status.Records[5].Status)
+	}
+
+	assert.Equal(t, map[string]int{
+		subject.SupportStatusNearEOL:   2,
+		subject.SupportStatusSupported: 3,
+		subject.SupportStatusUnknown:   2,
+	}, status.NodesByStatus())
+
+	status = subject.NewOSSupport(records, osReleasesFixture, 0, now.AddDate(1, 0, 0))
+	assert.Equal(t, subject.SupportStatusEOL, status.Records[0].Status)
+	assert.Equal(t, 2, status.NodesByStatus()[subject.SupportStatusEOL])
+}
+
+func TestNewOSSupportPlatformFamily(t *testing.T) {
+	var (
+		now     = time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+		records = []*subject.NodeReportItem{
+			&subject.NodeReportItem{Name: "rocky01", OS: "rocky", OSVersion: "8.5", OSFamily: "rhel"},
+			&subject.NodeReportItem{Name: "alma01", OS: "almalinux", OSVersion: "8.6", OSFamily: "rhel"},
+			// the releases of the platform take precedence over the ones of the family
+			&subject.NodeReportItem{Name: "db01", OS: "centos", OSVersion: "7.8.2003", OSFamily: "rhel"},
+			&subject.NodeReportItem{Name: "mint01", OS: "linuxmint", OSVersion: "20.1", OSFamily: "debian"},
+			// an old release of a platform with releases of its own never matches the family
+			&subject.NodeReportItem{Name: "old01", OS: "ubuntu", OSVersion: "12.04", OSFamily: "debian"},
+		}
+	)
+
+	status := subject.NewOSSupport(records, osReleasesFixture, 0, now)
+	byRelease := map[string]*subject.OSRecord{}
+	for _, record := range status.Records {
+		byRelease[record.String()] = record
+	}
+
+	if assert.Contains(t, byRelease, "almalinux 8") && assert.Contains(t, byRelease, "rocky 8") {
+		assert.Equal(t, "2029-05-31", byRelease["rocky 8"].EOL)
+		assert.Equal(t, subject.SupportStatusSupported, byRelease["rocky 8"].Status)
+		assert.Equal(t, []string{"alma01"}, byRelease["almalinux 8"].Nodes)
+	}
+	if assert.Contains(t, byRelease, "centos 7") {
+		assert.Equal(t, "2020-09-30", byRelease["centos 7"].EOL)
+	}
+	if assert.Contains(t, byRelease, "linuxmint 20.1") {
+		assert.Equal(t, subject.SupportStatusUnknown, byRelease["linuxmint 20.1"].Status)
+	}
+	if assert.Contains(t, byRelease, "ubuntu 12.04") {
+		assert.Equal(t, subject.SupportStatusUnknown, byRelease["ubuntu 12.04"].Status)
+		assert.Empty(t, byRelease["ubuntu 12.04"].EOL)
+	}
+}
+
+func TestMergeOSReleases(t *testing.T) {
+	merged := subject.MergeOSReleases(osReleasesFixture[:2], []subject.OSRelease{
+		{Platform: "ubuntu", Version: "16.04", EOL: "2026-04-30"},
+		{Platform: "rocky", Version: "8", EOL: "2029-05-31"},
+	})
+	assert.Equal(t, []subject.OSRelease{
+		{Platform: "ubuntu", Version: "18.04", EOL: "2023-05-31"},
+		{Platform: "ubuntu", Version: "16.04", EOL: "2026-04-30"},
+		{Platform: "rocky", Version: "8", EOL: "2029-05-31"},
+	}, merged)
+}
+
+func TestLoadOSReleases(t *testing.T) {
+	dir, err := ioutil.TempDir("", "os-releases")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "os-eol.json")
+	err = ioutil.WriteFile(path, []byte(`[{"platform": "rocky", "version": "8", "eol": "2029-05-31"}]`), 0644)
+	if assert.Nil(t, err) {
+		releases, err := subject.LoadOSReleases(path)
+		assert.Nil(t, err)
+		assert.Equal(t, len(subject.OSReleases)+1, len(releases))
+		assert.Contains(t, releases, subject.OSRelease{Platform: "rocky", Version: "8", EOL: "2029-05-31"})
+	}
+
+	_, err = subject.LoadOSReleases(filepath.Join(dir, "missing.json"))
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "unable to read OS end-of-life data")
+	}
+
+	err = ioutil.WriteFile(path, []byte(`{"platform": "rocky"}`), 0644)
+	if assert.Nil(t, err) {
+		_, err = subject.LoadOSReleases(path)
+		if assert.NotNil(t, err) {
+			assert.Contains(t, err.Error(), "malformed OS end-of-life data")
+		}
+	}
+
+	err = ioutil.WriteFile(path, []byte(`[{"platform": "rocky", "version": "8", "eol": "May 2029"}]`), 0644)
+	if assert.Nil(t, err) {
+		_, err = subject.LoadOSReleases(path)
+		if assert.NotNil(t, err) {
+			assert.Contains(t, err.Error(), "invalid EOL date 'May 2029' of rocky 8 (expected YYYY-MM-DD)")
+		}
+	}
+}
+
+// every release of the embedded table must have a valid EOL date
+func TestOSReleasesTable(t *testing.T) {
+	for _, release := range subject.OSReleases {
+		_, err := time.Parse("2006-01-02", release.EOL)
+		assert.Nilf(t, err, "invalid EOL date of %s %s", release.Platform, release.Version)
+	}
+}
@@ -0,0 +1,209 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+type PoliciesStatus struct {
+	Records           []*PolicyGroupRecord
+	TotalPolicyGroups int
+	TotalPolicies     int
+	// total number of nodes analyzed to find the policies usage
+	TotalNodes int
+}
+
+type PolicyGroupRecord struct {
+	Name     string
+	Policies []*PolicyRecord
+}
+
+func (pgr PolicyGroupRecord) Errors() []error {
+	errs := make([]error, 0)
+	for _, p := range pgr.Policies {
+		if p.GetError != nil {
+			errs = append(errs, p.GetError)
+		}
+	}
+	return errs
+}
+
+// PolicyRecord is the revision of a policy that is active in a policy group
+type PolicyRecord struct {
+	Name       string
+	RevisionID string
+	// number of revisions of the policy uploaded to the Chef Infra Server
+	NumRevisions  int
+	CookbookLocks []CookbookVersion
+	NumNodes      int
+	GetError      error
+}
+
+func (pr *PolicyRecord) CookbooksList() []string {
+	var cookbooks = make([]string, 0, len(pr.CookbookLocks))
+
+	for _, v := range pr.CookbookLocks {
+		cookbooks = append(cookbooks, v.String())
+	}
+
+	return cookbooks
+}
+
+// NewPolicies analyzes the policy revisions that are active in every policy group
+func NewPolicies(pi PolicyInterface, searcher SearchInterface) (*PoliciesStatus, error) {
+	fmt.Printf("Finding available policies...")
+	policies, err := pi.ListPolicies()
+	if err != nil {
+		fmt.Println(" (-)")
+		return nil, errors.Wrap(err, "unable to retrieve policies")
+	}
+	fmt.Printf(" (%d found)\n", len(policies))
+
+	fmt.Printf("Finding available policy groups...")
+	groups, err := ActivePolicyRevisions(pi)
+	if err != nil {
+		fmt.Println(" (-)")
+		return nil, err
+	}
+
+	status := &PoliciesStatus{
+		Records:           groups,
+		TotalPolicyGroups: len(groups),
+		TotalPolicies:     len(policies),
+	}
+	fmt.Printf(" (%d found)\n", status.TotalPolicyGroups)
+
+	if status.TotalPolicyGroups == 0 {
+		fmt.Println("No policy groups available for analysis")
+		return status, nil
+	}
+
+	fmt.Printf("Finding policies usage...")
+	usage, totalNodes, err := policiesUsage(searcher)
+	if err != nil {
+		fmt.Println(" (-)")
+		return nil, err
+	}
+	status.TotalNodes = totalNodes
+	fmt.Printf(" (%d nodes found)\n", totalNodes)
+
+	for _, group := range status.Records {
+		for _, policy := range group.Policies {
+			policy.NumRevisions = len(policies[policy.Name].Revisions)
+			policy.NumNodes = usage[group.Name+"/"+policy.Name]
+		}
+	}
+
+	return status, nil
+}
+
+// returns every policy group with the policy revisions that are active in it
+// and their locked cookbook versions
+func ActivePolicyRevisions(pi PolicyInterface) ([]*PolicyGroupRecord, error) {
+	groups, err := pi.ListPolicyGroups()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve policy groups")
+	}
+
+	var (
+		records = make([]*PolicyGroupRecord, 0, len(groups))
+		// the same revision could be active in multiple policy groups
+		locks = map[string]PolicyLock{}
+	)
+	for groupName, group := range groups {
+		record := &PolicyGroupRecord{Name: groupName, Policies: make([]*PolicyRecord, 0, len(group.Policies))}
+		records = append(records, record)
+
+		for policyName, ref := range group.Policies {
+			policy := &PolicyRecord{Name: policyName, RevisionID: ref.RevisionID}
+			record.Policies = append(record.Policies, policy)
+
+			key := policyName + "@" + ref.RevisionID
+			lock, ok := locks[key]
+			if !ok {
+				lock, err = pi.GetPolicyRevision(policyName, ref.RevisionID)
+				if err != nil {
+					policy.GetError = errors.Wrapf(err,
+						"unable to get revision %s of policy %s", ref.RevisionID, policyName)
+					continue
+				}
+				locks[key] = lock
+			}
+
+			policy.CookbookLocks = make([]CookbookVersion, 0, len(lock.CookbookLocks))
+			for cookbook, cookbookLock := range lock.CookbookLocks {
+				policy.CookbookLocks = append(policy.CookbookLocks,
+					CookbookVersion{Name: cookbook, Version: cookbookLock.Version},
+				)
+			}
+			sort.Slice(policy.CookbookLocks, func(i, j int) bool {
+				return policy.CookbookLocks[i].Name < policy.CookbookLocks[j].Name
+			})
+		}
+
+		sort.Slice(record.Policies, func(i, j int) bool {
+			return record.Policies[i].Name < record.Policies[j].Name
+		})
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Name < records[j].Name
+	})
+
+	return records, nil
+}
+
+// counts the number of nodes using every policy with a single sweep of all
+// the nodes, the keys of the index are 'policy_group/policy_name'
+func policiesUsage(searcher SearchInterface) (map[string]int, int, error) {
+	var (
+		usage      = map[string]int{}
+		totalNodes = 0
+		query      = map[string]interface{}{
+			"policy_name":  []string{"policy_name"},
+			"policy_group": []string{"policy_group"},
+		}
+		search = NewPartialSearch(searcher, "node", "*:*", query)
+	)
+
+	for search.Next() {
+		for _, element := range search.Page() {
+			v := element.(map[string]interface{})["data"].(map[string]interface{})
+			if v == nil {
+				continue
+			}
+
+			totalNodes++
+
+			// nodes not using policies won't have any policy name
+			name := safeStringFromMap(v, "policy_name")
+			if name == "" {
+				continue
+			}
+			usage[safeStringFromMap(v, "policy_group")+"/"+name]++
+		}
+	}
+	if err := search.Err(); err != nil {
+		return nil, 0, errors.Wrap(err, "unable to get policy usage information")
+	}
+
+	return usage, totalNodes, nil
+}
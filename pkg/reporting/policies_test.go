@@ -0,0 +1,181 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func TestPolicies(t *testing.T) {
+	var (
+		policymock = newMockPolicies()
+		searchmock = makeMockPaginatedSearch(mockedPoliciesUsageSearchRows(), 0)
+	)
+
+	policies, err := subject.NewPolicies(policymock, searchmock)
+	assert.Nil(t, err)
+	if assert.NotNil(t, policies) {
+		assert.Equal(t, 3, policies.TotalPolicyGroups)
+		assert.Equal(t, 2, policies.TotalPolicies)
+		assert.Equal(t, 4, policies.TotalNodes)
+
+		if assert.Equal(t, 3, len(policies.Records)) {
+			dev := policies.Records[0]
+			assert.Equal(t, "dev", dev.Name)
+			if assert.Equal(t, 1, len(dev.Policies)) {
+				app := dev.Policies[0]
+				assert.Equal(t, "app", app.Name)
+				assert.Equal(t, "rev2", app.RevisionID)
+				assert.Equal(t, 2, app.NumRevisions)
+				assert.Equal(t, 1, app.NumNodes)
+				assert.Equal(t, []string{"apache2(5.1.0)", "base(1.0.0)"}, app.CookbooksList())
+			}
+
+			empty := policies.Records[1]
+			assert.Equal(t, "empty", empty.Name)
+			assert.Empty(t, empty.Policies)
+
+			prod := policies.Records[2]
+			assert.Equal(t, "prod", prod.Name)
+			if assert.Equal(t, 2, len(prod.Policies)) {
+				app := prod.Policies[0]
+				assert.Equal(t, "app", app.Name)
+				assert.Equal(t, "rev1", app.RevisionID)
+				assert.Equal(t, 2, app.NumNodes)
+				assert.Equal(t, []string{"apache2(5.0.1)", "base(1.0.0)"}, app.CookbooksList())
+
+				db := prod.Policies[1]
+				assert.Equal(t, "db", db.Name)
+				assert.Equal(t, 1, db.NumRevisions)
+				assert.Equal(t, 0, db.NumNodes)
+				assert.Equal(t, []string{"mysql(8.0.0)"}, db.CookbooksList())
+			}
+			assert.Empty(t, prod.Errors())
+		}
+	}
+}
+
+func TestPoliciesRevisionsAreFetchedOnce(t *testing.T) {
+	policymock := newMockPolicies()
+	policymock.desiredPolicyGroups["staging"] = policymock.desiredPolicyGroups["prod"]
+
+	groups, err := subject.ActivePolicyRevisions(policymock)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(groups))
+	assert.ElementsMatch(t, []string{"app@rev1", "app@rev2", "db@rev1"}, policymock.requestedRevisionsKeys)
+}
+
+func TestPoliciesErrors(t *testing.T) {
+	policymock := newMockPolicies()
+	policymock.desiredPoliciesError = errors.New("policies error")
+	policies, err := subject.NewPolicies(policymock, makeMockPaginatedSearch("", 0))
+	assert.Nil(t, policies)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "unable to retrieve policies: policies error", err.Error())
+	}
+
+	policymock = newMockPolicies()
+	policymock.desiredGroupsError = errors.New("groups error")
+	policies, err = subject.NewPolicies(policymock, makeMockPaginatedSearch("", 0))
+	assert.Nil(t, policies)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "unable to retrieve policy groups: groups error", err.Error())
+	}
+
+	policymock = newMockPolicies()
+	policies, err = subject.NewPolicies(policymock, makeMockSearch("", errors.New("search error")))
+	assert.Nil(t, policies)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "unable to get policy usage information: search error", err.Error())
+	}
+
+	policymock = newMockPolicies()
+	policymock.desiredRevisionError = errors.New("revision error")
+	policies, err = subject.NewPolicies(policymock, makeMockPaginatedSearch("", 0))
+	assert.Nil(t, err)
+	if assert.NotNil(t, policies) {
+		errs := policies.Records[0].Errors()
+		if assert.Equal(t, 1, len(errs)) {
+			assert.Equal(t, "unable to get revision rev2 of policy app: revision error", errs[0].Error())
+		}
+	}
+}
+
+func TestPoliciesEmpty(t *testing.T) {
+	policymock := &PolicyMock{
+		desiredPolicyGroups: subject.PolicyGroupsResult{},
+		desiredPolicies:     subject.PoliciesResult{},
+	}
+	policies, err := subject.NewPolicies(policymock, makeMockPaginatedSearch("", 0))
+	assert.Nil(t, err)
+	if assert.NotNil(t, policies) {
+		assert.Equal(t, 0, policies.TotalPolicyGroups)
+		assert.Empty(t, policies.Records)
+	}
+}
+
+func newMockPolicies() *PolicyMock {
+	return &PolicyMock{
+		desiredPolicyGroups: subject.PolicyGroupsResult{
+			"dev": subject.PolicyGroup{Policies: map[string]subject.PolicyRevisionRef{
+				"app": subject.PolicyRevisionRef{RevisionID: "rev2"},
+			}},
+			"prod": subject.PolicyGroup{Policies: map[string]subject.PolicyRevisionRef{
+				"app": subject.PolicyRevisionRef{RevisionID: "rev1"},
+				"db":  subject.PolicyRevisionRef{RevisionID: "rev1"},
+			}},
+			"empty": subject.PolicyGroup{},
+		},
+		desiredPolicies: subject.PoliciesResult{
+			"app": subject.Policy{Revisions: map[string]interface{}{"rev1": map[string]interface{}{}, "rev2": map[string]interface{}{}}},
+			"db":  subject.Policy{Revisions: map[string]interface{}{"rev1": map[string]interface{}{}}},
+		},
+		desiredLocks: map[string]subject.PolicyLock{
+			"app@rev1": subject.PolicyLock{Name: "app", RevisionID: "rev1",
+				CookbookLocks: map[string]subject.CookbookLock{
+					"apache2": subject.CookbookLock{Version: "5.0.1"},
+					"base":    subject.CookbookLock{Version: "1.0.0"},
+				},
+			},
+			"app@rev2": subject.PolicyLock{Name: "app", RevisionID: "rev2",
+				CookbookLocks: map[string]subject.CookbookLock{
+					"apache2": subject.CookbookLock{Version: "5.1.0"},
+					"base":    subject.CookbookLock{Version: "1.0.0"},
+				},
+			},
+			"db@rev1": subject.PolicyLock{Name: "db", RevisionID: "rev1",
+				CookbookLocks: map[string]subject.CookbookLock{
+					"mysql": subject.CookbookLock{Version: "8.0.0"},
+				},
+			},
+		},
+	}
+}
+
+func mockedPoliciesUsageSearchRows() string {
+	return `[
+  { "data": { "policy_name": "app", "policy_group": "prod" } },
+  { "data": { "policy_name": "app", "policy_group": "prod" } },
+  { "data": { "policy_name": "app", "policy_group": "dev" } },
+  { "data": { "policy_name": null, "policy_group": null } }
+]`
+}
@@ -0,0 +1,110 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"fmt"
+	"net/url"
+
+	chef "github.com/chef/go-chef"
+)
+
+// PolicyGroupsResult is the response of the /policy_groups endpoint,
+// a map of policy group names to the policies assigned to them
+type PolicyGroupsResult map[string]PolicyGroup
+
+type PolicyGroup struct {
+	URI      string                       `json:"uri"`
+	Policies map[string]PolicyRevisionRef `json:"policies"`
+}
+
+// PolicyRevisionRef is the revision of a policy that is active in a policy group
+type PolicyRevisionRef struct {
+	RevisionID string `json:"revision_id"`
+}
+
+// PoliciesResult is the response of the /policies endpoint,
+// a map of policy names to their uploaded revisions
+type PoliciesResult map[string]Policy
+
+type Policy struct {
+	URI       string                 `json:"uri"`
+	Revisions map[string]interface{} `json:"revisions"`
+}
+
+// PolicyLock is a revision of a policy, that is, the Policyfile.lock.json
+// that was pushed to the Chef Infra Server
+type PolicyLock struct {
+	RevisionID    string                  `json:"revision_id"`
+	Name          string                  `json:"name"`
+	RunList       []string                `json:"run_list"`
+	CookbookLocks map[string]CookbookLock `json:"cookbook_locks"`
+}
+
+type CookbookLock struct {
+	Version    string `json:"version"`
+	Identifier string `json:"identifier"`
+}
+
+// ChefPolicies implements the PolicyInterface on top of a chef.Client
+type ChefPolicies struct {
+	client *chef.Client
+}
+
+func NewChefPolicies(client *chef.Client) *ChefPolicies {
+	return &ChefPolicies{client}
+}
+
+func (cp *ChefPolicies) ListPolicyGroups() (PolicyGroupsResult, error) {
+	groups := PolicyGroupsResult{}
+	err := cp.get("policy_groups", &groups)
+	return groups, err
+}
+
+func (cp *ChefPolicies) ListPolicies() (PoliciesResult, error) {
+	policies := PoliciesResult{}
+	err := cp.get("policies", &policies)
+	return policies, err
+}
+
+func (cp *ChefPolicies) GetPolicyRevision(name, revisionID string) (PolicyLock, error) {
+	lock := PolicyLock{}
+	err := cp.get(
+		fmt.Sprintf("policies/%s/revisions/%s", url.PathEscape(name), url.PathEscape(revisionID)),
+		&lock,
+	)
+	return lock, err
+}
+
+func (cp *ChefPolicies) get(path string, v interface{}) error {
+	return chefGet(cp.client, path, v)
+}
+
+// sends a GET request to an endpoint that go-chef doesn't expose and decodes
+// the JSON response into v
+func chefGet(client *chef.Client, path string, v interface{}) error {
+	req, err := client.NewRequest("GET", path, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.Do(req, v)
+	if res != nil {
+		defer res.Body.Close()
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	chef "github.com/chef/go-chef"
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func TestChefPolicies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		switch r.URL.Path {
+		case "/organizations/bubu/policy_groups":
+			fmt.Fprintln(w, `{"prod": {"uri": "https://chef/policy_groups/prod", "policies": {"app": {"revision_id": "abc"}}}}`)
+		case "/organizations/bubu/policies":
+			fmt.Fprintln(w, `{"app": {"uri": "https://chef/policies/app", "revisions": {"abc": {}, "def": {}}}}`)
+		case "/organizations/bubu/policies/app/revisions/abc":
+			fmt.Fprintln(w, `{
+  "revision_id": "abc",
+  "name": "app",
+  "run_list": ["recipe[apache2::default]"],
+  "cookbook_locks": {"apache2": {"version": "5.0.1", "identifier": "123"}}
+}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, `{"error": ["not found"]}`)
+		}
+	}))
+	defer server.Close()
+
+	client, err := chef.NewClient(&chef.Config{
+		Name:    "foo",
+		Key:     string(key()),
+		BaseURL: server.URL + "/organizations/bubu/",
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	policies := subject.NewChefPolicies(client)
+
+	groups, err := policies.ListPolicyGroups()
+	if assert.Nil(t, err) {
+		assert.Equal(t, "abc", groups["prod"].Policies["app"].RevisionID)
+	}
+
+	list, err := policies.ListPolicies()
+	if assert.Nil(t, err) {
+		assert.Equal(t, 2, len(list["app"].Revisions))
+	}
+
+	lock, err := policies.GetPolicyRevision("app", "abc")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "app", lock.Name)
+		assert.Equal(t, []string{"recipe[apache2::default]"}, lock.RunList)
+		assert.Equal(t, "5.0.1", lock.CookbookLocks["apache2"].Version)
+	}
+
+	_, err = policies.GetPolicyRevision("app", "missing")
+	assert.NotNil(t, err)
+}
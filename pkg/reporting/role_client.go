@@ -0,0 +1,55 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"net/url"
+
+	chef "github.com/chef/go-chef"
+)
+
+// RoleRunLists are the run lists of a role, the default one and the
+// environment specific ones (env_run_lists)
+type RoleRunLists struct {
+	Name        string              `json:"name"`
+	RunList     []string            `json:"run_list"`
+	EnvRunLists map[string][]string `json:"env_run_lists"`
+}
+
+// returns the run list of the role for the provided environment, and true
+// when it is an environment specific run list
+func (rrl RoleRunLists) RunListFor(environment string) ([]string, bool) {
+	if runList, ok := rrl.EnvRunLists[environment]; ok {
+		return runList, true
+	}
+	return rrl.RunList, false
+}
+
+// ChefRoleRunLists implements the RoleRunListsInterface on top of a chef.Client
+type ChefRoleRunLists struct {
+	client *chef.Client
+}
+
+func NewChefRoleRunLists(client *chef.Client) *ChefRoleRunLists {
+	return &ChefRoleRunLists{client}
+}
+
+func (crl *ChefRoleRunLists) GetRunLists(name string) (RoleRunLists, error) {
+	runLists := RoleRunLists{}
+	err := chefGet(crl.client, "roles/"+url.PathEscape(name), &runLists)
+	return runLists, err
+}
@@ -0,0 +1,71 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	chef "github.com/chef/go-chef"
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func TestChefRoleRunLists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		switch r.URL.Path {
+		case "/organizations/bubu/roles/base":
+			fmt.Fprintln(w, `{
+  "name": "base",
+  "run_list": ["recipe[ntp]"],
+  "env_run_lists": {"production": ["recipe[ntp]", "role[security]"]}
+}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, `{"error": ["not found"]}`)
+		}
+	}))
+	defer server.Close()
+
+	client, err := chef.NewClient(&chef.Config{
+		Name:    "foo",
+		Key:     string(key()),
+		BaseURL: server.URL + "/organizations/bubu/",
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	roles := subject.NewChefRoleRunLists(client)
+
+	runLists, err := roles.GetRunLists("base")
+	if assert.Nil(t, err) {
+		runList, envSpecific := runLists.RunListFor("production")
+		assert.True(t, envSpecific)
+		assert.Equal(t, []string{"recipe[ntp]", "role[security]"}, runList)
+
+		runList, envSpecific = runLists.RunListFor("_default")
+		assert.False(t, envSpecific)
+		assert.Equal(t, []string{"recipe[ntp]"}, runList)
+	}
+
+	_, err = roles.GetRunLists("missing")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,286 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"fmt"
+	"sort"
+
+	chef "github.com/chef/go-chef"
+	"github.com/pkg/errors"
+)
+
+type RolesStatus struct {
+	Records    []*RoleRecord
+	TotalRoles int
+	// total number of nodes analyzed to find the roles usage
+	TotalNodes int
+}
+
+type RoleRecord struct {
+	Name        string
+	Description string
+	RunList     []string
+	// roles pulled in by the run list, directly or through other roles
+	NestedRoles []string
+	// roles, cookbooks and recipes referenced by the run list that
+	// don't exist on the Chef Infra Server
+	MissingRoles     []string
+	MissingCookbooks []string
+	MissingRecipes   []string
+	// number of top-level attributes
+	NumDefaultAttributes  int
+	NumOverrideAttributes int
+	Nodes                 []string
+	GetError              error
+	RunListError          error
+}
+
+func (rr RoleRecord) Errors() []error {
+	errs := make([]error, 0)
+	if rr.GetError != nil {
+		errs = append(errs, rr.GetError)
+	}
+	if rr.RunListError != nil {
+		errs = append(errs, rr.RunListError)
+	}
+	return errs
+}
+
+func (rr *RoleRecord) NumNodes() int {
+	return len(rr.Nodes)
+}
+
+// a role is unused when no node has it in its expanded run list
+func (rr *RoleRecord) Unused() bool {
+	return len(rr.Nodes) == 0
+}
+
+// NewRoles analyzes every role of the Chef Infra Server
+//
+// NOTE: the Chef Infra Server only lists the recipes of the latest version of
+// every cookbook, a recipe that only exists in older versions is reported missing
+func NewRoles(roles RoleInterface, cbi CookbookInterface, searcher SearchInterface) (*RolesStatus, error) {
+	fmt.Printf("Finding available roles...")
+	roleList, err := roles.List()
+	if err != nil {
+		fmt.Println(" (-)")
+		return nil, errors.Wrap(err, "unable to retrieve roles")
+	}
+
+	status := &RolesStatus{
+		Records:    make([]*RoleRecord, 0, len(*roleList)),
+		TotalRoles: len(*roleList),
+	}
+	fmt.Printf(" (%d found)\n", status.TotalRoles)
+
+	if status.TotalRoles == 0 {
+		fmt.Println("No roles available for analysis")
+		return status, nil
+	}
+
+	cookbooks, err := cbi.ListAvailableVersions("1")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve cookbooks")
+	}
+
+	recipeList, err := cbi.ListAllRecipes()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve recipes")
+	}
+	recipes := make(map[string]bool, len(recipeList))
+	for _, recipe := range recipeList {
+		recipes[RunListItem{Type: RunListRecipe, Name: recipe}.Recipe()] = true
+	}
+
+	fmt.Printf("Finding roles usage...")
+	usage, totalNodes, err := rolesUsage(searcher)
+	if err != nil {
+		fmt.Println(" (-)")
+		return nil, err
+	}
+	status.TotalNodes = totalNodes
+	fmt.Printf(" (%d nodes found)\n", totalNodes)
+
+	fmt.Println("Analyzing roles...")
+	runLists := make(map[string][]RunListItem, status.TotalRoles)
+	for name := range *roleList {
+		record := &RoleRecord{Name: name, Nodes: usage[name]}
+		if record.Nodes == nil {
+			record.Nodes = []string{}
+		}
+
+		role, err := roles.Get(name)
+		if err != nil {
+			record.GetError = errors.Wrapf(err, "unable to get role %s", name)
+			runLists[name] = []RunListItem{}
+			status.Records = append(status.Records, record)
+			continue
+		}
+
+		record.Description = role.Description
+		record.RunList = role.RunList
+		record.NumDefaultAttributes = numTopLevelAttributes(role.DefaultAttributes)
+		record.NumOverrideAttributes = numTopLevelAttributes(role.OverrideAttributes)
+		runLists[name] = record.parseRunList(cookbooks, recipes)
+
+		status.Records = append(status.Records, record)
+	}
+
+	for _, record := range status.Records {
+		record.NestedRoles, record.MissingRoles = expandNestedRoles(record.Name, runLists)
+	}
+
+	sort.Slice(status.Records, func(i, j int) bool {
+		return status.Records[i].Name < status.Records[j].Name
+	})
+
+	return status, nil
+}
+
+// parses the run list of the role and records the cookbooks and recipes that
+// don't exist on the Chef Infra Server, returns the valid run list items
+func (rr *RoleRecord) parseRunList(cookbooks chef.CookbookListResult, recipes map[string]bool) []RunListItem {
+	var (
+		items            = make([]RunListItem, 0, len(rr.RunList))
+		invalid          = make([]string, 0)
+		missingCookbooks = map[string]bool{}
+		missingRecipes   = map[string]bool{}
+	)
+
+	for _, entry := range rr.RunList {
+		item, err := ParseRunListItem(entry)
+		if err != nil {
+			invalid = append(invalid, entry)
+			continue
+		}
+		items = append(items, item)
+
+		if item.IsRole() {
+			continue
+		}
+
+		if _, ok := cookbooks[item.Cookbook()]; !ok {
+			missingCookbooks[item.Cookbook()] = true
+			continue
+		}
+
+		if !recipes[item.Recipe()] {
+			missingRecipes[item.Recipe()] = true
+		}
+	}
+
+	if len(invalid) != 0 {
+		rr.RunListError = errors.Errorf("invalid run list item(s): %v", invalid)
+	}
+	rr.MissingCookbooks = sortedKeys(missingCookbooks)
+	rr.MissingRecipes = sortedKeys(missingRecipes)
+
+	return items
+}
+
+// walks the run lists of the roles to find every role that the provided role
+// pulls in, it returns the nested roles and the ones that don't exist
+func expandNestedRoles(name string, runLists map[string][]RunListItem) ([]string, []string) {
+	var (
+		visited = map[string]bool{name: true}
+		nested  = map[string]bool{}
+		missing = map[string]bool{}
+		queue   = []string{name}
+	)
+
+	for len(queue) != 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, item := range runLists[current] {
+			if !item.IsRole() || visited[item.Name] {
+				continue
+			}
+			visited[item.Name] = true
+
+			if _, ok := runLists[item.Name]; !ok {
+				missing[item.Name] = true
+				continue
+			}
+
+			nested[item.Name] = true
+			queue = append(queue, item.Name)
+		}
+	}
+
+	return sortedKeys(nested), sortedKeys(missing)
+}
+
+// builds an index of the nodes that have every role in their expanded run list
+// with a single sweep of all the nodes, it also returns the total number of nodes
+func rolesUsage(searcher SearchInterface) (map[string][]string, int, error) {
+	var (
+		usage      = map[string][]string{}
+		totalNodes = 0
+		query      = map[string]interface{}{
+			"name":  []string{"name"},
+			"roles": []string{"roles"},
+		}
+		search = NewPartialSearch(searcher, "node", "*:*", query)
+	)
+
+	for search.Next() {
+		for _, element := range search.Page() {
+			v := element.(map[string]interface{})["data"].(map[string]interface{})
+			if v == nil {
+				continue
+			}
+
+			totalNodes++
+
+			// nodes that have never converged won't have any roles
+			roles, ok := v["roles"].([]interface{})
+			if !ok {
+				continue
+			}
+
+			name := safeStringFromMap(v, "name")
+			for _, role := range roles {
+				if roleName, ok := role.(string); ok {
+					usage[roleName] = append(usage[roleName], name)
+				}
+			}
+		}
+	}
+	if err := search.Err(); err != nil {
+		return nil, 0, errors.Wrap(err, "unable to get role usage information")
+	}
+
+	return usage, totalNodes, nil
+}
+
+func numTopLevelAttributes(attributes interface{}) int {
+	if attrs, ok := attributes.(map[string]interface{}); ok {
+		return len(attrs)
+	}
+	return 0
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,190 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"errors"
+	"testing"
+
+	chef "github.com/chef/go-chef"
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func TestRoles(t *testing.T) {
+	var (
+		rolemock = RoleMock{desiredRoles: map[string]*chef.Role{
+			"base": &chef.Role{Name: "base", Description: "base role",
+				RunList:           chef.RunList{"recipe[ntp]", "recipe[users::sysadmins]", "role[security]"},
+				DefaultAttributes: map[string]interface{}{"ntp": map[string]interface{}{}, "users": "admin"},
+			},
+			"security": &chef.Role{Name: "security",
+				RunList:            chef.RunList{"recipe[audit@1.0.0]", "role[base]"},
+				OverrideAttributes: map[string]interface{}{"audit": true},
+			},
+			"web": &chef.Role{Name: "web",
+				RunList: chef.RunList{"role[base]", "nginx::missing", "role[monitoring]", "recipe[php]"},
+			},
+			"orphan": &chef.Role{Name: "orphan", RunList: chef.RunList{}},
+		}}
+		cbmock = CookbookMock{
+			desiredCookbookList: chef.CookbookListResult{
+				"ntp": chef.CookbookVersions{}, "users": chef.CookbookVersions{},
+				"audit": chef.CookbookVersions{}, "nginx": chef.CookbookVersions{},
+			},
+			desiredRecipes: chef.CookbookRecipesResult{"ntp", "users::sysadmins", "audit", "nginx"},
+		}
+		searchmock = makeMockPaginatedSearch(mockedRolesUsageSearchRows(), 0)
+	)
+
+	roles, err := subject.NewRoles(rolemock, cbmock, searchmock)
+	assert.Nil(t, err)
+	if assert.NotNil(t, roles) {
+		assert.Equal(t, 4, roles.TotalRoles)
+		assert.Equal(t, 3, roles.TotalNodes)
+		if assert.Equal(t, 4, len(roles.Records)) {
+			base := roles.Records[0]
+			assert.Equal(t, "base", base.Name)
+			assert.Equal(t, "base role", base.Description)
+			assert.Equal(t, []string{"recipe[ntp]", "recipe[users::sysadmins]", "role[security]"}, base.RunList)
+			assert.Equal(t, []string{"security"}, base.NestedRoles)
+			assert.Empty(t, base.MissingRoles)
+			assert.Empty(t, base.MissingCookbooks)
+			assert.Empty(t, base.MissingRecipes)
+			assert.Equal(t, 2, base.NumDefaultAttributes)
+			assert.Equal(t, 0, base.NumOverrideAttributes)
+			assert.Equal(t, []string{"node1", "node2"}, base.Nodes)
+			assert.False(t, base.Unused())
+			assert.Empty(t, base.Errors())
+
+			orphan := roles.Records[1]
+			assert.Equal(t, "orphan", orphan.Name)
+			assert.Equal(t, 0, orphan.NumNodes())
+			assert.True(t, orphan.Unused())
+
+			security := roles.Records[2]
+			assert.Equal(t, "security", security.Name)
+			assert.Equal(t, []string{"base"}, security.NestedRoles, "cycles must not loop forever")
+			assert.Equal(t, 1, security.NumOverrideAttributes)
+			assert.Equal(t, []string{"node1"}, security.Nodes)
+
+			web := roles.Records[3]
+			assert.Equal(t, "web", web.Name)
+			assert.Equal(t, []string{"base", "security"}, web.NestedRoles)
+			assert.Equal(t, []string{"monitoring"}, web.MissingRoles)
+			assert.Equal(t, []string{"php"}, web.MissingCookbooks)
+			assert.Equal(t, []string{"nginx::missing"}, web.MissingRecipes)
+			assert.Equal(t, 1, web.NumNodes())
+		}
+	}
+}
+
+func TestRolesEmpty(t *testing.T) {
+	roles, err := subject.NewRoles(RoleMock{}, CookbookMock{}, makeMockPaginatedSearch("", 0))
+	assert.Nil(t, err)
+	if assert.NotNil(t, roles) {
+		assert.Equal(t, 0, roles.TotalRoles)
+		assert.Empty(t, roles.Records)
+	}
+}
+
+func TestRolesListError(t *testing.T) {
+	roles, err := subject.NewRoles(RoleMock{desiredListError: errors.New("lookup error")},
+		CookbookMock{}, makeMockPaginatedSearch("", 0))
+	assert.Nil(t, roles)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "unable to retrieve roles: lookup error", err.Error())
+	}
+}
+
+func TestRolesRecipesError(t *testing.T) {
+	var (
+		rolemock = RoleMock{desiredRoles: map[string]*chef.Role{"base": &chef.Role{Name: "base"}}}
+		cbmock   = CookbookMock{desiredRecipesError: errors.New("recipes error")}
+	)
+	roles, err := subject.NewRoles(rolemock, cbmock, makeMockPaginatedSearch("", 0))
+	assert.Nil(t, roles)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "unable to retrieve recipes: recipes error", err.Error())
+	}
+}
+
+func TestRolesUsageError(t *testing.T) {
+	var (
+		rolemock   = RoleMock{desiredRoles: map[string]*chef.Role{"base": &chef.Role{Name: "base"}}}
+		searchmock = makeMockSearch("", errors.New("search error"))
+	)
+	roles, err := subject.NewRoles(rolemock, CookbookMock{}, searchmock)
+	assert.Nil(t, roles)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "unable to get role usage information: search error", err.Error())
+	}
+}
+
+func TestRolesGetAndRunListErrors(t *testing.T) {
+	var (
+		rolemock = RoleMock{
+			desiredRoles:    map[string]*chef.Role{"base": &chef.Role{Name: "base"}},
+			desiredGetError: errors.New("get error"),
+		}
+		searchmock = makeMockPaginatedSearch(mockedRolesUsageSearchRows(), 0)
+	)
+	roles, err := subject.NewRoles(rolemock, CookbookMock{}, searchmock)
+	assert.Nil(t, err)
+	if assert.NotNil(t, roles) && assert.Equal(t, 1, len(roles.Records)) {
+		errs := roles.Records[0].Errors()
+		if assert.Equal(t, 1, len(errs)) {
+			assert.Equal(t, "unable to get role base: get error", errs[0].Error())
+		}
+	}
+
+	rolemock = RoleMock{desiredRoles: map[string]*chef.Role{
+		"base": &chef.Role{Name: "base", RunList: chef.RunList{"foo[bar]"}},
+	}}
+	roles, err = subject.NewRoles(rolemock, CookbookMock{}, searchmock)
+	assert.Nil(t, err)
+	if assert.NotNil(t, roles) && assert.Equal(t, 1, len(roles.Records)) {
+		errs := roles.Records[0].Errors()
+		if assert.Equal(t, 1, len(errs)) {
+			assert.Equal(t, "invalid run list item(s): [foo[bar]]", errs[0].Error())
+		}
+	}
+}
+
+func mockedRolesUsageSearchRows() string {
+	return `[
+  {
+    "data": {
+      "name": "node1",
+      "roles": ["base", "security"]
+    }
+  },
+  {
+    "data": {
+      "name": "node2",
+      "roles": ["base", "web"]
+    }
+  },
+  {
+    "data": {
+      "name": "node3",
+      "roles": null
+    }
+  }
+]`
+}
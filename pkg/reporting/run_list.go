@@ -0,0 +1,103 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// types of run list items
+const (
+	RunListRole   = "role"
+	RunListRecipe = "recipe"
+)
+
+// RunListItem is a single entry of a run list, it could be either a role or a
+// recipe, recipes can optionally be pinned to a cookbook version
+//
+// examples:
+//
+//	role[base]
+//	recipe[apache2::mod_ssl@5.0.1]
+//	apache2
+type RunListItem struct {
+	Type    string
+	Name    string
+	Version string
+}
+
+func ParseRunListItem(str string) (RunListItem, error) {
+	var (
+		item    = RunListItem{Type: RunListRecipe}
+		trimmed = strings.TrimSpace(str)
+	)
+
+	if strings.HasSuffix(trimmed, "]") {
+		idx := strings.Index(trimmed, "[")
+		if idx <= 0 {
+			return item, errors.Errorf("invalid run list item '%s'", str)
+		}
+		item.Type = trimmed[:idx]
+		trimmed = trimmed[idx+1 : len(trimmed)-1]
+	}
+
+	switch item.Type {
+	case RunListRole:
+		item.Name = trimmed
+	case RunListRecipe:
+		if idx := strings.Index(trimmed, "@"); idx != -1 {
+			item.Version = trimmed[idx+1:]
+			trimmed = trimmed[:idx]
+		}
+		item.Name = trimmed
+	default:
+		return item, errors.Errorf("invalid run list item '%s'", str)
+	}
+
+	if item.Name == "" {
+		return item, errors.Errorf("invalid run list item '%s'", str)
+	}
+
+	return item, nil
+}
+
+func (i RunListItem) IsRole() bool {
+	return i.Type == RunListRole
+}
+
+// returns the name of the cookbook of a recipe item
+func (i RunListItem) Cookbook() string {
+	return strings.SplitN(i.Name, "::", 2)[0]
+}
+
+// returns the fully qualified name of a recipe item, that is, 'cookbook::recipe'
+func (i RunListItem) Recipe() string {
+	if strings.Contains(i.Name, "::") {
+		return i.Name
+	}
+	return i.Name + "::default"
+}
+
+func (i RunListItem) String() string {
+	if i.Version != "" {
+		return fmt.Sprintf("%s[%s@%s]", i.Type, i.Name, i.Version)
+	}
+	return fmt.Sprintf("%s[%s]", i.Type, i.Name)
+}
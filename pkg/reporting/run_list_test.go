@@ -0,0 +1,115 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func TestParseRunListItem(t *testing.T) {
+	cases := []struct {
+		item     string
+		expected subject.RunListItem
+		cookbook string
+		recipe   string
+	}{
+		{"role[base]", subject.RunListItem{Type: "role", Name: "base"}, "base", "base::default"},
+		{"recipe[apache2]", subject.RunListItem{Type: "recipe", Name: "apache2"}, "apache2", "apache2::default"},
+		{"recipe[apache2::mod_ssl]", subject.RunListItem{Type: "recipe", Name: "apache2::mod_ssl"}, "apache2", "apache2::mod_ssl"},
+		{"recipe[apache2::mod_ssl@5.0.1]",
+			subject.RunListItem{Type: "recipe", Name: "apache2::mod_ssl", Version: "5.0.1"}, "apache2", "apache2::mod_ssl"},
+		{"mysql", subject.RunListItem{Type: "recipe", Name: "mysql"}, "mysql", "mysql::default"},
+		{" mysql::server ", subject.RunListItem{Type: "recipe", Name: "mysql::server"}, "mysql", "mysql::server"},
+	}
+
+	for _, c := range cases {
+		item, err := subject.ParseRunListItem(c.item)
+		if assert.Nil(t, err, c.item) {
+			assert.Equal(t, c.expected, item)
+			assert.Equal(t, c.cookbook, item.Cookbook())
+			assert.Equal(t, c.recipe, item.Recipe())
+			assert.Equal(t, c.expected.Type == "role", item.IsRole())
+		}
+	}
+}
+
+func TestParseRunListItemInvalid(t *testing.T) {
+	for _, str := range []string{"", "role[]", "[base]", "foo[bar]", "recipe[@1.0.0]"} {
+		_, err := subject.ParseRunListItem(str)
+		if assert.NotNil(t, err, str) {
+			assert.Equal(t, "invalid run list item '"+str+"'", err.Error())
+		}
+	}
+}
+
+func TestRunListItemString(t *testing.T) {
+	assert.Equal(t, "role[base]", subject.RunListItem{Type: "role", Name: "base"}.String())
+	assert.Equal(t, "recipe[nginx::default@1.0.0]",
+		subject.RunListItem{Type: "recipe", Name: "nginx::default", Version: "1.0.0"}.String())
+}
+
+func TestExpandRunList(t *testing.T) {
+	roles := RoleRunListsMock{desiredRunLists: map[string]subject.RoleRunLists{
+		"base": subject.RoleRunLists{Name: "base",
+			RunList: []string{"recipe[ntp]"},
+			EnvRunLists: map[string][]string{
+				"production": []string{"recipe[ntp]", "role[security]"},
+			},
+		},
+		"security":  subject.RoleRunLists{Name: "security", RunList: []string{"recipe[audit]", "role[base]"}},
+		"webserver": subject.RoleRunLists{Name: "webserver", RunList: []string{"role[base]", "apache2", "recipe[ntp::default]"}},
+	}}
+	runList := []string{"role[webserver]", "recipe[app@1.2.3]", "recipe[apache2]", "role[ghost]", "foo[bar]"}
+
+	recipes := func(expansion *subject.RunListExpansion) []subject.RunListItem {
+		items := make([]subject.RunListItem, 0, len(expansion.Recipes))
+		for _, recipe := range expansion.Recipes {
+			items = append(items, recipe.RunListItem)
+		}
+		return items
+	}
+
+	// roles are expanded in place and only the first occurrence of a recipe is applied
+	expansion := subject.ExpandRunList(roles, "", runList)
+	assert.Equal(t, "_default", expansion.Environment)
+	assert.Equal(t, []subject.RunListItem{
+		subject.RunListItem{Type: "recipe", Name: "ntp"},
+		subject.RunListItem{Type: "recipe", Name: "apache2"},
+		subject.RunListItem{Type: "recipe", Name: "app", Version: "1.2.3"},
+	}, recipes(expansion))
+	assert.Equal(t, []string{"ghost"}, expansion.MissingRoles)
+	assert.Empty(t, expansion.RoleCycles)
+	if assert.Equal(t, 1, len(expansion.Errors)) {
+		assert.EqualError(t, expansion.Errors[0], "invalid run list item 'foo[bar]'")
+	}
+
+	// the environment specific run lists of the roles are used
+	expansion = subject.ExpandRunList(roles, "production", runList)
+	assert.Equal(t, []subject.RunListItem{
+		subject.RunListItem{Type: "recipe", Name: "ntp"},
+		subject.RunListItem{Type: "recipe", Name: "audit"},
+		subject.RunListItem{Type: "recipe", Name: "apache2"},
+		subject.RunListItem{Type: "recipe", Name: "app", Version: "1.2.3"},
+	}, recipes(expansion))
+	assert.Equal(t, [][]string{[]string{"base", "security", "base"}}, expansion.RoleCycles)
+	assert.Equal(t, "role[webserver] -> role[base] (production run list) -> role[security] -> recipe[audit::default]",
+		subject.CookbookProvenance{Chain: expansion.Recipes[1].Chain}.ChainString())
+}
@@ -0,0 +1,150 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"net/url"
+
+	chef "github.com/chef/go-chef"
+)
+
+const (
+	// number of rows we request per page, this is the same default
+	// that the Chef Infra Client uses
+	DefaultSearchPageSize = 1000
+
+	// default sorting of search results in the Chef Infra Server
+	defaultSearchSortBy = "X_CHEF_id_CHEF_X asc"
+)
+
+// ChefSearch implements the SearchInterface on top of a chef.Client
+//
+// NOTE: the go-chef SearchService.PartialExec() hard-codes the 'start' and 'rows'
+// parameters, which means that we can't paginate partial searches with it
+type ChefSearch struct {
+	client *chef.Client
+}
+
+func NewChefSearch(client *chef.Client) *ChefSearch {
+	return &ChefSearch{client}
+}
+
+func (cs *ChefSearch) PartialExecPage(idx, statement string, params map[string]interface{}, start, rows int) (chef.SearchResult, error) {
+	query := chef.SearchQuery{
+		Index: idx,
+		// the go-chef library doesn't escape the query
+		Query:  url.QueryEscape(statement),
+		SortBy: defaultSearchSortBy,
+		Start:  start,
+		Rows:   rows,
+	}
+	return query.DoPartial(cs.client, params)
+}
+
+// PartialSearch is an iterator that executes a partial search page by page,
+// callers consume the results of each page as they arrive
+//
+// example:
+//
+//	search := NewPartialSearch(searcher, "node", "*:*", params)
+//	for search.Next() {
+//	  for _, row := range search.Page() {
+//	    // process row
+//	  }
+//	}
+//	if err := search.Err(); err != nil {
+//	  return err
+//	}
+type PartialSearch struct {
+	Searcher  SearchInterface
+	Index     string
+	Statement string
+	Params    map[string]interface{}
+	PageSize  int
+
+	// total number of rows that the Chef Infra Server reported for the query
+	Total int
+	// number of rows actually received, when the requestor does not have permissions
+	// to view all the objects, the server returns less rows than the reported Total
+	Received int
+
+	start int
+	done  bool
+	page  []interface{}
+	err   error
+}
+
+func NewPartialSearch(searcher SearchInterface, idx, statement string, params map[string]interface{}) *PartialSearch {
+	return &PartialSearch{
+		Searcher:  searcher,
+		Index:     idx,
+		Statement: statement,
+		Params:    params,
+		PageSize:  DefaultSearchPageSize,
+	}
+}
+
+// fetches the next page of results, it returns false when there are no more
+// pages to fetch or when an error occurred, use Err() to verify the latter
+func (ps *PartialSearch) Next() bool {
+	if ps.done {
+		return false
+	}
+
+	if ps.PageSize <= 0 {
+		ps.PageSize = DefaultSearchPageSize
+	}
+
+	res, err := ps.Searcher.PartialExecPage(ps.Index, ps.Statement, ps.Params, ps.start, ps.PageSize)
+	if err != nil {
+		ps.err = err
+		ps.done = true
+		ps.page = nil
+		return false
+	}
+
+	ps.Total = res.Total
+	ps.Received += len(res.Rows)
+	ps.page = res.Rows
+
+	// we move forward the number of rows we requested and not the number of rows
+	// we received, the 'start' parameter is an offset of the full result set
+	ps.start += ps.PageSize
+	if ps.start >= ps.Total {
+		ps.done = true
+	}
+
+	return true
+}
+
+// returns the rows of the current page
+func (ps *PartialSearch) Page() []interface{} {
+	return ps.page
+}
+
+// returns the error, if any, that stopped the iteration
+func (ps *PartialSearch) Err() error {
+	return ps.err
+}
+
+// returns the number of rows that the server reported but we didn't receive
+func (ps *PartialSearch) Missing() int {
+	if ps.Total > ps.Received {
+		return ps.Total - ps.Received
+	}
+	return 0
+}
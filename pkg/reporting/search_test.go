@@ -0,0 +1,136 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	chef "github.com/chef/go-chef"
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func TestPartialSearchPagination(t *testing.T) {
+	mock := makeMockPaginatedSearch(mockedNodesSearchRows(), 0)
+	search := subject.NewPartialSearch(mock, "node", "*:*", nil)
+	search.PageSize = 2
+
+	pages := [][]interface{}{}
+	for search.Next() {
+		pages = append(pages, search.Page())
+	}
+	assert.Nil(t, search.Err())
+
+	if assert.Equal(t, 2, len(pages), "3 rows with a page size of 2 should be 2 pages") {
+		assert.Equal(t, 2, len(pages[0]))
+		assert.Equal(t, 1, len(pages[1]))
+	}
+	assert.Equal(t, []int{0, 2}, mock.requestedStarts)
+	assert.Equal(t, 3, search.Total)
+	assert.Equal(t, 3, search.Received)
+	assert.Equal(t, 0, search.Missing())
+	assert.False(t, search.Next(), "a finished search should not fetch more pages")
+}
+
+func TestPartialSearchWithRowsNotVisible(t *testing.T) {
+	// the server reports 5 rows but the requestor can only see 3 of them
+	mock := makeMockPaginatedSearch(mockedNodesSearchRows(), 2)
+	search := subject.NewPartialSearch(mock, "node", "*:*", nil)
+	search.PageSize = 2
+
+	received := 0
+	for search.Next() {
+		received += len(search.Page())
+	}
+	assert.Nil(t, search.Err())
+	assert.Equal(t, 3, received)
+	assert.Equal(t, []int{0, 2, 4}, mock.requestedStarts)
+	assert.Equal(t, 5, search.Total)
+	assert.Equal(t, 3, search.Received)
+	assert.Equal(t, 2, search.Missing())
+}
+
+func TestPartialSearchEmpty(t *testing.T) {
+	mock := makeMockPaginatedSearch(mockedEmptyNodesSearchRows(), 0)
+	search := subject.NewPartialSearch(mock, "node", "*:*", nil)
+
+	assert.True(t, search.Next(), "the first page should always be fetched")
+	assert.Empty(t, search.Page())
+	assert.False(t, search.Next())
+	assert.Nil(t, search.Err())
+	assert.Equal(t, 0, search.Total)
+}
+
+func TestPartialSearchErrorOnSecondPage(t *testing.T) {
+	mock := makeMockPaginatedSearch(mockedNodesSearchRows(), 0)
+	mock.desiredError = errors.New("i/o timeout")
+	mock.errorOnPage = 2
+	search := subject.NewPartialSearch(mock, "node", "*:*", nil)
+	search.PageSize = 1
+
+	pages := 0
+	for search.Next() {
+		pages++
+	}
+	assert.Equal(t, 1, pages)
+	assert.EqualError(t, search.Err(), "i/o timeout")
+	assert.Nil(t, search.Page())
+	assert.False(t, search.Next())
+}
+
+func TestChefSearchPartialExecPage(t *testing.T) {
+	var (
+		requestQuery map[string][]string
+		requestBody  map[string]interface{}
+		server       = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestQuery = r.URL.Query()
+			json.NewDecoder(r.Body).Decode(&requestBody)
+			assert.Equal(t, "POST", r.Method)
+			assert.True(t, strings.HasSuffix(r.URL.Path, "/search/node"))
+			fmt.Fprintln(w, `{"total": 2001, "start": 1000, "rows": [{"data": {"name": "node1"}}]}`)
+		}))
+	)
+	defer server.Close()
+
+	client, err := chef.NewClient(&chef.Config{
+		Name:    "foo",
+		Key:     string(key()),
+		BaseURL: server.URL + "/organizations/bubu/",
+	})
+	if assert.Nil(t, err) {
+		searcher := subject.NewChefSearch(client)
+		res, err := searcher.PartialExecPage("node",
+			"chef_environment:qa AND role:web",
+			map[string]interface{}{"name": []string{"name"}},
+			1000, 500,
+		)
+		assert.Nil(t, err)
+		assert.Equal(t, 2001, res.Total)
+		assert.Equal(t, 1, len(res.Rows))
+		assert.Equal(t, []string{"chef_environment:qa AND role:web"}, requestQuery["q"])
+		assert.Equal(t, []string{"1000"}, requestQuery["start"])
+		assert.Equal(t, []string{"500"}, requestQuery["rows"])
+		assert.Equal(t, map[string]interface{}{"name": []interface{}{"name"}}, requestBody)
+	}
+}
@@ -0,0 +1,279 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	chef "github.com/chef/go-chef"
+	"github.com/pkg/errors"
+)
+
+// the maximum number of cookbook versions the solver tries before giving up,
+// the search backtracks and in the worst case it is exponential
+const DefaultSolverMaxSteps = 10000
+
+// SourcedConstraint is a version constraint and what imposed it, an environment
+// or the metadata of a cookbook version
+type SourcedConstraint struct {
+	Constraint string
+	Source     string
+}
+
+func (sc SourcedConstraint) String() string {
+	return fmt.Sprintf("%s (%s)", sc.Constraint, sc.Source)
+}
+
+// SolverConflict is the cookbook that the solver couldn't find a version for
+// and the constraints that applied to it when it gave up
+type SolverConflict struct {
+	Cookbook    string
+	Constraints []SourcedConstraint
+	// true when no version of the cookbook is uploaded at all
+	NotUploaded bool
+}
+
+func (sc *SolverConflict) String() string {
+	constraints := make([]string, 0, len(sc.Constraints))
+	for _, c := range sc.Constraints {
+		constraints = append(constraints, c.String())
+	}
+
+	if sc.NotUploaded {
+		return fmt.Sprintf("cookbook %s is not uploaded, required by %s",
+			sc.Cookbook, strings.Join(constraints, ", "))
+	}
+	return fmt.Sprintf("no uploaded version of %s satisfies %s",
+		sc.Cookbook, strings.Join(constraints, ", "))
+}
+
+// Solution is the set of cookbook versions the Chef Infra Server would pick,
+// when there is no solution, the conflict explains why
+type Solution struct {
+	Cookbooks []CookbookVersion
+	Conflict  *SolverConflict
+}
+
+func (s *Solution) Solved() bool {
+	return s.Conflict == nil
+}
+
+// returns the version of the cookbook in the solution, if any
+func (s *Solution) VersionOf(cookbook string) string {
+	for _, cbv := range s.Cookbooks {
+		if cbv.Name == cookbook {
+			return cbv.Version
+		}
+	}
+	return ""
+}
+
+// Solver resolves cookbook versions like the dependency solver of the Chef
+// Infra Server: every cookbook gets the latest uploaded version that satisfies
+// the constraints of the environment and the metadata of the other cookbooks
+// in the solution, backtracking when a choice leads to a conflict
+//
+// the metadata of every cookbook version is fetched the first time the solver
+// considers it and reused across solutions
+type Solver struct {
+	MaxSteps int
+
+	cbi CookbookInterface
+	// cookbook name -> uploaded versions, latest first
+	versions map[string][]string
+	// cookbook name@version -> dependency -> version constraint
+	depends map[string]map[string]string
+	// state of the current search
+	steps         int
+	conflict      *SolverConflict
+	conflictDepth int
+}
+
+func NewSolver(cbi CookbookInterface, cookbooks chef.CookbookListResult) *Solver {
+	solver := &Solver{
+		MaxSteps: DefaultSolverMaxSteps,
+		cbi:      cbi,
+		versions: make(map[string][]string, len(cookbooks)),
+		depends:  map[string]map[string]string{},
+	}
+
+	for name, cookbookVersions := range cookbooks {
+		versions := make([]string, 0, len(cookbookVersions.Versions))
+		for _, cbv := range cookbookVersions.Versions {
+			versions = append(versions, cbv.Version)
+		}
+		sort.Slice(versions, func(i, j int) bool {
+			return compareVersionStrings(versions[i], versions[j]) > 0
+		})
+		solver.versions[name] = versions
+	}
+
+	return solver
+}
+
+// Solve finds the versions of the provided cookbooks and all their dependencies
+// that satisfy the pinned versions, like the cookbook_versions of an environment,
+// the source describes where the pins come from (e.g. 'environment production')
+func (s *Solver) Solve(cookbooks []string, pins map[string]string, source string) (*Solution, error) {
+	var (
+		selected    = map[string]string{}
+		constraints = map[string][]SourcedConstraint{}
+	)
+
+	for cookbook, pin := range pins {
+		constraints[cookbook] = []SourcedConstraint{{Constraint: pin, Source: source}}
+	}
+
+	s.steps = 0
+	s.conflict = nil
+	s.conflictDepth = -1
+
+	solved, err := s.search(selected, constraints, cookbooks)
+	if err != nil {
+		return nil, err
+	}
+
+	solution := &Solution{Cookbooks: []CookbookVersion{}}
+	if !solved {
+		solution.Conflict = s.conflict
+		return solution, nil
+	}
+
+	for _, name := range sortedStringKeys(selected) {
+		solution.Cookbooks = append(solution.Cookbooks, CookbookVersion{Name: name, Version: selected[name]})
+	}
+	return solution, nil
+}
+
+// picks a version for the first cookbook of the queue and recurses with its
+// dependencies appended to the queue, it undoes its choices when they fail
+func (s *Solver) search(selected map[string]string, constraints map[string][]SourcedConstraint,
+	queue []string) (bool, error) {
+
+	s.steps++
+	if s.steps > s.MaxSteps {
+		return false, errors.Errorf("unable to find a solution after trying %d cookbook versions", s.MaxSteps)
+	}
+
+	for len(queue) != 0 {
+		if _, ok := selected[queue[0]]; !ok {
+			break
+		}
+		queue = queue[1:]
+	}
+	if len(queue) == 0 {
+		return true, nil
+	}
+
+	name := queue[0]
+	for _, version := range s.versions[name] {
+		if !satisfiesAll(version, constraints[name]) {
+			continue
+		}
+
+		depends, err := s.dependencies(name, version)
+		if err != nil {
+			return false, err
+		}
+
+		var (
+			deps     = sortedStringKeys(depends)
+			source   = fmt.Sprintf("%s %s", name, version)
+			conflict = false
+		)
+		for _, dep := range deps {
+			constraints[dep] = append(constraints[dep], SourcedConstraint{Constraint: depends[dep], Source: source})
+			sel, ok := selected[dep]
+			if dep == name {
+				// a cookbook that depends on itself must satisfy its own constraint
+				sel, ok = version, true
+			}
+			if ok && !satisfiesAll(sel, constraints[dep][len(constraints[dep])-1:]) {
+				s.recordConflict(dep, constraints[dep], len(selected))
+				conflict = true
+			}
+		}
+
+		if !conflict {
+			selected[name] = version
+			next := make([]string, 0, len(queue)-1+len(deps))
+			next = append(next, queue[1:]...)
+			next = append(next, deps...)
+
+			solved, err := s.search(selected, constraints, next)
+			if err != nil || solved {
+				return solved, err
+			}
+			delete(selected, name)
+		}
+
+		for _, dep := range deps {
+			constraints[dep] = constraints[dep][:len(constraints[dep])-1]
+		}
+	}
+
+	s.recordConflict(name, constraints[name], len(selected))
+	return false, nil
+}
+
+// keeps the conflict found deepest in the search, it is the one closest to a
+// solution and therefore the most useful one to report
+func (s *Solver) recordConflict(name string, constraints []SourcedConstraint, depth int) {
+	if depth <= s.conflictDepth {
+		return
+	}
+	s.conflictDepth = depth
+	s.conflict = &SolverConflict{
+		Cookbook:    name,
+		Constraints: append([]SourcedConstraint{}, constraints...),
+		NotUploaded: len(s.versions[name]) == 0,
+	}
+}
+
+// returns the dependencies declared in the metadata of a cookbook version
+func (s *Solver) dependencies(name, version string) (map[string]string, error) {
+	key := name + "@" + version
+	if depends, ok := s.depends[key]; ok {
+		return depends, nil
+	}
+
+	cookbook, err := s.cbi.GetVersion(name, version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get metadata of cookbook %s (%s)", name, version)
+	}
+
+	depends := cookbook.Metadata.Depends
+	if depends == nil {
+		depends = map[string]string{}
+	}
+	s.depends[key] = depends
+	return depends, nil
+}
+
+// returns true if the version satisfies all the constraints, invalid
+// constraints are never satisfied
+func satisfiesAll(version string, constraints []SourcedConstraint) bool {
+	for _, c := range constraints {
+		constraint, err := ParseVersionConstraint(c.Constraint)
+		if err != nil || !constraint.SatisfiedBy(version) {
+			return false
+		}
+	}
+	return true
+}
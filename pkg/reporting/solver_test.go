@@ -0,0 +1,166 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"testing"
+
+	chef "github.com/chef/go-chef"
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func solverMock() (*CookbookMetadataMock, chef.CookbookListResult) {
+	versions := func(vs ...string) chef.CookbookVersions {
+		cbv := chef.CookbookVersions{}
+		for _, v := range vs {
+			cbv.Versions = append(cbv.Versions, chef.CookbookVersion{Version: v})
+		}
+		return cbv
+	}
+
+	cookbooks := chef.CookbookListResult{
+		"app":    versions("1.0.0", "2.0.0"),
+		"db":     versions("2.1.0", "1.0.0", "2.0.0", "1.5.0"),
+		"legacy": versions("1.0.0"),
+		"web":    versions("1.0.0"),
+	}
+	return &CookbookMetadataMock{
+		CookbookMock: CookbookMock{desiredCookbookList: cookbooks},
+		desiredDepends: map[string]map[string]string{
+			"app@1.0.0":    map[string]string{"db": "~> 1.0"},
+			"app@2.0.0":    map[string]string{"db": "~> 2.0"},
+			"db@1.0.0":     map[string]string{},
+			"db@1.5.0":     map[string]string{},
+			"db@2.0.0":     map[string]string{},
+			"db@2.1.0":     map[string]string{},
+			"legacy@1.0.0": map[string]string{"db": "= 1.0.0"},
+			"web@1.0.0":    map[string]string{"missing": ">= 0.0.0"},
+		},
+	}, cookbooks
+}
+
+func TestSolverPicksLatestVersions(t *testing.T) {
+	cbi, cookbooks := solverMock()
+	solution, err := subject.NewSolver(cbi, cookbooks).Solve([]string{"app"}, nil, "environment _default")
+	assert.Nil(t, err)
+	if assert.NotNil(t, solution) {
+		assert.True(t, solution.Solved())
+		assert.Equal(t, []subject.CookbookVersion{
+			{Name: "app", Version: "2.0.0"},
+			{Name: "db", Version: "2.1.0"},
+		}, solution.Cookbooks)
+		assert.Equal(t, "2.1.0", solution.VersionOf("db"))
+		assert.Equal(t, "", solution.VersionOf("legacy"))
+	}
+}
+
+func TestSolverBacktracks(t *testing.T) {
+	cbi, cookbooks := solverMock()
+	solver := subject.NewSolver(cbi, cookbooks)
+
+	// the latest app requires db 2.x, which the environment doesn't allow
+	solution, err := solver.Solve([]string{"app"}, map[string]string{"db": "< 2.0"}, "environment production")
+	assert.Nil(t, err)
+	if assert.NotNil(t, solution) {
+		assert.True(t, solution.Solved())
+		assert.Equal(t, []subject.CookbookVersion{
+			{Name: "app", Version: "1.0.0"},
+			{Name: "db", Version: "1.5.0"},
+		}, solution.Cookbooks)
+	}
+
+	// db is picked first, legacy forces a different version of it
+	solution, err = solver.Solve([]string{"db", "legacy"}, nil, "environment production")
+	assert.Nil(t, err)
+	if assert.NotNil(t, solution) {
+		assert.True(t, solution.Solved())
+		assert.Equal(t, []subject.CookbookVersion{
+			{Name: "db", Version: "1.0.0"},
+			{Name: "legacy", Version: "1.0.0"},
+		}, solution.Cookbooks)
+	}
+
+	// the metadata of every cookbook version is fetched only once
+	assert.ElementsMatch(t,
+		[]string{"app@2.0.0", "app@1.0.0", "db@1.5.0", "db@2.1.0", "legacy@1.0.0", "db@2.0.0", "db@1.0.0"},
+		cbi.requested)
+}
+
+func TestSolverConflict(t *testing.T) {
+	cbi, cookbooks := solverMock()
+	solution, err := subject.NewSolver(cbi, cookbooks).Solve(
+		[]string{"app", "db"},
+		map[string]string{"app": "= 2.0.0", "db": "< 2.0"},
+		"environment production",
+	)
+	assert.Nil(t, err)
+	if assert.NotNil(t, solution) {
+		assert.False(t, solution.Solved())
+		assert.Empty(t, solution.Cookbooks)
+		if assert.NotNil(t, solution.Conflict) {
+			assert.Equal(t, "db", solution.Conflict.Cookbook)
+			assert.False(t, solution.Conflict.NotUploaded)
+			assert.Equal(t,
+				"no uploaded version of db satisfies < 2.0 (environment production), ~> 2.0 (app 2.0.0)",
+				solution.Conflict.String())
+		}
+	}
+}
+
+func TestSolverNotUploaded(t *testing.T) {
+	cbi, cookbooks := solverMock()
+	solution, err := subject.NewSolver(cbi, cookbooks).Solve([]string{"web"}, nil, "environment production")
+	assert.Nil(t, err)
+	if assert.NotNil(t, solution) && assert.NotNil(t, solution.Conflict) {
+		assert.True(t, solution.Conflict.NotUploaded)
+		assert.Equal(t,
+			"cookbook missing is not uploaded, required by >= 0.0.0 (web 1.0.0)",
+			solution.Conflict.String())
+	}
+}
+
+func TestSolverInvalidConstraint(t *testing.T) {
+	cbi, cookbooks := solverMock()
+	solution, err := subject.NewSolver(cbi, cookbooks).Solve(
+		[]string{"db"}, map[string]string{"db": "~> one"}, "environment production",
+	)
+	assert.Nil(t, err)
+	if assert.NotNil(t, solution) {
+		assert.False(t, solution.Solved())
+	}
+}
+
+func TestSolverErrors(t *testing.T) {
+	cbi, cookbooks := solverMock()
+	delete(cbi.desiredDepends, "db@2.1.0")
+
+	solution, err := subject.NewSolver(cbi, cookbooks).Solve([]string{"db"}, nil, "environment production")
+	assert.Nil(t, solution)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "unable to get metadata of cookbook db (2.1.0)")
+	}
+
+	solver := subject.NewSolver(cbi, cookbooks)
+	solver.MaxSteps = 1
+	solution, err = solver.Solve([]string{"app"}, nil, "environment production")
+	assert.Nil(t, solution)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "unable to find a solution after trying 1 cookbook versions", err.Error())
+	}
+}
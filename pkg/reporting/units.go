@@ -0,0 +1,89 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// parses a duration that, besides the units supported by time.ParseDuration,
+// accepts days (d) and weeks (w), like '30d' or '2w'
+func ParseDuration(str string) (time.Duration, error) {
+	trimmed := strings.TrimSpace(str)
+
+	for suffix, unit := range map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	} {
+		if !strings.HasSuffix(trimmed, suffix) {
+			continue
+		}
+		n, err := strconv.ParseFloat(strings.TrimSuffix(trimmed, suffix), 64)
+		if err != nil || n < 0 {
+			return 0, errors.Errorf("invalid duration '%s'", str)
+		}
+		return time.Duration(n * float64(unit)), nil
+	}
+
+	d, err := time.ParseDuration(trimmed)
+	if err != nil || d < 0 {
+		return 0, errors.Errorf("invalid duration '%s'", str)
+	}
+	return d, nil
+}
+
+// byte size units, ordered from the longest suffix to the shortest
+var byteSizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"TB", 1 << 40},
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"T", 1 << 40},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// parses a size in bytes like '512MB', '2G' or '1024', units are powers of 1024
+func ParseByteSize(str string) (int64, error) {
+	var (
+		trimmed = strings.ToUpper(strings.TrimSpace(str))
+		unit    = int64(1)
+	)
+
+	for _, u := range byteSizeUnits {
+		if strings.HasSuffix(trimmed, u.suffix) {
+			trimmed = strings.TrimSpace(strings.TrimSuffix(trimmed, u.suffix))
+			unit = u.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil || n < 0 {
+		return 0, errors.Errorf("invalid size '%s'", str)
+	}
+	return int64(n * float64(unit)), nil
+}
@@ -0,0 +1,74 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func TestParseDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"30d":   30 * 24 * time.Hour,
+		"2w":    14 * 24 * time.Hour,
+		"1.5d":  36 * time.Hour,
+		"12h":   12 * time.Hour,
+		" 90m ": 90 * time.Minute,
+		"0":     0,
+	}
+	for str, expected := range cases {
+		actual, err := subject.ParseDuration(str)
+		if assert.Nil(t, err, str) {
+			assert.Equal(t, expected, actual, str)
+		}
+	}
+
+	for _, str := range []string{"", "d", "-1d", "thirty days", "-5h"} {
+		_, err := subject.ParseDuration(str)
+		if assert.NotNil(t, err, str) {
+			assert.Equal(t, "invalid duration '"+str+"'", err.Error())
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	cases := map[string]int64{
+		"1024":   1024,
+		"10B":    10,
+		"2kb":    2048,
+		"512MB":  512 << 20,
+		"1.5G":   3 << 29,
+		" 1 TB ": 1 << 40,
+	}
+	for str, expected := range cases {
+		actual, err := subject.ParseByteSize(str)
+		if assert.Nil(t, err, str) {
+			assert.Equal(t, expected, actual, str)
+		}
+	}
+
+	for _, str := range []string{"", "MB", "-1G", "lots"} {
+		_, err := subject.ParseByteSize(str)
+		if assert.NotNil(t, err, str) {
+			assert.Equal(t, "invalid size '"+str+"'", err.Error())
+		}
+	}
+}
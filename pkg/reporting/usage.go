@@ -0,0 +1,145 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CookbooksUsage is an in-memory index of the nodes using every cookbook version,
+// it is built with a single sweep of all the nodes instead of one search per
+// cookbook version, which reduces the load on the Chef Infra Server drastically
+type CookbooksUsage struct {
+	// cookbook name -> cookbook version -> list of nodes
+	index map[string]map[string][]string
+	// cookbook name -> cookbook version -> list of policies (policy_group/policy_name)
+	policies map[string]map[string][]string
+	// total number of nodes indexed
+	TotalNodes int
+	// number of nodes left out of the index because they are stale
+	StaleNodes int
+	// number of nodes that the Chef Infra Server reported but didn't return,
+	// the requestor doesn't have permissions to view them
+	HiddenNodes int
+}
+
+// builds the cookbooks usage index by doing a paginated partial search of
+// the cookbooks attribute of every node, when staleAfter is not zero, nodes
+// that haven't checked in within that period, or never did, are left out
+// of the index
+func NewCookbooksUsage(searcher SearchInterface, staleAfter time.Duration) (*CookbooksUsage, error) {
+	var (
+		usage = &CookbooksUsage{index: map[string]map[string][]string{}}
+		query = map[string]interface{}{
+			"name":      []string{"name"},
+			"cookbooks": []string{"cookbooks"},
+			"ohai_time": []string{"ohai_time"},
+		}
+		search = NewPartialSearch(searcher, "node", "*:*", query)
+		now    = time.Now()
+	)
+
+	for search.Next() {
+		for _, element := range search.Page() {
+			v := element.(map[string]interface{})["data"].(map[string]interface{})
+			if v == nil {
+				continue
+			}
+
+			if isStale(ohaiTimeToTime(safeFloatFromMap(v, "ohai_time")), staleAfter, now) {
+				usage.StaleNodes++
+				continue
+			}
+
+			usage.TotalNodes++
+
+			// nodes that have never converged won't have any cookbooks
+			cookbooks, ok := v["cookbooks"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			name := safeStringFromMap(v, "name")
+			for cookbook, details := range cookbooks {
+				detailsMap, ok := details.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				usage.Add(cookbook, safeStringFromMap(detailsMap, "version"), name)
+			}
+		}
+	}
+	if err := search.Err(); err != nil {
+		return nil, errors.Wrap(err, "unable to get cookbook usage information")
+	}
+	usage.HiddenNodes = search.Missing()
+
+	return usage, nil
+}
+
+// adds a node to the list of nodes using the provided cookbook version
+func (cu *CookbooksUsage) Add(cookbook, version, node string) {
+	if cu.index == nil {
+		cu.index = map[string]map[string][]string{}
+	}
+	if _, ok := cu.index[cookbook]; !ok {
+		cu.index[cookbook] = map[string][]string{}
+	}
+	cu.index[cookbook][version] = append(cu.index[cookbook][version], node)
+}
+
+// returns the list of nodes using the provided cookbook version
+func (cu *CookbooksUsage) NodesUsing(cookbook, version string) []string {
+	nodes := make([]string, 0)
+	if versions, ok := cu.index[cookbook]; ok {
+		nodes = append(nodes, versions[version]...)
+	}
+	return nodes
+}
+
+// adds a policy to the list of policies locking the provided cookbook version
+func (cu *CookbooksUsage) AddPolicy(cookbook, version, policyGroup, policyName string) {
+	if cu.policies == nil {
+		cu.policies = map[string]map[string][]string{}
+	}
+	if _, ok := cu.policies[cookbook]; !ok {
+		cu.policies[cookbook] = map[string][]string{}
+	}
+	cu.policies[cookbook][version] = append(cu.policies[cookbook][version], policyGroup+"/"+policyName)
+}
+
+// adds the cookbook versions locked by the provided policy revisions
+func (cu *CookbooksUsage) AddPolicyGroups(groups []*PolicyGroupRecord) {
+	for _, group := range groups {
+		for _, policy := range group.Policies {
+			for _, lock := range policy.CookbookLocks {
+				cu.AddPolicy(lock.Name, lock.Version, group.Name, policy.Name)
+			}
+		}
+	}
+}
+
+// returns the list of policies (policy_group/policy_name) locking the provided cookbook version
+func (cu *CookbooksUsage) PoliciesUsing(cookbook, version string) []string {
+	policies := make([]string, 0)
+	if versions, ok := cu.policies[cookbook]; ok {
+		policies = append(policies, versions[version]...)
+	}
+	return policies
+}
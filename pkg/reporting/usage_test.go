@@ -0,0 +1,77 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func TestNewCookbooksUsage(t *testing.T) {
+	usage, err := subject.NewCookbooksUsage(makeMockSearch(mockedNodesSearchRows(), nil), 0)
+	assert.Nil(t, err)
+	if assert.NotNil(t, usage) {
+		assert.Equal(t, 3, usage.TotalNodes)
+		assert.Equal(t, 0, usage.StaleNodes)
+		assert.ElementsMatch(t, []string{"node1", "node2"}, usage.NodesUsing("mycookbook", "1.0"))
+		assert.Equal(t, []string{"node2"}, usage.NodesUsing("test", "9.9"))
+		assert.Equal(t, []string{}, usage.NodesUsing("test", "1.0"))
+		assert.Equal(t, []string{}, usage.NodesUsing("foo", "1.0"))
+	}
+}
+
+func TestNewCookbooksUsageActiveOnly(t *testing.T) {
+	// node1 last checked in on 2019, node2 in the future and node3 never did
+	usage, err := subject.NewCookbooksUsage(makeMockSearch(mockedNodesSearchRows(), nil), 30*24*time.Hour)
+	assert.Nil(t, err)
+	if assert.NotNil(t, usage) {
+		assert.Equal(t, 1, usage.TotalNodes)
+		assert.Equal(t, 2, usage.StaleNodes)
+		assert.Equal(t, []string{"node2"}, usage.NodesUsing("mycookbook", "1.0"))
+	}
+}
+
+func TestNewCookbooksUsageHiddenNodes(t *testing.T) {
+	// the server reports 4 nodes but the requestor can only view 3 of them
+	usage, err := subject.NewCookbooksUsage(makeMockPaginatedSearch(mockedNodesSearchRows(), 1), 0)
+	assert.Nil(t, err)
+	if assert.NotNil(t, usage) {
+		assert.Equal(t, 3, usage.TotalNodes)
+		assert.Equal(t, 1, usage.HiddenNodes)
+	}
+}
+
+func TestNewCookbooksUsageError(t *testing.T) {
+	usage, err := subject.NewCookbooksUsage(makeMockSearch("", errors.New("lookup error")), 0)
+	assert.Nil(t, usage)
+	assert.EqualError(t, err, "unable to get cookbook usage information: lookup error")
+}
+
+func TestCookbooksUsageAdd(t *testing.T) {
+	var usage subject.CookbooksUsage
+	assert.Equal(t, []string{}, usage.NodesUsing("foo", "1.0"))
+	usage.Add("foo", "1.0", "node1")
+	usage.Add("foo", "1.0", "node2")
+	usage.Add("foo", "2.0", "node3")
+	assert.Equal(t, []string{"node1", "node2"}, usage.NodesUsing("foo", "1.0"))
+	assert.Equal(t, []string{"node3"}, usage.NodesUsing("foo", "2.0"))
+}
@@ -0,0 +1,190 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Version is a Chef cookbook version, it has the format 'x.y.z' or 'x.y'
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+	// number of parts the version was parsed from, required
+	// to evaluate the pessimistic operator (~>)
+	parts int
+}
+
+func ParseVersion(str string) (Version, error) {
+	var (
+		v      = Version{}
+		fields = strings.Split(strings.TrimSpace(str), ".")
+	)
+
+	if len(fields) < 1 || len(fields) > 3 {
+		return v, errors.Errorf("invalid version '%s'", str)
+	}
+
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return v, errors.Errorf("invalid version '%s'", str)
+		}
+		switch i {
+		case 0:
+			v.Major = n
+		case 1:
+			v.Minor = n
+		case 2:
+			v.Patch = n
+		}
+	}
+	v.parts = len(fields)
+
+	return v, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// returns -1, 0 or 1 if the version is lower, equal or greater than the provided one
+func (v Version) Compare(o Version) int {
+	switch {
+	case v.Major != o.Major:
+		return compareInts(v.Major, o.Major)
+	case v.Minor != o.Minor:
+		return compareInts(v.Minor, o.Minor)
+	default:
+		return compareInts(v.Patch, o.Patch)
+	}
+}
+
+func compareInts(a, b int) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// supported operators of a version constraint
+var versionConstraintOperators = []string{"~>", ">=", "<=", "!=", ">", "<", "="}
+
+// VersionConstraint is a Chef-style version constraint like '~> 5.0' or '>= 1.2.3'
+type VersionConstraint struct {
+	Operator string
+	Version  Version
+}
+
+func ParseVersionConstraint(str string) (VersionConstraint, error) {
+	var (
+		vc      = VersionConstraint{Operator: "="}
+		trimmed = strings.TrimSpace(str)
+	)
+
+	for _, op := range versionConstraintOperators {
+		if strings.HasPrefix(trimmed, op) {
+			vc.Operator = op
+			trimmed = strings.TrimPrefix(trimmed, op)
+			break
+		}
+	}
+
+	version, err := ParseVersion(trimmed)
+	if err != nil {
+		return vc, errors.Errorf("invalid version constraint '%s'", str)
+	}
+	vc.Version = version
+
+	return vc, nil
+}
+
+// returns true if the provided version satisfies the constraint
+func (vc VersionConstraint) Satisfies(v Version) bool {
+	cmp := v.Compare(vc.Version)
+	switch vc.Operator {
+	case ">=":
+		return cmp >= 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case "<":
+		return cmp < 0
+	case "!=":
+		return cmp != 0
+	case "~>":
+		if cmp < 0 {
+			return false
+		}
+		// '~> 5.0' allows any 5.x version and '~> 5.0.1' allows any 5.0.x version
+		if vc.Version.parts == 3 {
+			return v.Major == vc.Version.Major && v.Minor == vc.Version.Minor
+		}
+		return v.Major == vc.Version.Major
+	default:
+		return cmp == 0
+	}
+}
+
+// same as Satisfies() but it receives the version as a string, invalid
+// versions never satisfy a constraint
+func (vc VersionConstraint) SatisfiedBy(version string) bool {
+	v, err := ParseVersion(version)
+	if err != nil {
+		return false
+	}
+	return vc.Satisfies(v)
+}
+
+func (vc VersionConstraint) String() string {
+	var version string
+	switch vc.Version.parts {
+	case 1:
+		version = strconv.Itoa(vc.Version.Major)
+	case 2:
+		version = fmt.Sprintf("%d.%d", vc.Version.Major, vc.Version.Minor)
+	default:
+		version = vc.Version.String()
+	}
+	return fmt.Sprintf("%s %s", vc.Operator, version)
+}
+
+// returns true if the provided string is meant to be a version constraint,
+// that is, it starts with an operator or it is a bare version like '1.2.3',
+// names that start with a digit, like '7-zip', are not constraints
+func looksLikeVersionConstraint(str string) bool {
+	trimmed := strings.TrimSpace(str)
+	if trimmed == "" {
+		return false
+	}
+	for _, op := range versionConstraintOperators {
+		if strings.HasPrefix(trimmed, op) {
+			return true
+		}
+	}
+	_, err := ParseVersionConstraint(trimmed)
+	return err == nil
+}
@@ -0,0 +1,125 @@
+//
+// Copyright 2019 Chef Software, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package reporting_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	subject "github.com/chef/chef-analyze/pkg/reporting"
+)
+
+func TestParseVersion(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{"1.2.3", "1.2.3"},
+		{"1.2", "1.2.0"},
+		{"5", "5.0.0"},
+		{" 10.0.12 ", "10.0.12"},
+	}
+	for _, kase := range cases {
+		v, err := subject.ParseVersion(kase.input)
+		if assert.Nil(t, err) {
+			assert.Equal(t, kase.expected, v.String())
+		}
+	}
+
+	for _, invalid := range []string{"", "a.b.c", "1.2.3.4", "1..2", "-1.0", "1.0.0-beta"} {
+		_, err := subject.ParseVersion(invalid)
+		assert.NotNilf(t, err, "version '%s' should be invalid", invalid)
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.0", "1.0.0", 0},
+		{"1.0.1", "1.0.0", 1},
+		{"1.0.0", "1.1.0", -1},
+		{"2.0.0", "1.10.10", 1},
+		{"1.10.0", "1.9.0", 1},
+	}
+	for _, kase := range cases {
+		a, _ := subject.ParseVersion(kase.a)
+		b, _ := subject.ParseVersion(kase.b)
+		assert.Equalf(t, kase.expected, a.Compare(b), "%s <=> %s", kase.a, kase.b)
+	}
+}
+
+func TestVersionConstraintSatisfiedBy(t *testing.T) {
+	cases := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{"= 1.0.0", "1.0.0", true},
+		{"1.0.0", "1.0.0", true},
+		{"1.0.0", "1.0.1", false},
+		{"!= 1.0.0", "1.0.1", true},
+		{"!= 1.0.0", "1.0.0", false},
+		{">= 1.0", "1.0.0", true},
+		{">= 1.0", "0.9.9", false},
+		{"> 1.0", "1.0.0", false},
+		{"> 1.0", "1.0.1", true},
+		{"< 2.0", "1.99.99", true},
+		{"< 2.0", "2.0.0", false},
+		{"<= 2.0", "2.0.0", true},
+		{"~> 5.0", "5.0.0", true},
+		{"~> 5.0", "5.9.1", true},
+		{"~> 5.0", "6.0.0", false},
+		{"~> 5.0", "4.9.0", false},
+		{"~> 5.1", "5.0.9", false},
+		{"~> 5.0.1", "5.0.9", true},
+		{"~> 5.0.1", "5.0.0", false},
+		{"~> 5.0.1", "5.1.0", false},
+		{"~>5.0", "5.3.0", true},
+		{">= 1.0", "not-a-version", false},
+	}
+	for _, kase := range cases {
+		vc, err := subject.ParseVersionConstraint(kase.constraint)
+		if assert.Nil(t, err) {
+			assert.Equalf(t, kase.expected, vc.SatisfiedBy(kase.version),
+				"'%s' satisfied by '%s'", kase.constraint, kase.version)
+		}
+	}
+}
+
+func TestParseVersionConstraint(t *testing.T) {
+	vc, err := subject.ParseVersionConstraint("~> 5.0")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "~>", vc.Operator)
+		assert.Equal(t, "~> 5.0", vc.String())
+	}
+
+	vc, err = subject.ParseVersionConstraint("1.2.3")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "=", vc.Operator)
+		assert.Equal(t, "= 1.2.3", vc.String())
+	}
+
+	_, err = subject.ParseVersionConstraint("~> foo")
+	assert.EqualError(t, err, "invalid version constraint '~> foo'")
+
+	_, err = subject.ParseVersionConstraint("=> 1.0")
+	assert.EqualError(t, err, "invalid version constraint '=> 1.0'")
+}